@@ -0,0 +1,566 @@
+package main
+
+import (
+	"bufio"
+	authmodels "domain-max/pkg/auth/models"
+	"domain-max/pkg/auth/tokens"
+	"domain-max/pkg/config"
+	"domain-max/pkg/database"
+	"domain-max/pkg/dns/models"
+	"domain-max/pkg/dns/providers"
+	"domain-max/pkg/utils"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// command 命令行子命令
+type command struct {
+	name        string
+	description string
+	run         func(cfg *config.Config, args []string) error
+	unchecked   bool // 配置验证失败时仍然执行，由命令自行验证
+}
+
+// commands 支持的子命令，不带子命令时启动服务器
+var commands = []command{
+	{"rotate-key", "使用新的加密密钥重新加密DNS服务商配置", runRotateKey, false},
+	{"migrate", "执行数据库迁移：status、up、down", runMigrate, false},
+	{"create-admin", "创建管理员账号", runCreateAdmin, false},
+	{"reset-password", "重置用户密码", runResetPassword, false},
+	{"set-quota", "设置用户的DNS记录配额", runSetQuota, false},
+	{"export-zone", "将域名的DNS记录导出为BIND区域文件", runExportZone, false},
+	{"check-config", "检查配置、数据库连接和DNS服务商配置", runCheckConfig, true},
+}
+
+// runCommand 执行子命令
+func runCommand(name string, args []string) error {
+	for _, cmd := range commands {
+		if cmd.name != name {
+			continue
+		}
+
+		cfg := config.Parse()
+		registerDevProviders(cfg)
+		if !cmd.unchecked {
+			if err := cfg.Validate(); err != nil {
+				return fmt.Errorf("配置验证失败: %v", err)
+			}
+			if err := providers.SetEncryptionKey(cfg.EncryptionKey); err != nil {
+				return fmt.Errorf("加密密钥无效: %v", err)
+			}
+		}
+		return cmd.run(cfg, args)
+	}
+
+	printUsage()
+	if name == "help" || name == "-h" || name == "--help" {
+		return nil
+	}
+	return fmt.Errorf("未知命令: %s", name)
+}
+
+// printUsage 打印子命令列表
+func printUsage() {
+	fmt.Fprintf(os.Stderr, "用法: %s [命令] [参数]\n\n不带命令时启动服务器。可用命令:\n", os.Args[0])
+	for _, cmd := range commands {
+		fmt.Fprintf(os.Stderr, "  %-16s %s\n", cmd.name, cmd.description)
+	}
+}
+
+// openDB 连接数据库，命令自行输出结果，不打印SQL日志
+func openDB(cfg *config.Config) (*gorm.DB, error) {
+	db, err := database.Connect(cfg)
+	if err != nil {
+		return nil, err
+	}
+	db.Logger = logger.Default.LogMode(logger.Silent)
+	return db, nil
+}
+
+// runRotateKey 使用新密钥重新加密全部DNS服务商的敏感配置
+//
+// 旧密钥默认为当前的ENCRYPTION_KEY。完成后需要将ENCRYPTION_KEY更新为新密钥并重启服务。
+func runRotateKey(cfg *config.Config, args []string) error {
+	fs := flag.NewFlagSet("rotate-key", flag.ExitOnError)
+	oldKeyHex := fs.String("old-key", cfg.EncryptionKey, "原加密密钥（64位十六进制），默认为ENCRYPTION_KEY")
+	newKeyHex := fs.String("new-key", "", "新加密密钥（64位十六进制）")
+	fs.Parse(args)
+
+	if *newKeyHex == "" {
+		fs.Usage()
+		return errors.New("必须指定 -new-key")
+	}
+	oldKey, err := utils.ParseEncryptionKey(*oldKeyHex)
+	if err != nil {
+		return fmt.Errorf("原加密密钥无效: %v", err)
+	}
+	newKey, err := utils.ParseEncryptionKey(*newKeyHex)
+	if err != nil {
+		return fmt.Errorf("新加密密钥无效: %v", err)
+	}
+
+	db, err := openDB(cfg)
+	if err != nil {
+		return err
+	}
+
+	var count int
+	err = db.Transaction(func(tx *gorm.DB) error {
+		// 已删除的服务商也需要重新加密，避免恢复后无法解密
+		var items []models.DNSProvider
+		if err := tx.Unscoped().Find(&items).Error; err != nil {
+			return err
+		}
+
+		for _, item := range items {
+			rotated, err := providers.RotateConfig(item.Type, item.Config, oldKey, newKey)
+			if err != nil {
+				return fmt.Errorf("DNS服务商 %s(ID %d): %v", item.Name, item.ID, err)
+			}
+			if rotated == item.Config {
+				continue
+			}
+			if err := tx.Unscoped().Model(&item).Update("config", rotated).Error; err != nil {
+				return err
+			}
+			count++
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("重新加密失败，已回滚: %v", err)
+	}
+
+	fmt.Printf("已重新加密 %d 个DNS服务商配置，请将 ENCRYPTION_KEY 更新为新密钥后重启服务\n", count)
+	return nil
+}
+
+// runMigrate 查看、执行或回滚数据库迁移
+func runMigrate(cfg *config.Config, args []string) error {
+	fs := flag.NewFlagSet("migrate", flag.ExitOnError)
+	steps := fs.Int("steps", 0, "up时执行的迁移数量，默认全部；down时回滚的迁移数量，默认1")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "用法: %s migrate <status|up|down> [-steps N]\n", os.Args[0])
+		fs.PrintDefaults()
+	}
+
+	if len(args) == 0 {
+		fs.Usage()
+		return errors.New("必须指定 status、up 或 down")
+	}
+	action := args[0]
+	fs.Parse(args[1:])
+	if *steps < 0 {
+		return errors.New("-steps 不能为负数")
+	}
+
+	db, err := openDB(cfg)
+	if err != nil {
+		return err
+	}
+
+	switch action {
+	case "status":
+		states, err := database.MigrationStatus(db)
+		if err != nil {
+			return err
+		}
+		pending := 0
+		for _, state := range states {
+			status := "未执行"
+			switch {
+			case state.Unknown:
+				status = "已执行（当前程序中不存在）"
+			case state.AppliedAt != nil:
+				status = "已执行 " + state.AppliedAt.Format("2006-01-02 15:04:05")
+			default:
+				pending++
+			}
+			fmt.Printf("%4d  %-32s %s\n", state.Version, state.Description, status)
+		}
+		fmt.Printf("共 %d 个迁移，%d 个未执行\n", len(states), pending)
+		return nil
+	case "up":
+		applied, err := database.MigrateUp(db, *steps)
+		for _, m := range applied {
+			fmt.Printf("已执行迁移 %d: %s\n", m.Version, m.Description)
+		}
+		if err != nil {
+			return err
+		}
+		if len(applied) == 0 {
+			fmt.Println("没有需要执行的迁移")
+		}
+		return nil
+	case "down":
+		if *steps == 0 {
+			*steps = 1
+		}
+		reverted, err := database.MigrateDown(db, *steps)
+		for _, m := range reverted {
+			fmt.Printf("已回滚迁移 %d: %s\n", m.Version, m.Description)
+		}
+		if err != nil {
+			return err
+		}
+		if len(reverted) == 0 {
+			fmt.Println("没有可回滚的迁移")
+		}
+		return nil
+	default:
+		fs.Usage()
+		return fmt.Errorf("未知的迁移操作: %s", action)
+	}
+}
+
+// runCreateAdmin 创建已激活的管理员账号，未指定 -password 时从标准输入读取密码
+func runCreateAdmin(cfg *config.Config, args []string) error {
+	fs := flag.NewFlagSet("create-admin", flag.ExitOnError)
+	email := fs.String("email", "", "管理员邮箱")
+	password := fs.String("password", "", "密码，为空时从标准输入读取")
+	nickname := fs.String("nickname", "系统管理员", "昵称")
+	quota := fs.Int("quota", 1000, "DNS记录配额")
+	fs.Parse(args)
+
+	if err := authmodels.ValidateEmail(*email); err != nil {
+		fs.Usage()
+		return err
+	}
+	if err := authmodels.ValidateNickname(*nickname); err != nil {
+		return err
+	}
+	if *quota < 0 {
+		return errors.New("-quota 不能为负数")
+	}
+	hashed, err := readPasswordHash(*password)
+	if err != nil {
+		return err
+	}
+
+	db, err := openDB(cfg)
+	if err != nil {
+		return err
+	}
+
+	// 已删除的用户仍占用邮箱的唯一索引
+	var count int64
+	if err := db.Unscoped().Model(&authmodels.User{}).Where("email = ?", *email).Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return fmt.Errorf("用户 %s 已存在，可使用 reset-password 重置密码", *email)
+	}
+
+	now := time.Now()
+	user := authmodels.User{
+		Email:           *email,
+		Password:        hashed,
+		Nickname:        *nickname,
+		IsActive:        true,
+		EmailVerifiedAt: &now,
+		IsAdmin:         true,
+		DNSRecordQuota:  *quota,
+		Status:          "normal",
+	}
+	if err := db.Create(&user).Error; err != nil {
+		return fmt.Errorf("创建管理员失败: %v", err)
+	}
+
+	fmt.Printf("已创建管理员 %s（ID %d）\n", user.Email, user.ID)
+	return nil
+}
+
+// runResetPassword 重置用户密码，未指定 -password 时从标准输入读取密码
+func runResetPassword(cfg *config.Config, args []string) error {
+	fs := flag.NewFlagSet("reset-password", flag.ExitOnError)
+	email := fs.String("email", "", "用户邮箱")
+	password := fs.String("password", "", "新密码，为空时从标准输入读取")
+	fs.Parse(args)
+
+	if *email == "" {
+		fs.Usage()
+		return errors.New("必须指定 -email")
+	}
+
+	db, err := openDB(cfg)
+	if err != nil {
+		return err
+	}
+	user, err := findUser(db, *email)
+	if err != nil {
+		return err
+	}
+	hashed, err := readPasswordHash(*password)
+	if err != nil {
+		return err
+	}
+
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(user).Update("password", hashed).Error; err != nil {
+			return err
+		}
+		return tokens.RevokeUser(tx, user.ID)
+	})
+	if err != nil {
+		return fmt.Errorf("重置密码失败: %v", err)
+	}
+
+	fmt.Printf("已重置用户 %s 的密码，并已吊销其全部登录会话\n", user.Email)
+	return nil
+}
+
+// runSetQuota 设置用户的DNS记录配额
+func runSetQuota(cfg *config.Config, args []string) error {
+	fs := flag.NewFlagSet("set-quota", flag.ExitOnError)
+	email := fs.String("email", "", "用户邮箱")
+	quota := fs.Int("quota", -1, "DNS记录配额")
+	fs.Parse(args)
+
+	if *email == "" || *quota < 0 {
+		fs.Usage()
+		return errors.New("必须指定 -email 和不小于0的 -quota")
+	}
+
+	db, err := openDB(cfg)
+	if err != nil {
+		return err
+	}
+	user, err := findUser(db, *email)
+	if err != nil {
+		return err
+	}
+
+	previous := user.DNSRecordQuota
+	if err := db.Model(user).Update("dns_record_quota", *quota).Error; err != nil {
+		return fmt.Errorf("设置配额失败: %v", err)
+	}
+
+	var used int64
+	db.Model(&models.DNSRecord{}).Where("user_id = ?", user.ID).Count(&used)
+	fmt.Printf("用户 %s 的DNS记录配额已从 %d 改为 %d，当前已使用 %d\n", user.Email, previous, *quota, used)
+	return nil
+}
+
+// runExportZone 将域名的本地DNS记录导出为BIND区域文件
+func runExportZone(cfg *config.Config, args []string) error {
+	fs := flag.NewFlagSet("export-zone", flag.ExitOnError)
+	name := fs.String("domain", "", "域名，如 example.com")
+	output := fs.String("o", "", "输出文件，默认输出到标准输出")
+	fs.Parse(args)
+
+	if *name == "" {
+		fs.Usage()
+		return errors.New("必须指定 -domain")
+	}
+
+	db, err := openDB(cfg)
+	if err != nil {
+		return err
+	}
+
+	var domain models.Domain
+	if err := db.Where("name = ?", strings.ToLower(strings.TrimSuffix(*name, "."))).First(&domain).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return fmt.Errorf("域名 %s 不存在", *name)
+		}
+		return err
+	}
+
+	var rows []models.DNSRecord
+	if err := db.Where("domain_id = ?", domain.ID).Order("subdomain, type, id").Find(&rows).Error; err != nil {
+		return err
+	}
+	records := make([]providers.Record, 0, len(rows))
+	for i := range rows {
+		records = append(records, providers.FromDNSRecord(&rows[i]))
+	}
+
+	var w io.Writer = os.Stdout
+	if *output != "" {
+		f, err := os.Create(*output)
+		if err != nil {
+			return fmt.Errorf("创建输出文件失败: %v", err)
+		}
+		defer f.Close()
+		w = f
+	}
+	if err := providers.WriteZoneFile(w, domain.Name, records); err != nil {
+		return fmt.Errorf("导出区域文件失败: %v", err)
+	}
+
+	if *output != "" {
+		fmt.Fprintf(os.Stderr, "已导出 %s 的 %d 条记录到 %s\n", domain.Name, len(records), *output)
+	}
+	return nil
+}
+
+// runCheckConfig 检查配置是否可用于启动服务，有检查项失败时返回错误
+func runCheckConfig(cfg *config.Config, args []string) error {
+	fs := flag.NewFlagSet("check-config", flag.ExitOnError)
+	fs.Parse(args)
+
+	var report checkReport
+
+	fmt.Printf("运行环境: %s\n", cfg.Environment)
+	if cfg.DBType == "sqlite" {
+		fmt.Printf("数据库: sqlite %s\n", cfg.DBPath)
+	} else {
+		fmt.Printf("数据库: %s %s@%s:%s/%s\n", cfg.DBType, cfg.DBUser, cfg.DBHost, cfg.DBPort, cfg.DBName)
+	}
+	fmt.Printf("BASE_URL: %s\n\n", cfg.BaseURL)
+
+	if err := cfg.Validate(); err != nil {
+		report.fail("配置验证", err)
+	} else {
+		report.pass("配置验证")
+	}
+
+	keyErr := providers.SetEncryptionKey(cfg.EncryptionKey)
+	if keyErr != nil {
+		report.fail("加密密钥", keyErr)
+	} else {
+		report.pass("加密密钥")
+	}
+
+	db, err := openDB(cfg)
+	if err != nil {
+		report.fail("数据库连接", err)
+		return report.result()
+	}
+	report.pass("数据库连接")
+
+	states, err := database.MigrationStatus(db)
+	if err != nil {
+		report.fail("数据库迁移", err)
+		return report.result()
+	}
+	pending := 0
+	for _, state := range states {
+		if state.AppliedAt == nil {
+			pending++
+		}
+	}
+	if pending > 0 {
+		// 未执行的迁移在服务启动时自动执行，不影响其余检查
+		report.warn("数据库迁移", fmt.Sprintf("有 %d 个迁移未执行，可使用 migrate up 执行", pending))
+	} else {
+		report.pass("数据库迁移")
+	}
+
+	// 尚未执行过迁移的数据库没有数据表，跳过依赖数据表的检查
+	const noTable = "数据表尚未创建，执行迁移后再检查"
+
+	if !db.Migrator().HasTable(&models.DNSProvider{}) {
+		report.warn("DNS服务商配置", noTable)
+	} else if keyErr == nil {
+		var items []models.DNSProvider
+		if err := db.Find(&items).Error; err != nil {
+			report.fail("DNS服务商配置", err)
+		} else {
+			failed := 0
+			for _, item := range items {
+				if _, err := providers.DecryptConfig(item.Type, item.Config); err != nil {
+					report.fail("DNS服务商配置", fmt.Errorf("%s(ID %d): %v", item.Name, item.ID, err))
+					failed++
+				}
+			}
+			if failed == 0 {
+				report.pass(fmt.Sprintf("DNS服务商配置（%d 个）", len(items)))
+			}
+		}
+	}
+
+	var admins []authmodels.User
+	if !db.Migrator().HasTable(&authmodels.User{}) {
+		report.warn("管理员账号", noTable)
+	} else if err := db.Where("is_admin = ? AND is_active = ?", true, true).Find(&admins).Error; err != nil {
+		report.fail("管理员账号", err)
+	} else if len(admins) == 0 {
+		report.warn("管理员账号", "没有可用的管理员账号，可使用 create-admin 创建")
+	} else {
+		defaultPassword := false
+		for _, admin := range admins {
+			if admin.Email == "admin@example.com" && bcrypt.CompareHashAndPassword([]byte(admin.Password), []byte("admin123")) == nil {
+				defaultPassword = true
+			}
+		}
+		switch {
+		case !defaultPassword:
+			report.pass(fmt.Sprintf("管理员账号（%d 个）", len(admins)))
+		case cfg.Environment == "production":
+			report.fail("管理员账号", errors.New("默认管理员 admin@example.com 仍在使用初始密码，请使用 reset-password 修改"))
+		default:
+			report.warn("管理员账号", "默认管理员 admin@example.com 仍在使用初始密码")
+		}
+	}
+
+	return report.result()
+}
+
+// checkReport check-config的检查结果
+type checkReport struct {
+	failed int
+}
+
+func (r *checkReport) pass(name string) {
+	fmt.Printf("[通过] %s\n", name)
+}
+
+func (r *checkReport) warn(name, message string) {
+	fmt.Printf("[警告] %s: %s\n", name, message)
+}
+
+func (r *checkReport) fail(name string, err error) {
+	r.failed++
+	fmt.Printf("[失败] %s: %v\n", name, err)
+}
+
+// result 有检查项失败时返回错误
+func (r *checkReport) result() error {
+	if r.failed > 0 {
+		return fmt.Errorf("配置检查未通过，%d 项失败", r.failed)
+	}
+	return nil
+}
+
+// findUser 按邮箱查找用户
+func findUser(db *gorm.DB, email string) (*authmodels.User, error) {
+	var user authmodels.User
+	if err := db.Where("email = ?", email).First(&user).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("用户 %s 不存在", email)
+		}
+		return nil, err
+	}
+	return &user, nil
+}
+
+// readPasswordHash 验证密码强度并返回bcrypt哈希，password为空时从标准输入读取一行
+func readPasswordHash(password string) (string, error) {
+	if password == "" {
+		fmt.Fprint(os.Stderr, "请输入密码: ")
+		line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+		if err != nil && (err != io.EOF || line == "") {
+			return "", errors.New("读取密码失败")
+		}
+		password = strings.TrimRight(line, "\r\n")
+	}
+	if err := authmodels.ValidatePassword(password); err != nil {
+		return "", err
+	}
+
+	hashed, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", fmt.Errorf("密码加密失败: %v", err)
+	}
+	return string(hashed), nil
+}
@@ -1,41 +1,79 @@
 package main
 
 import (
+	"context"
 	"domain-max/pkg/api"
+	authmodels "domain-max/pkg/auth/models"
+	"domain-max/pkg/auth/tokens"
 	"domain-max/pkg/config"
 	"domain-max/pkg/database"
+	"domain-max/pkg/dns/providers"
+	"domain-max/pkg/dns/reconcile"
+	"domain-max/pkg/dns/syncqueue"
+	"domain-max/pkg/email/mailqueue"
 	"domain-max/pkg/middleware"
 	"log"
 	"os"
 	"path/filepath"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"golang.org/x/crypto/bcrypt"
 	"gorm.io/gorm"
 )
 
 func main() {
+	// 执行子命令
+	if len(os.Args) > 1 {
+		if err := runCommand(os.Args[1], os.Args[2:]); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
 	// 加载配置
 	cfg := config.Load()
 
+	registerDevProviders(cfg)
+
+	// 设置DNS服务商敏感配置的加密密钥
+	if err := providers.SetEncryptionKey(cfg.EncryptionKey); err != nil {
+		log.Fatal("加密密钥无效:", err)
+	}
+
 	// 连接数据库
-	var db *gorm.DB
-	var err error
-	
-	if cfg.Environment == "development" {
-		log.Println("开发环境：跳过数据库连接")
-		db = nil
-	} else {
-		db, err = database.Connect(cfg)
-		if err != nil {
-			log.Fatal("数据库连接失败:", err)
-		}
+	db, err := database.Connect(cfg)
+	if err != nil {
+		log.Fatal("数据库连接失败:", err)
+	}
 
-		// 自动迁移数据库表
-		if err := database.Migrate(db); err != nil {
-			log.Fatal("数据库迁移失败:", err)
-		}
+	// 执行未执行的数据库迁移
+	if err := database.Migrate(db); err != nil {
+		log.Fatal("数据库迁移失败:", err)
+	}
+
+	// 检查管理员账号，开发环境自动创建默认管理员
+	if err := ensureAdmin(db, cfg); err != nil {
+		log.Fatal("创建默认管理员失败:", err)
+	}
+
+	// 后台将DNS记录的变更同步到服务商
+	go syncqueue.NewWorker(db).Run(context.Background())
+
+	// 定期检测本地DNS记录与服务商区域的差异
+	if cfg.DriftCheckInterval > 0 {
+		go reconcile.NewChecker(db).Run(context.Background(), time.Duration(cfg.DriftCheckInterval)*time.Minute)
 	}
 
+	// 后台发送邮件队列中的邮件
+	go mailqueue.NewWorker(db, cfg.MailWorkers).Run(context.Background())
+
+	// 定期清理已完成的邮件和投递记录
+	go mailqueue.RunCleanup(context.Background(), db, time.Hour)
+
+	// 定期清理过期的刷新令牌和吊销记录
+	go tokens.RunCleanup(context.Background(), db, time.Hour)
+
 	// 设置Gin模式
 	if cfg.Environment == "production" {
 		gin.SetMode(gin.ReleaseMode)
@@ -65,6 +103,67 @@ func main() {
 	log.Fatal(router.Run(":" + cfg.Port))
 }
 
+// mockDataDir 开发环境模拟服务商保存数据文件的目录
+const mockDataDir = "data/mock-dns"
+
+// registerDevProviders 开发环境注册模拟DNS服务商，生产环境不可用
+func registerDevProviders(cfg *config.Config) {
+	if cfg.Environment == "development" {
+		providers.RegisterMock(mockDataDir)
+	}
+}
+
+// 开发环境的默认管理员账号
+const (
+	devAdminEmail    = "admin@example.com"
+	devAdminPassword = "admin123"
+)
+
+// ensureAdmin 没有管理员时，开发环境创建默认管理员，其他环境提示使用 create-admin 创建
+func ensureAdmin(db *gorm.DB, cfg *config.Config) error {
+	var count int64
+	if err := db.Model(&authmodels.User{}).Where("is_admin = ?", true).Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return nil
+	}
+	if cfg.Environment != "development" {
+		log.Printf("警告: 没有管理员账号，请使用 %s create-admin 创建", os.Args[0])
+		return nil
+	}
+
+	// 已删除的用户仍占用邮箱的唯一索引
+	if err := db.Unscoped().Model(&authmodels.User{}).Where("email = ?", devAdminEmail).Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		log.Printf("警告: 没有管理员账号，%s 已被占用，请使用 %s create-admin 创建", devAdminEmail, os.Args[0])
+		return nil
+	}
+
+	hashed, err := bcrypt.GenerateFromPassword([]byte(devAdminPassword), bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	admin := authmodels.User{
+		Email:           devAdminEmail,
+		Password:        string(hashed),
+		Nickname:        "系统管理员",
+		IsActive:        true,
+		EmailVerifiedAt: &now,
+		IsAdmin:         true,
+		DNSRecordQuota:  1000,
+		Status:          "normal",
+	}
+	if err := db.Create(&admin).Error; err != nil {
+		return err
+	}
+	log.Printf("开发环境已创建默认管理员 %s，密码为 %s", devAdminEmail, devAdminPassword)
+	return nil
+}
+
 func setupWebRoutes(router *gin.Engine) {
 	// 检查web/dist目录是否存在
 	webDistPath := "web/dist"
@@ -2,12 +2,19 @@ package api
 
 import (
 	"domain-max/pkg/auth/models"
+	"domain-max/pkg/auth/tokens"
 	"domain-max/pkg/config"
+	"domain-max/pkg/email"
+	"domain-max/pkg/email/mailqueue"
+	"domain-max/pkg/middleware"
+	"errors"
+	"fmt"
+	"log"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
-	"github.com/golang-jwt/jwt/v5"
 	"golang.org/x/crypto/bcrypt"
 	"gorm.io/gorm"
 )
@@ -61,18 +68,29 @@ func (h *AuthHandler) Register(c *gin.Context) {
 		Email:          req.Email,
 		Password:       string(hashedPassword),
 		Nickname:       req.Nickname,
-		IsActive:       false, // 默认不激活，需要邮箱验证
+		IsActive:       true, // 表示未被管理员禁用，登录还要求EmailVerifiedAt已设置
 		IsAdmin:        false,
 		DNSRecordQuota: 10, // 默认配额
 		Status:         "normal",
 	}
 
-	if err := h.db.Create(&user).Error; err != nil {
+	// 验证邮件与用户一起写入，由后台任务发送
+	locale := email.MatchLocale(c.GetHeader("Accept-Language"))
+	err = h.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&user).Error; err != nil {
+			return err
+		}
+		token, err := tokens.IssueEmailVerification(tx, user.Email)
+		if err != nil {
+			return err
+		}
+		return h.queueVerificationEmail(tx, user, token, locale)
+	})
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "用户创建失败"})
 		return
 	}
-
-	// TODO: 发送邮箱验证邮件
+	mailqueue.Notify()
 
 	c.JSON(http.StatusCreated, gin.H{
 		"message": "用户注册成功，请查收验证邮件",
@@ -84,6 +102,75 @@ func (h *AuthHandler) Register(c *gin.Context) {
 	})
 }
 
+// VerifyEmail 验证邮箱，令牌可以通过查询参数或JSON提交
+func (h *AuthHandler) VerifyEmail(c *gin.Context) {
+	var req models.VerifyEmailRequest
+	if err := c.ShouldBind(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, err := tokens.VerifyEmail(h.db, req.Token)
+	if err != nil {
+		if errors.Is(err, tokens.ErrInvalidVerificationToken) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "邮箱验证失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "邮箱验证成功，请登录",
+		"user": gin.H{
+			"id":    user.ID,
+			"email": user.Email,
+		},
+	})
+}
+
+// ResendVerification 重新发送验证邮件
+func (h *AuthHandler) ResendVerification(c *gin.Context) {
+	var req models.ResendVerificationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// 为了安全，邮箱不存在或已验证时同样返回成功
+	response := gin.H{"message": "如果邮箱已注册且尚未验证，验证邮件已发送"}
+
+	var user models.User
+	if err := h.db.Where("email = ?", req.Email).First(&user).Error; err != nil {
+		c.JSON(http.StatusOK, response)
+		return
+	}
+	if user.EmailVerifiedAt != nil || !user.IsActive || user.Status != "normal" {
+		c.JSON(http.StatusOK, response)
+		return
+	}
+
+	locale := email.MatchLocale(c.GetHeader("Accept-Language"))
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		token, err := tokens.IssueEmailVerification(tx, user.Email)
+		if err != nil {
+			return err
+		}
+		return h.queueVerificationEmail(tx, user, token, locale)
+	})
+	if err != nil {
+		if errors.Is(err, tokens.ErrVerificationThrottled) {
+			c.JSON(http.StatusTooManyRequests, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "生成验证链接失败"})
+		return
+	}
+	mailqueue.Notify()
+
+	c.JSON(http.StatusOK, response)
+}
+
 // Login 用户登录
 func (h *AuthHandler) Login(c *gin.Context) {
 	var req models.LoginRequest
@@ -101,6 +188,11 @@ func (h *AuthHandler) Login(c *gin.Context) {
 
 	// 检查用户状态
 	if !user.IsActive {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "账户已被禁用，请联系管理员"})
+		return
+	}
+
+	if user.EmailVerifiedAt == nil {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "账户未激活，请查收验证邮件"})
 		return
 	}
@@ -116,8 +208,8 @@ func (h *AuthHandler) Login(c *gin.Context) {
 		return
 	}
 
-	// 生成JWT token
-	token, err := h.generateJWTToken(user)
+	// 签发登录令牌和刷新令牌
+	pair, err := tokens.Issue(h.db, h.jwtSecret, &user)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "生成登录令牌失败"})
 		return
@@ -132,21 +224,81 @@ func (h *AuthHandler) Login(c *gin.Context) {
 	}
 
 	c.JSON(http.StatusOK, models.LoginResponse{
-		Token: token,
-		User:  user,
+		Token:            pair.AccessToken,
+		ExpiresAt:        pair.AccessExpiresAt,
+		RefreshToken:     pair.RefreshToken,
+		RefreshExpiresAt: pair.RefreshExpiresAt,
+		User:             user,
+	})
+}
+
+// Refresh 使用刷新令牌换取新的登录令牌
+func (h *AuthHandler) Refresh(c *gin.Context) {
+	var req models.RefreshRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	pair, user, err := tokens.Rotate(h.db, h.jwtSecret, req.RefreshToken)
+	if err != nil {
+		switch {
+		case errors.Is(err, tokens.ErrInvalidRefreshToken),
+			errors.Is(err, tokens.ErrRefreshTokenReused),
+			errors.Is(err, tokens.ErrUserUnavailable):
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "刷新登录令牌失败"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, models.LoginResponse{
+		Token:            pair.AccessToken,
+		ExpiresAt:        pair.AccessExpiresAt,
+		RefreshToken:     pair.RefreshToken,
+		RefreshExpiresAt: pair.RefreshExpiresAt,
+		User:             *user,
+	})
+}
+
+// Logout 退出登录，吊销当前登录令牌和刷新令牌
+func (h *AuthHandler) Logout(c *gin.Context) {
+	identity, ok := middleware.CurrentUser(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "用户未认证"})
+		return
+	}
+
+	// 请求体可以为空，此时按当前登录令牌查找刷新令牌
+	var req models.LogoutRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	if err := tokens.Logout(h.db, identity, req.RefreshToken); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "退出登录失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "已退出登录",
 	})
 }
 
 // GetProfile 获取用户资料
 func (h *AuthHandler) GetProfile(c *gin.Context) {
-	userID, exists := c.Get("user_id")
-	if !exists {
+	identity, ok := middleware.CurrentUser(c)
+	if !ok {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "用户未认证"})
 		return
 	}
 
 	var user models.User
-	if err := h.db.First(&user, userID).Error; err != nil {
+	if err := h.db.First(&user, identity.UserID).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "用户不存在"})
 		return
 	}
@@ -158,8 +310,8 @@ func (h *AuthHandler) GetProfile(c *gin.Context) {
 
 // UpdateProfile 更新用户资料
 func (h *AuthHandler) UpdateProfile(c *gin.Context) {
-	userID, exists := c.Get("user_id")
-	if !exists {
+	identity, ok := middleware.CurrentUser(c)
+	if !ok {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "用户未认证"})
 		return
 	}
@@ -181,7 +333,7 @@ func (h *AuthHandler) UpdateProfile(c *gin.Context) {
 	}
 
 	// 更新用户信息
-	if err := h.db.Model(&models.User{}).Where("id = ?", userID).Updates(map[string]interface{}{
+	if err := h.db.Model(&models.User{}).Where("id = ?", identity.UserID).Updates(map[string]interface{}{
 		"nickname": updateData.Nickname,
 		"avatar":   updateData.Avatar,
 	}).Error; err != nil {
@@ -196,8 +348,8 @@ func (h *AuthHandler) UpdateProfile(c *gin.Context) {
 
 // ChangePassword 修改密码
 func (h *AuthHandler) ChangePassword(c *gin.Context) {
-	userID, exists := c.Get("user_id")
-	if !exists {
+	identity, ok := middleware.CurrentUser(c)
+	if !ok {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "用户未认证"})
 		return
 	}
@@ -220,7 +372,7 @@ func (h *AuthHandler) ChangePassword(c *gin.Context) {
 
 	// 获取用户信息
 	var user models.User
-	if err := h.db.First(&user, userID).Error; err != nil {
+	if err := h.db.First(&user, identity.UserID).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "用户不存在"})
 		return
 	}
@@ -238,14 +390,20 @@ func (h *AuthHandler) ChangePassword(c *gin.Context) {
 		return
 	}
 
-	// 更新密码
-	if err := h.db.Model(&user).Update("password", string(hashedPassword)).Error; err != nil {
+	// 更新密码并吊销全部会话
+	err = h.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&user).Update("password", string(hashedPassword)).Error; err != nil {
+			return err
+		}
+		return tokens.RevokeUser(tx, user.ID)
+	})
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "密码更新失败"})
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"message": "密码修改成功",
+		"message": "密码修改成功，请重新登录",
 	})
 }
 
@@ -268,14 +426,27 @@ func (h *AuthHandler) ForgotPassword(c *gin.Context) {
 	}
 
 	// 检查用户状态
-	if !user.IsActive || user.Status != "normal" {
+	if !user.IsActive || user.Status != "normal" || user.EmailVerifiedAt == nil {
 		c.JSON(http.StatusOK, gin.H{
 			"message": "如果邮箱存在，重置密码链接已发送",
 		})
 		return
 	}
 
-	// TODO: 生成密码重置令牌并发送邮件
+	// 生成重置令牌并写入重置邮件，失败时只记录日志，避免暴露邮箱是否存在
+	locale := email.MatchLocale(c.GetHeader("Accept-Language"))
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		token, err := tokens.IssuePasswordReset(tx, user.Email)
+		if err != nil {
+			return err
+		}
+		return h.queuePasswordResetEmail(tx, user, token, locale)
+	})
+	if err == nil {
+		mailqueue.Notify()
+	} else if !errors.Is(err, tokens.ErrPasswordResetThrottled) {
+		log.Printf("生成 %s 的密码重置邮件失败: %v", user.Email, err)
+	}
 
 	c.JSON(http.StatusOK, gin.H{
 		"message": "如果邮箱存在，重置密码链接已发送",
@@ -296,24 +467,69 @@ func (h *AuthHandler) ResetPassword(c *gin.Context) {
 		return
 	}
 
-	// TODO: 验证重置令牌
+	// 加密新密码
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "密码加密失败"})
+		return
+	}
 
-	// TODO: 找到对应的用户并更新密码
+	// 验证重置令牌，更新密码并吊销全部会话
+	if _, err := tokens.ResetPassword(h.db, req.Token, string(hashedPassword)); err != nil {
+		switch {
+		case errors.Is(err, tokens.ErrInvalidResetToken):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, tokens.ErrUserUnavailable):
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "密码重置失败"})
+		}
+		return
+	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"message": "密码重置成功",
+		"message": "密码重置成功，请使用新密码登录",
 	})
 }
 
-// generateJWTToken 生成JWT令牌
-func (h *AuthHandler) generateJWTToken(user models.User) (string, error) {
-	claims := jwt.MapClaims{
-		"user_id":  user.ID,
-		"email":    user.Email,
-		"is_admin": user.IsAdmin,
-		"exp":      time.Now().Add(time.Hour * 24).Unix(), // 24小时过期
+// queueVerificationEmail 在事务中写入邮箱验证邮件
+func (h *AuthHandler) queueVerificationEmail(tx *gorm.DB, user models.User, token, locale string) error {
+	link := fmt.Sprintf("%s/api/auth/verify-email?token=%s", strings.TrimSuffix(h.cfg.BaseURL, "/"), token)
+	return h.queueLinkEmail(tx, user.Email, email.TemplateVerification, locale, email.VerificationData{
+		Name:       displayName(user),
+		Link:       link,
+		ValidHours: int(tokens.EmailVerificationTTL.Hours()),
+	}, link)
+}
+
+// queuePasswordResetEmail 在事务中写入密码重置邮件，链接指向前端的重置密码页面
+func (h *AuthHandler) queuePasswordResetEmail(tx *gorm.DB, user models.User, token, locale string) error {
+	link := fmt.Sprintf("%s/reset-password?token=%s", strings.TrimSuffix(h.cfg.BaseURL, "/"), token)
+	return h.queueLinkEmail(tx, user.Email, email.TemplatePasswordReset, locale, email.PasswordResetData{
+		Name:         displayName(user),
+		Link:         link,
+		ValidMinutes: int(tokens.PasswordResetTTL.Minutes()),
+	}, link)
+}
+
+// queueLinkEmail 在事务中写入包含链接的模板邮件
+func (h *AuthHandler) queueLinkEmail(tx *gorm.DB, to, template, locale string, data interface{}, link string) error {
+	if _, err := mailqueue.EnqueueTemplate(tx, to, template, locale, data); err != nil {
+		return err
+	}
+	// 开发环境未配置SMTP时将链接输出到日志，便于本地调试
+	if h.cfg.Environment == "development" {
+		if _, err := email.ActiveConfigs(tx); errors.Is(err, email.ErrNoSMTPConfig) {
+			log.Printf("未配置SMTP，发送给 %s 的链接: %s", to, link)
+		}
 	}
+	return nil
+}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString([]byte(h.jwtSecret))
-}
\ No newline at end of file
+// displayName 邮件中称呼用户的名称
+func displayName(user models.User) string {
+	if user.Nickname != "" {
+		return user.Nickname
+	}
+	return user.Email
+}
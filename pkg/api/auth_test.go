@@ -0,0 +1,144 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"testing"
+
+	authmodels "domain-max/pkg/auth/models"
+	emailmodels "domain-max/pkg/email/models"
+)
+
+var verifyLinkPattern = regexp.MustCompile(`verify-email\?token=([0-9a-f]+)`)
+
+// register 注册用户并返回验证邮件中的令牌
+func (s *testServer) register(email, password string) string {
+	s.t.Helper()
+
+	code := s.do(http.MethodPost, "/api/auth/register", "", map[string]string{
+		"email":            email,
+		"password":         password,
+		"confirm_password": password,
+	}, nil)
+	if code != http.StatusCreated {
+		s.t.Fatalf("注册返回 %d", code)
+	}
+	return s.lastVerificationToken(email)
+}
+
+// lastVerificationToken 返回最近一封发给email的验证邮件中的令牌
+func (s *testServer) lastVerificationToken(email string) string {
+	s.t.Helper()
+
+	var job emailmodels.MailJob
+	if err := s.db.Where("recipient = ?", email).Order("id DESC").First(&job).Error; err != nil {
+		s.t.Fatalf("查询验证邮件失败: %v", err)
+	}
+	match := verifyLinkPattern.FindStringSubmatch(job.TextBody)
+	if match == nil {
+		s.t.Fatalf("验证邮件中没有验证链接: %s", job.TextBody)
+	}
+	return match[1]
+}
+
+// login 登录并返回状态码和错误信息
+func (s *testServer) login(email, password string) (int, string) {
+	s.t.Helper()
+
+	var resp struct {
+		Error string `json:"error"`
+	}
+	code := s.do(http.MethodPost, "/api/auth/login", "", map[string]string{
+		"email":    email,
+		"password": password,
+	}, &resp)
+	return code, resp.Error
+}
+
+// user 按邮箱查询用户
+func (s *testServer) user(email string) *authmodels.User {
+	s.t.Helper()
+
+	var user authmodels.User
+	if err := s.db.Where("email = ?", email).First(&user).Error; err != nil {
+		s.t.Fatalf("查询用户失败: %v", err)
+	}
+	return &user
+}
+
+func TestVerifyEmailAllowsLogin(t *testing.T) {
+	s := newTestServer(t)
+	const email, password = "new@example.com", "Passw0rd!x"
+
+	token := s.register(email, password)
+	if code, msg := s.login(email, password); code != http.StatusUnauthorized {
+		t.Fatalf("未验证邮箱时登录返回 %d %s", code, msg)
+	}
+
+	if code := s.do(http.MethodGet, "/api/auth/verify-email?token="+token, "", nil, nil); code != http.StatusOK {
+		t.Fatalf("验证邮箱返回 %d", code)
+	}
+	if user := s.user(email); user.EmailVerifiedAt == nil || !user.IsActive {
+		t.Fatalf("验证后的用户为 %+v", user)
+	}
+	if code, msg := s.login(email, password); code != http.StatusOK {
+		t.Fatalf("验证后登录返回 %d %s", code, msg)
+	}
+
+	// 令牌只能使用一次
+	if code := s.do(http.MethodGet, "/api/auth/verify-email?token="+token, "", nil, nil); code != http.StatusBadRequest {
+		t.Fatalf("重复使用令牌返回 %d", code)
+	}
+}
+
+func TestVerifyEmailKeepsAdminDeactivation(t *testing.T) {
+	tests := []struct {
+		name     string
+		verified bool // 禁用前是否已验证邮箱
+	}{
+		{name: "unverified"},
+		{name: "verified", verified: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestServer(t)
+			admin := s.createUser("admin@example.com", true)
+			const email, password = "user@example.com", "Passw0rd!x"
+
+			token := s.register(email, password)
+			if tt.verified {
+				if code := s.do(http.MethodGet, "/api/auth/verify-email?token="+token, "", nil, nil); code != http.StatusOK {
+					t.Fatalf("验证邮箱返回 %d", code)
+				}
+			}
+
+			user := s.user(email)
+			path := fmt.Sprintf("/api/users/%d", user.ID)
+			if code := s.do(http.MethodPut, path, s.token(admin), map[string]interface{}{"is_active": false}, nil); code != http.StatusOK {
+				t.Fatalf("禁用用户返回 %d", code)
+			}
+
+			// 被禁用的账户不会收到新的验证邮件
+			var before, after int64
+			s.db.Model(&emailmodels.MailJob{}).Where("recipient = ?", email).Count(&before)
+			if code := s.do(http.MethodPost, "/api/auth/resend-verification", "", map[string]string{"email": email}, nil); code != http.StatusOK {
+				t.Fatalf("重新发送验证邮件返回 %d", code)
+			}
+			s.db.Model(&emailmodels.MailJob{}).Where("recipient = ?", email).Count(&after)
+			if after != before {
+				t.Fatalf("被禁用的账户收到了新的验证邮件")
+			}
+
+			// 使用禁用前收到的链接验证邮箱不会重新启用账户
+			s.do(http.MethodGet, "/api/auth/verify-email?token="+token, "", nil, nil)
+			if user := s.user(email); user.IsActive {
+				t.Fatalf("验证邮箱后被禁用的账户重新启用: %+v", user)
+			}
+			if code, msg := s.login(email, password); code != http.StatusUnauthorized || msg != "账户已被禁用，请联系管理员" {
+				t.Fatalf("被禁用的账户登录返回 %d %s", code, msg)
+			}
+		})
+	}
+}
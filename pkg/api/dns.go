@@ -3,13 +3,20 @@ package api
 import (
 	authmodels "domain-max/pkg/auth/models"
 	"domain-max/pkg/dns/models"
+	"domain-max/pkg/dns/syncqueue"
+	"domain-max/pkg/middleware"
+	"errors"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 )
 
+// providerRequestTimeout 单次请求DNS服务商的超时时间
+const providerRequestTimeout = 30 * time.Second
+
 // DNSHandler DNS记录处理器
 type DNSHandler struct {
 	db *gorm.DB
@@ -22,8 +29,8 @@ func NewDNSHandler(db *gorm.DB) *DNSHandler {
 
 // ListDNSRecords 获取DNS记录列表
 func (h *DNSHandler) ListDNSRecords(c *gin.Context) {
-	userID, exists := c.Get("user_id")
-	if !exists {
+	identity, ok := middleware.CurrentUser(c)
+	if !ok {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "用户未认证"})
 		return
 	}
@@ -43,7 +50,7 @@ func (h *DNSHandler) ListDNSRecords(c *gin.Context) {
 	}
 
 	// 构建查询
-	query := h.db.Model(&models.DNSRecord{}).Where("user_id = ?", userID)
+	query := h.db.Model(&models.DNSRecord{}).Where("user_id = ?", identity.UserID)
 
 	if domainID != "" {
 		query = query.Where("domain_id = ?", domainID)
@@ -80,15 +87,15 @@ func (h *DNSHandler) ListDNSRecords(c *gin.Context) {
 
 // GetDNSRecord 获取单个DNS记录
 func (h *DNSHandler) GetDNSRecord(c *gin.Context) {
-	userID, exists := c.Get("user_id")
-	if !exists {
+	identity, ok := middleware.CurrentUser(c)
+	if !ok {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "用户未认证"})
 		return
 	}
 
 	id := c.Param("id")
 	var record models.DNSRecord
-	if err := h.db.Preload("Domain").Where("id = ? AND user_id = ?", id, userID).First(&record).Error; err != nil {
+	if err := h.db.Preload("Domain").Where("id = ? AND user_id = ?", id, identity.UserID).First(&record).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
 			c.JSON(http.StatusNotFound, gin.H{"error": "记录不存在"})
 			return
@@ -97,15 +104,73 @@ func (h *DNSHandler) GetDNSRecord(c *gin.Context) {
 		return
 	}
 
+	syncs, err := h.recordSyncStates(&record)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "查询失败"})
+		return
+	}
+
 	c.JSON(http.StatusOK, gin.H{
 		"record": record,
+		"syncs":  syncs,
 	})
 }
 
+// recordSyncState DNS记录在单个DNS服务商中的同步状态
+type recordSyncState struct {
+	ProviderID   uint       `json:"provider_id"`
+	ProviderName string     `json:"provider_name"`
+	ProviderType string     `json:"provider_type"`
+	Role         string     `json:"role"` // primary：主服务商，secondary：备用服务商
+	ExternalID   string     `json:"external_id"`
+	Status       string     `json:"status"`
+	SyncError    string     `json:"sync_error"`
+	SyncedAt     *time.Time `json:"synced_at"`
+}
+
+// recordSyncStates 返回记录在域名绑定的各DNS服务商中的同步状态，record需已加载Domain
+func (h *DNSHandler) recordSyncStates(record *models.DNSRecord) ([]recordSyncState, error) {
+	states := make([]recordSyncState, 0, 2)
+	for i, providerID := range record.Domain.ProviderIDs() {
+		var provider models.DNSProvider
+		if err := h.db.Unscoped().First(&provider, providerID).Error; err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, err
+		}
+
+		var sync models.RecordSync
+		if err := h.db.Where(models.RecordSync{RecordID: record.ID, ProviderID: providerID}).FirstOrInit(&sync).Error; err != nil {
+			return nil, err
+		}
+
+		state := recordSyncState{
+			ProviderID:   providerID,
+			ProviderName: provider.Name,
+			ProviderType: provider.Type,
+			Role:         "primary",
+			ExternalID:   sync.ExternalID,
+			Status:       sync.Status,
+			SyncError:    sync.SyncError,
+			SyncedAt:     sync.SyncedAt,
+		}
+		if i > 0 {
+			state.Role = "secondary"
+		}
+		// 早期同步的记录只在记录中保存了主服务商的同步状态
+		if sync.ID == 0 && i == 0 {
+			state.ExternalID = record.ExternalID
+			state.Status = record.Status
+			state.SyncError = record.SyncError
+			state.SyncedAt = record.SyncedAt
+		}
+		states = append(states, state)
+	}
+	return states, nil
+}
+
 // CreateDNSRecord 创建DNS记录
 func (h *DNSHandler) CreateDNSRecord(c *gin.Context) {
-	userID, exists := c.Get("user_id")
-	if !exists {
+	identity, ok := middleware.CurrentUser(c)
+	if !ok {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "用户未认证"})
 		return
 	}
@@ -118,14 +183,14 @@ func (h *DNSHandler) CreateDNSRecord(c *gin.Context) {
 
 	// 检查用户配额
 	var user authmodels.User
-	if err := h.db.First(&user, userID).Error; err != nil {
+	if err := h.db.First(&user, identity.UserID).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "用户不存在"})
 		return
 	}
 
 	// 统计当前DNS记录数量
 	var count int64
-	if err := h.db.Model(&models.DNSRecord{}).Where("user_id = ?", userID).Count(&count).Error; err != nil {
+	if err := h.db.Model(&models.DNSRecord{}).Where("user_id = ?", identity.UserID).Count(&count).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "查询失败"})
 		return
 	}
@@ -144,7 +209,7 @@ func (h *DNSHandler) CreateDNSRecord(c *gin.Context) {
 
 	// 创建DNS记录
 	record := models.DNSRecord{
-		UserID:    userID.(uint),
+		UserID:    identity.UserID,
 		DomainID:  req.DomainID,
 		Subdomain: req.Subdomain,
 		Type:      req.Type,
@@ -153,8 +218,8 @@ func (h *DNSHandler) CreateDNSRecord(c *gin.Context) {
 		Priority:  req.Priority,
 		Weight:    req.Weight,
 		Port:      req.Port,
+		Proxied:   req.Proxied,
 		Comment:   req.Comment,
-		Status:    "active",
 	}
 
 	// 验证记录
@@ -163,10 +228,18 @@ func (h *DNSHandler) CreateDNSRecord(c *gin.Context) {
 		return
 	}
 
-	if err := h.db.Create(&record).Error; err != nil {
+	// 保存记录并写入同步任务，由后台任务同步到DNS服务商
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&record).Error; err != nil {
+			return err
+		}
+		return syncqueue.Enqueue(tx, &domain, models.SyncActionCreate, &record, nil)
+	})
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "创建失败"})
 		return
 	}
+	syncqueue.Notify()
 
 	// 重新加载关联数据
 	h.db.Preload("Domain").First(&record, record.ID)
@@ -179,15 +252,15 @@ func (h *DNSHandler) CreateDNSRecord(c *gin.Context) {
 
 // UpdateDNSRecord 更新DNS记录
 func (h *DNSHandler) UpdateDNSRecord(c *gin.Context) {
-	userID, exists := c.Get("user_id")
-	if !exists {
+	identity, ok := middleware.CurrentUser(c)
+	if !ok {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "用户未认证"})
 		return
 	}
 
 	id := c.Param("id")
 	var record models.DNSRecord
-	if err := h.db.Where("id = ? AND user_id = ?", id, userID).First(&record).Error; err != nil {
+	if err := h.db.Where("id = ? AND user_id = ?", id, identity.UserID).First(&record).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
 			c.JSON(http.StatusNotFound, gin.H{"error": "记录不存在"})
 			return
@@ -202,6 +275,9 @@ func (h *DNSHandler) UpdateDNSRecord(c *gin.Context) {
 		return
 	}
 
+	// 保留更新前的记录，用于在DNS服务商中定位记录
+	old := record
+
 	// 更新字段
 	if req.Subdomain != "" {
 		record.Subdomain = req.Subdomain
@@ -218,6 +294,9 @@ func (h *DNSHandler) UpdateDNSRecord(c *gin.Context) {
 	record.Priority = req.Priority
 	record.Weight = req.Weight
 	record.Port = req.Port
+	if req.Proxied != nil {
+		record.Proxied = *req.Proxied
+	}
 	if req.Comment != "" {
 		record.Comment = req.Comment
 	}
@@ -228,10 +307,23 @@ func (h *DNSHandler) UpdateDNSRecord(c *gin.Context) {
 		return
 	}
 
-	if err := h.db.Save(&record).Error; err != nil {
+	var domain models.Domain
+	if err := h.db.First(&domain, record.DomainID).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "查询域名失败"})
+		return
+	}
+
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(&record).Error; err != nil {
+			return err
+		}
+		return syncqueue.Enqueue(tx, &domain, models.SyncActionUpdate, &record, &old)
+	})
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "更新失败"})
 		return
 	}
+	syncqueue.Notify()
 
 	// 重新加载关联数据
 	h.db.Preload("Domain").First(&record, record.ID)
@@ -244,17 +336,35 @@ func (h *DNSHandler) UpdateDNSRecord(c *gin.Context) {
 
 // DeleteDNSRecord 删除DNS记录
 func (h *DNSHandler) DeleteDNSRecord(c *gin.Context) {
-	userID, exists := c.Get("user_id")
-	if !exists {
+	identity, ok := middleware.CurrentUser(c)
+	if !ok {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "用户未认证"})
 		return
 	}
 
 	id := c.Param("id")
-	if err := h.db.Where("id = ? AND user_id = ?", id, userID).Delete(&models.DNSRecord{}).Error; err != nil {
+	var record models.DNSRecord
+	if err := h.db.Preload("Domain").Where("id = ? AND user_id = ?", id, identity.UserID).First(&record).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "记录不存在"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "查询失败"})
+		return
+	}
+
+	// 删除记录并写入同步任务，服务商中的记录由后台任务删除
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(&record).Error; err != nil {
+			return err
+		}
+		return syncqueue.Enqueue(tx, &record.Domain, models.SyncActionDelete, &record, nil)
+	})
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "删除失败"})
 		return
 	}
+	syncqueue.Notify()
 
 	c.JSON(http.StatusOK, gin.H{
 		"message": "删除成功",
@@ -263,8 +373,8 @@ func (h *DNSHandler) DeleteDNSRecord(c *gin.Context) {
 
 // BatchCreateDNSRecords 批量创建DNS记录
 func (h *DNSHandler) BatchCreateDNSRecords(c *gin.Context) {
-	userID, exists := c.Get("user_id")
-	if !exists {
+	identity, ok := middleware.CurrentUser(c)
+	if !ok {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "用户未认证"})
 		return
 	}
@@ -277,14 +387,14 @@ func (h *DNSHandler) BatchCreateDNSRecords(c *gin.Context) {
 
 	// 检查用户配额
 	var user authmodels.User
-	if err := h.db.First(&user, userID).Error; err != nil {
+	if err := h.db.First(&user, identity.UserID).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "用户不存在"})
 		return
 	}
 
 	// 统计当前DNS记录数量
 	var count int64
-	if err := h.db.Model(&models.DNSRecord{}).Where("user_id = ?", userID).Count(&count).Error; err != nil {
+	if err := h.db.Model(&models.DNSRecord{}).Where("user_id = ?", identity.UserID).Count(&count).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "查询失败"})
 		return
 	}
@@ -306,16 +416,23 @@ func (h *DNSHandler) BatchCreateDNSRecords(c *gin.Context) {
 		return
 	}
 
-	if len(domains) != len(domainIDs) {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "部分域名不存在"})
-		return
+	domainMap := make(map[uint]*models.Domain, len(domains))
+	for i := range domains {
+		domainMap[domains[i].ID] = &domains[i]
+	}
+
+	for _, id := range domainIDs {
+		if _, ok := domainMap[id]; !ok {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "部分域名不存在"})
+			return
+		}
 	}
 
 	// 创建DNS记录
 	records := make([]models.DNSRecord, 0, len(req.Records))
 	for _, recordReq := range req.Records {
 		record := models.DNSRecord{
-			UserID:    userID.(uint),
+			UserID:    identity.UserID,
 			DomainID:  recordReq.DomainID,
 			Subdomain: recordReq.Subdomain,
 			Type:      recordReq.Type,
@@ -324,8 +441,8 @@ func (h *DNSHandler) BatchCreateDNSRecords(c *gin.Context) {
 			Priority:  recordReq.Priority,
 			Weight:    recordReq.Weight,
 			Port:      recordReq.Port,
+			Proxied:   recordReq.Proxied,
 			Comment:   recordReq.Comment,
-			Status:    "active",
 		}
 
 		// 验证记录
@@ -337,10 +454,22 @@ func (h *DNSHandler) BatchCreateDNSRecords(c *gin.Context) {
 		records = append(records, record)
 	}
 
-	if err := h.db.CreateInBatches(records, 100).Error; err != nil {
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.CreateInBatches(records, 100).Error; err != nil {
+			return err
+		}
+		for i := range records {
+			if err := syncqueue.Enqueue(tx, domainMap[records[i].DomainID], models.SyncActionCreate, &records[i], nil); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "批量创建失败"})
 		return
 	}
+	syncqueue.Notify()
 
 	c.JSON(http.StatusCreated, gin.H{
 		"message": "批量创建成功",
@@ -350,8 +479,8 @@ func (h *DNSHandler) BatchCreateDNSRecords(c *gin.Context) {
 
 // ExportDNSRecords 导出DNS记录
 func (h *DNSHandler) ExportDNSRecords(c *gin.Context) {
-	userID, exists := c.Get("user_id")
-	if !exists {
+	identity, ok := middleware.CurrentUser(c)
+	if !ok {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "用户未认证"})
 		return
 	}
@@ -359,7 +488,7 @@ func (h *DNSHandler) ExportDNSRecords(c *gin.Context) {
 	domainID := c.Query("domain_id")
 
 	// 构建查询
-	query := h.db.Model(&models.DNSRecord{}).Where("user_id = ?", userID)
+	query := h.db.Model(&models.DNSRecord{}).Where("user_id = ?", identity.UserID)
 	if domainID != "" {
 		query = query.Where("domain_id = ?", domainID)
 	}
@@ -381,6 +510,7 @@ func (h *DNSHandler) ExportDNSRecords(c *gin.Context) {
 			Priority:  record.Priority,
 			Weight:    record.Weight,
 			Port:      record.Port,
+			Proxied:   record.Proxied,
 			Comment:   record.Comment,
 			Domain:    record.Domain.Name,
 		})
@@ -390,4 +520,39 @@ func (h *DNSHandler) ExportDNSRecords(c *gin.Context) {
 		Records: exports,
 		Total:   len(exports),
 	})
-}
\ No newline at end of file
+}
+
+// RetryDNSRecordSync 重新执行DNS记录失败的同步任务
+func (h *DNSHandler) RetryDNSRecordSync(c *gin.Context) {
+	identity, ok := middleware.CurrentUser(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "用户未认证"})
+		return
+	}
+
+	id := c.Param("id")
+	var record models.DNSRecord
+	if err := h.db.Where("id = ? AND user_id = ?", id, identity.UserID).First(&record).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "记录不存在"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "查询失败"})
+		return
+	}
+
+	if err := syncqueue.Retry(h.db, &record); err != nil {
+		if errors.Is(err, syncqueue.ErrNoFailedJob) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "重试失败"})
+		return
+	}
+	syncqueue.Notify()
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "已重新加入同步队列",
+		"record":  record,
+	})
+}
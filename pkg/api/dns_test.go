@@ -0,0 +1,244 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"domain-max/pkg/dns/models"
+	"domain-max/pkg/dns/providers"
+	"domain-max/pkg/dns/syncqueue"
+)
+
+// createMockProvider 创建已启用的模拟服务商，zone为预置的区域
+func (s *testServer) createMockProvider(name, zone string, errorRate int) *models.DNSProvider {
+	s.t.Helper()
+
+	provider := &models.DNSProvider{
+		Name:     name,
+		Type:     "mock",
+		Config:   fmt.Sprintf(`{"zones": %q, "error_rate": %d}`, zone, errorRate),
+		IsActive: true,
+	}
+	if err := s.db.Create(provider).Error; err != nil {
+		s.t.Fatalf("创建服务商失败: %v", err)
+	}
+	return provider
+}
+
+// upstreamRecords 返回模拟服务商区域中的记录
+//
+// 未指定数据文件的模拟服务商共享同一份区域数据，读取时不注入错误。
+func (s *testServer) upstreamRecords(zone string) []providers.Record {
+	s.t.Helper()
+
+	p, err := providers.New("mock", []byte("{}"))
+	if err != nil {
+		s.t.Fatalf("创建驱动失败: %v", err)
+	}
+	records, err := p.ListRecords(context.Background(), providers.Zone{Name: zone})
+	if err != nil {
+		s.t.Fatalf("读取服务商记录失败: %v", err)
+	}
+	return records
+}
+
+// syncAll 执行全部到期的同步任务
+func (s *testServer) syncAll() {
+	syncqueue.NewWorker(s.db).RunPending(context.Background())
+}
+
+func TestDNSHandlerSyncsRecordChanges(t *testing.T) {
+	tests := []struct {
+		name      string
+		secondary bool
+	}{
+		{name: "primary"},
+		{name: "primary and secondary", secondary: true},
+	}
+
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestServer(t)
+			user := s.createUser("user@example.com", false)
+			token := s.token(user)
+
+			zone := fmt.Sprintf("sync%d.example", i)
+			primary := s.createMockProvider("primary", zone, 0)
+			domain := models.Domain{Name: zone, IsActive: true, ProviderID: &primary.ID}
+			bound := []uint{primary.ID}
+			if tt.secondary {
+				secondary := s.createMockProvider("secondary", zone, 0)
+				domain.SecondaryProviderID = &secondary.ID
+				bound = append(bound, secondary.ID)
+			}
+			if err := s.db.Create(&domain).Error; err != nil {
+				t.Fatalf("创建域名失败: %v", err)
+			}
+
+			// 创建
+			var created struct {
+				Record models.DNSRecord `json:"record"`
+			}
+			code := s.do(http.MethodPost, "/api/dns-records", token, map[string]interface{}{
+				"domain_id": domain.ID,
+				"subdomain": "www",
+				"type":      "A",
+				"value":     "1.2.3.4",
+				"ttl":       600,
+			}, &created)
+			if code != http.StatusCreated {
+				t.Fatalf("创建记录返回 %d", code)
+			}
+			if created.Record.Status != models.RecordStatusPending {
+				t.Fatalf("创建后状态为 %q，应为 %q", created.Record.Status, models.RecordStatusPending)
+			}
+			s.syncAll()
+			s.assertRecord(created.Record.ID, models.RecordStatusSynced)
+			s.assertSynced(created.Record.ID, bound)
+			// 主备服务商共享模拟区域，每个服务商各写入一条记录
+			records := s.upstreamRecords(zone)
+			if len(records) != len(bound) {
+				t.Fatalf("服务商中的记录为 %+v", records)
+			}
+			for _, r := range records {
+				if r.Name != "www" || r.Type != "A" || r.Value != "1.2.3.4" {
+					t.Fatalf("服务商中的记录为 %+v", r)
+				}
+			}
+
+			// 更新
+			path := fmt.Sprintf("/api/dns-records/%d", created.Record.ID)
+			code = s.do(http.MethodPut, path, token, map[string]interface{}{
+				"type":  "A",
+				"value": "5.6.7.8",
+			}, nil)
+			if code != http.StatusOK {
+				t.Fatalf("更新记录返回 %d", code)
+			}
+			s.syncAll()
+			s.assertRecord(created.Record.ID, models.RecordStatusSynced)
+			s.assertSynced(created.Record.ID, bound)
+			records = s.upstreamRecords(zone)
+			if len(records) != len(bound) {
+				t.Fatalf("服务商中的记录为 %+v", records)
+			}
+			for _, r := range records {
+				if r.Value != "5.6.7.8" {
+					t.Fatalf("服务商中的记录未更新: %+v", r)
+				}
+			}
+
+			// 删除
+			if code := s.do(http.MethodDelete, path, token, nil, nil); code != http.StatusOK {
+				t.Fatalf("删除记录返回 %d", code)
+			}
+			s.syncAll()
+			if records := s.upstreamRecords(zone); len(records) != 0 {
+				t.Fatalf("服务商中的记录未删除: %+v", records)
+			}
+			s.assertNoPendingJobs()
+		})
+	}
+}
+
+func TestDNSHandlerRetriesFailedSync(t *testing.T) {
+	s := newTestServer(t)
+	user := s.createUser("user@example.com", false)
+	token := s.token(user)
+
+	zone := "failing.example"
+	provider := s.createMockProvider("failing", zone, 100)
+	domain := models.Domain{Name: zone, IsActive: true, ProviderID: &provider.ID}
+	if err := s.db.Create(&domain).Error; err != nil {
+		t.Fatalf("创建域名失败: %v", err)
+	}
+
+	var created struct {
+		Record models.DNSRecord `json:"record"`
+	}
+	code := s.do(http.MethodPost, "/api/dns-records", token, map[string]interface{}{
+		"domain_id": domain.ID,
+		"subdomain": "api",
+		"type":      "TXT",
+		"value":     "hello",
+		"ttl":       600,
+	}, &created)
+	if code != http.StatusCreated {
+		t.Fatalf("创建记录返回 %d", code)
+	}
+	s.syncAll()
+
+	record := s.assertRecord(created.Record.ID, models.RecordStatusPending)
+	if !strings.Contains(record.SyncError, providers.ErrMockInjected.Error()) {
+		t.Fatalf("同步错误为 %q", record.SyncError)
+	}
+	var job models.SyncJob
+	if err := s.db.Where("record_id = ?", record.ID).First(&job).Error; err != nil {
+		t.Fatalf("查询同步任务失败: %v", err)
+	}
+	if job.Status != models.SyncJobPending || job.Attempts != 1 || !job.NextRunAt.After(time.Now()) {
+		t.Fatalf("同步任务应等待重试: %+v", job)
+	}
+	if records := s.upstreamRecords(zone); len(records) != 0 {
+		t.Fatalf("失败的同步写入了记录: %+v", records)
+	}
+
+	// 服务商恢复后重试成功
+	if err := s.db.Model(provider).Update("config", fmt.Sprintf(`{"zones": %q}`, zone)).Error; err != nil {
+		t.Fatalf("更新服务商失败: %v", err)
+	}
+	if err := s.db.Model(&job).Update("next_run_at", job.CreatedAt).Error; err != nil {
+		t.Fatalf("更新同步任务失败: %v", err)
+	}
+	s.syncAll()
+	s.assertRecord(record.ID, models.RecordStatusSynced)
+	if records := s.upstreamRecords(zone); len(records) != 1 || records[0].Value != "hello" {
+		t.Fatalf("服务商中的记录为 %+v", records)
+	}
+}
+
+// assertSynced 检查记录在每个服务商中都已同步并保存了服务商记录ID
+func (s *testServer) assertSynced(recordID uint, providerIDs []uint) {
+	s.t.Helper()
+
+	for _, providerID := range providerIDs {
+		var state models.RecordSync
+		if err := s.db.Where("record_id = ? AND provider_id = ?", recordID, providerID).First(&state).Error; err != nil {
+			s.t.Fatalf("查询服务商 %d 的同步状态失败: %v", providerID, err)
+		}
+		if state.Status != models.RecordStatusSynced || state.ExternalID == "" {
+			s.t.Fatalf("服务商 %d 的同步状态为 %+v", providerID, state)
+		}
+	}
+}
+
+// assertRecord 检查记录的同步状态
+func (s *testServer) assertRecord(id uint, status string) *models.DNSRecord {
+	s.t.Helper()
+
+	var record models.DNSRecord
+	if err := s.db.First(&record, id).Error; err != nil {
+		s.t.Fatalf("查询记录失败: %v", err)
+	}
+	if record.Status != status {
+		s.t.Fatalf("记录状态为 %q，应为 %q（%s）", record.Status, status, record.SyncError)
+	}
+	return &record
+}
+
+// assertNoPendingJobs 检查全部同步任务已完成
+func (s *testServer) assertNoPendingJobs() {
+	s.t.Helper()
+
+	var count int64
+	if err := s.db.Model(&models.SyncJob{}).Where("status <> ?", models.SyncJobDone).Count(&count).Error; err != nil {
+		s.t.Fatalf("查询同步任务失败: %v", err)
+	}
+	if count > 0 {
+		s.t.Fatalf("还有 %d 个未完成的同步任务", count)
+	}
+}
@@ -1,12 +1,19 @@
 package api
 
 import (
+	"context"
+	authmodels "domain-max/pkg/auth/models"
 	"domain-max/pkg/dns/models"
+	"domain-max/pkg/dns/providers"
+	"domain-max/pkg/dns/reconcile"
+	"domain-max/pkg/dns/syncqueue"
+	"domain-max/pkg/middleware"
 	"errors"
 	"net/http"
 	"regexp"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
@@ -24,8 +31,8 @@ func NewDomainHandler(db *gorm.DB) *DomainHandler {
 
 // ListDomains 获取域名列表
 func (h *DomainHandler) ListDomains(c *gin.Context) {
-	userID, exists := c.Get("user_id")
-	if !exists {
+	identity, ok := middleware.CurrentUser(c)
+	if !ok {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "用户未认证"})
 		return
 	}
@@ -64,7 +71,7 @@ func (h *DomainHandler) ListDomains(c *gin.Context) {
 	var domains []models.Domain
 	offset := (page - 1) * pageSize
 	if err := query.Preload("DNSRecords", func(db *gorm.DB) *gorm.DB {
-		return db.Where("user_id = ?", userID).Order("created_at DESC")
+		return db.Where("user_id = ?", identity.UserID).Order("created_at DESC")
 	}).Offset(offset).Limit(pageSize).Order("created_at DESC").Find(&domains).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "查询失败"})
 		return
@@ -98,8 +105,7 @@ func (h *DomainHandler) GetDomain(c *gin.Context) {
 
 // CreateDomain 创建域名
 func (h *DomainHandler) CreateDomain(c *gin.Context) {
-	_, exists := c.Get("user_id")
-	if !exists {
+	if _, ok := middleware.CurrentUser(c); !ok {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "用户未认证"})
 		return
 	}
@@ -108,6 +114,11 @@ func (h *DomainHandler) CreateDomain(c *gin.Context) {
 		Name        string `json:"name" binding:"required"`
 		DomainType  string `json:"domain_type"`
 		Description string `json:"description"`
+		ProviderID  *uint  `json:"provider_id"`
+		ZoneID      string `json:"zone_id"`
+
+		SecondaryProviderID *uint  `json:"secondary_provider_id"`
+		SecondaryZoneID     string `json:"secondary_zone_id"`
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -134,6 +145,20 @@ func (h *DomainHandler) CreateDomain(c *gin.Context) {
 		DomainType:  req.DomainType,
 		IsActive:    true,
 		Description: req.Description,
+		ZoneID:      req.ZoneID,
+	}
+	if req.ProviderID != nil && *req.ProviderID != 0 {
+		domain.ProviderID = req.ProviderID
+	}
+	if req.SecondaryProviderID != nil && *req.SecondaryProviderID != 0 {
+		domain.SecondaryProviderID = req.SecondaryProviderID
+		domain.SecondaryZoneID = req.SecondaryZoneID
+	}
+
+	// 检查绑定的DNS服务商
+	if status, err := h.checkDomainProvider(&domain); err != nil {
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
 	}
 
 	if err := h.db.Create(&domain).Error; err != nil {
@@ -149,8 +174,7 @@ func (h *DomainHandler) CreateDomain(c *gin.Context) {
 
 // UpdateDomain 更新域名
 func (h *DomainHandler) UpdateDomain(c *gin.Context) {
-	_, exists := c.Get("user_id")
-	if !exists {
+	if _, ok := middleware.CurrentUser(c); !ok {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "用户未认证"})
 		return
 	}
@@ -166,11 +190,18 @@ func (h *DomainHandler) UpdateDomain(c *gin.Context) {
 		return
 	}
 
+	previous := domain
+
 	var req struct {
-		Name        string `json:"name"`
-		DomainType  string `json:"domain_type"`
-		IsActive    *bool  `json:"is_active"`
-		Description string `json:"description"`
+		Name        string  `json:"name"`
+		DomainType  string  `json:"domain_type"`
+		IsActive    *bool   `json:"is_active"`
+		Description string  `json:"description"`
+		ProviderID  *uint   `json:"provider_id"` // 为0时解除绑定
+		ZoneID      *string `json:"zone_id"`
+
+		SecondaryProviderID *uint   `json:"secondary_provider_id"` // 为0时解除绑定
+		SecondaryZoneID     *string `json:"secondary_zone_id"`
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -202,11 +233,119 @@ func (h *DomainHandler) UpdateDomain(c *gin.Context) {
 	if req.Description != "" {
 		domain.Description = req.Description
 	}
+	if req.ProviderID != nil {
+		var providerID *uint
+		if *req.ProviderID != 0 {
+			providerID = req.ProviderID
+		}
+		if !sameProviderID(domain.ProviderID, providerID) {
+			// 已同步到原服务商的记录无法迁移，需先删除记录
+			synced, err := h.hasSyncedRecords(&domain, domain.ProviderID)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "查询失败"})
+				return
+			}
+			if synced {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "该域名下还有已同步到DNS服务商的记录，无法更换DNS服务商"})
+				return
+			}
+		}
+		domain.ProviderID = providerID
+	}
+	if req.ZoneID != nil {
+		if *req.ZoneID != domain.ZoneID && sameProviderID(domain.ProviderID, previous.ProviderID) {
+			// 已同步的记录保存的是原区域中的记录ID，更换区域后无法再更新或删除
+			synced, err := h.hasSyncedRecords(&domain, domain.ProviderID)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "查询失败"})
+				return
+			}
+			if synced {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "该域名下还有已同步到DNS服务商的记录，无法修改区域ID"})
+				return
+			}
+		}
+		domain.ZoneID = *req.ZoneID
+	}
+	if req.SecondaryProviderID != nil {
+		var providerID *uint
+		if *req.SecondaryProviderID != 0 {
+			providerID = req.SecondaryProviderID
+		}
+		if !sameProviderID(domain.SecondaryProviderID, providerID) {
+			synced, err := h.hasSyncedRecords(&domain, domain.SecondaryProviderID)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "查询失败"})
+				return
+			}
+			if synced {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "该域名下还有已同步到备用DNS服务商的记录，无法更换备用DNS服务商"})
+				return
+			}
+		}
+		domain.SecondaryProviderID = providerID
+	}
+	if req.SecondaryZoneID != nil {
+		if *req.SecondaryZoneID != domain.SecondaryZoneID && sameProviderID(domain.SecondaryProviderID, previous.SecondaryProviderID) {
+			synced, err := h.hasSyncedRecords(&domain, domain.SecondaryProviderID)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "查询失败"})
+				return
+			}
+			if synced {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "该域名下还有已同步到备用DNS服务商的记录，无法修改备用区域ID"})
+				return
+			}
+		}
+		domain.SecondaryZoneID = *req.SecondaryZoneID
+	}
+
+	// 只在启用域名或更换DNS服务商时检查绑定的服务商，服务商被禁用后仍可修改域名的其他信息
+	bindingChanged := !sameProviderID(previous.ProviderID, domain.ProviderID) ||
+		!sameProviderID(previous.SecondaryProviderID, domain.SecondaryProviderID)
+	if bindingChanged || (domain.IsActive && !previous.IsActive) {
+		if status, err := h.checkDomainProvider(&domain); err != nil {
+			c.JSON(status, gin.H{"error": err.Error()})
+			return
+		}
+	}
 
-	if err := h.db.Save(&domain).Error; err != nil {
+	// 新绑定的服务商需要写入域名下已有的记录
+	var added, removed []uint
+	for _, providerID := range domain.ProviderIDs() {
+		if !containsProviderID(previous.ProviderIDs(), providerID) {
+			added = append(added, providerID)
+		}
+	}
+	for _, providerID := range previous.ProviderIDs() {
+		if !containsProviderID(domain.ProviderIDs(), providerID) {
+			removed = append(removed, providerID)
+		}
+	}
+
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(&domain).Error; err != nil {
+			return err
+		}
+		for _, providerID := range removed {
+			if err := syncqueue.Unbind(tx, &domain, providerID); err != nil {
+				return err
+			}
+		}
+		for _, providerID := range added {
+			if err := h.syncExistingRecords(tx, &domain, providerID); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "更新失败"})
 		return
 	}
+	if len(added) > 0 {
+		syncqueue.Notify()
+	}
 
 	c.JSON(http.StatusOK, gin.H{
 		"message": "更新成功",
@@ -216,8 +355,7 @@ func (h *DomainHandler) UpdateDomain(c *gin.Context) {
 
 // DeleteDomain 删除域名
 func (h *DomainHandler) DeleteDomain(c *gin.Context) {
-	_, exists := c.Get("user_id")
-	if !exists {
+	if _, ok := middleware.CurrentUser(c); !ok {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "用户未认证"})
 		return
 	}
@@ -257,10 +395,94 @@ func (h *DomainHandler) DeleteDomain(c *gin.Context) {
 	})
 }
 
+// checkDomainProvider 检查域名绑定的DNS服务商是否存在，启用状态的域名要求服务商也已启用
+func (h *DomainHandler) checkDomainProvider(domain *models.Domain) (int, error) {
+	if domain.SecondaryProviderID != nil {
+		if domain.ProviderID == nil {
+			return http.StatusBadRequest, errors.New("设置备用DNS服务商前需先绑定主DNS服务商")
+		}
+		if *domain.SecondaryProviderID == *domain.ProviderID {
+			return http.StatusBadRequest, errors.New("备用DNS服务商不能与主DNS服务商相同")
+		}
+	}
+
+	for _, providerID := range domain.ProviderIDs() {
+		var provider models.DNSProvider
+		if err := h.db.First(&provider, providerID).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return http.StatusBadRequest, errors.New("DNS服务商不存在")
+			}
+			return http.StatusInternalServerError, errors.New("查询失败")
+		}
+		if domain.IsActive && !provider.IsActive {
+			return http.StatusBadRequest, errors.New("DNS服务商已禁用，无法启用该域名")
+		}
+	}
+	return http.StatusOK, nil
+}
+
+// hasSyncedRecords 域名下是否有已同步到指定DNS服务商的记录
+func (h *DomainHandler) hasSyncedRecords(domain *models.Domain, providerID *uint) (bool, error) {
+	if providerID == nil {
+		return false, nil
+	}
+
+	var count int64
+	records := h.db.Model(&models.DNSRecord{}).Select("id").Where("domain_id = ?", domain.ID)
+	if err := h.db.Model(&models.RecordSync{}).
+		Where("provider_id = ? AND external_id <> '' AND record_id IN (?)", *providerID, records).
+		Count(&count).Error; err != nil {
+		return false, err
+	}
+	if count > 0 {
+		return true, nil
+	}
+
+	// 早期同步的记录只在记录中保存了主服务商的记录ID
+	if sameProviderID(domain.ProviderID, providerID) {
+		if err := h.db.Model(&models.DNSRecord{}).Where("domain_id = ? AND external_id <> ''", domain.ID).Count(&count).Error; err != nil {
+			return false, err
+		}
+	}
+	return count > 0, nil
+}
+
+// syncExistingRecords 为域名下已有的记录写入同步到指定DNS服务商的任务
+func (h *DomainHandler) syncExistingRecords(tx *gorm.DB, domain *models.Domain, providerID uint) error {
+	var records []models.DNSRecord
+	if err := tx.Where("domain_id = ?", domain.ID).Find(&records).Error; err != nil {
+		return err
+	}
+	for i := range records {
+		if err := syncqueue.EnqueueFor(tx, providerID, models.SyncActionCreate, &records[i], nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// containsProviderID 服务商ID列表中是否包含指定ID
+func containsProviderID(ids []uint, id uint) bool {
+	for _, v := range ids {
+		if v == id {
+			return true
+		}
+	}
+	return false
+}
+
+// sameProviderID 比较两个可为空的服务商ID是否相同
+func sameProviderID(a, b *uint) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return *a == *b
+}
+
 // GetDomainDNSRecords 获取域名的DNS记录
 func (h *DomainHandler) GetDomainDNSRecords(c *gin.Context) {
-	userID, exists := c.Get("user_id")
-	if !exists {
+	identity, ok := middleware.CurrentUser(c)
+	if !ok {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "用户未认证"})
 		return
 	}
@@ -292,7 +514,7 @@ func (h *DomainHandler) GetDomainDNSRecords(c *gin.Context) {
 	}
 
 	// 构建查询
-	query := h.db.Model(&models.DNSRecord{}).Where("domain_id = ? AND user_id = ?", domainID, userID)
+	query := h.db.Model(&models.DNSRecord{}).Where("domain_id = ? AND user_id = ?", domainID, identity.UserID)
 
 	if recordType != "" {
 		query = query.Where("type = ?", recordType)
@@ -327,8 +549,8 @@ func (h *DomainHandler) GetDomainDNSRecords(c *gin.Context) {
 
 // GetDomainStats 获取域名统计信息
 func (h *DomainHandler) GetDomainStats(c *gin.Context) {
-	userID, exists := c.Get("user_id")
-	if !exists {
+	identity, ok := middleware.CurrentUser(c)
+	if !ok {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "用户未认证"})
 		return
 	}
@@ -353,7 +575,7 @@ func (h *DomainHandler) GetDomainStats(c *gin.Context) {
 	}
 
 	if err := h.db.Model(&models.DNSRecord{}).
-		Where("domain_id = ? AND user_id = ?", domainID, userID).
+		Where("domain_id = ? AND user_id = ?", domainID, identity.UserID).
 		Select("type, count(*) as count").
 		Group("type").
 		Find(&stats).Error; err != nil {
@@ -364,7 +586,7 @@ func (h *DomainHandler) GetDomainStats(c *gin.Context) {
 	// 统计总记录数
 	var totalRecords int64
 	if err := h.db.Model(&models.DNSRecord{}).
-		Where("domain_id = ? AND user_id = ?", domainID, userID).
+		Where("domain_id = ? AND user_id = ?", domainID, identity.UserID).
 		Count(&totalRecords).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "查询失败"})
 		return
@@ -377,6 +599,154 @@ func (h *DomainHandler) GetDomainStats(c *gin.Context) {
 	})
 }
 
+// importFailure 导入失败的记录
+type importFailure struct {
+	Name  string `json:"name"`
+	Type  string `json:"type"`
+	Value string `json:"value"`
+	Error string `json:"error"`
+}
+
+// ImportDomainRecords 从域名绑定的DNS服务商导入已有记录
+//
+// 已在本地存在对应记录的服务商记录会被跳过，未通过校验的记录逐条报告而不会中断导入。
+// 管理员导入不受用户的DNS记录配额限制。
+func (h *DomainHandler) ImportDomainRecords(c *gin.Context) {
+	// 检查管理员权限
+	identity, ok := middleware.CurrentUser(c)
+	if !ok || !identity.IsAdmin {
+		c.JSON(http.StatusForbidden, gin.H{"error": "需要管理员权限"})
+		return
+	}
+
+	var req struct {
+		UserID uint `json:"user_id" binding:"required"` // 导入记录的所属用户
+		DryRun bool `json:"dry_run"`                    // 只返回导入结果预览，不写入数据库
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var domain models.Domain
+	if err := h.db.First(&domain, c.Param("id")).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "域名不存在"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "查询失败"})
+		return
+	}
+	if domain.ProviderID == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "该域名未绑定DNS服务商"})
+		return
+	}
+
+	var user authmodels.User
+	if err := h.db.First(&user, req.UserID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "用户不存在"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "查询失败"})
+		return
+	}
+
+	// 读取服务商中的记录
+	provider, err := providers.OpenForDomain(h.db, &domain)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	ctx, cancel := context.WithTimeout(c.Request.Context(), providerRequestTimeout)
+	defer cancel()
+	upstream, err := provider.ListRecords(ctx, providers.ZoneForDomain(&domain))
+	if err != nil {
+		c.JSON(http.StatusBadGateway, gin.H{"error": "读取DNS服务商记录失败: " + err.Error()})
+		return
+	}
+
+	var existing []models.DNSRecord
+	if err := h.db.Where("domain_id = ?", domain.ID).Find(&existing).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "查询失败"})
+		return
+	}
+
+	// 只导入本地没有对应记录的服务商记录，SOA和根域名NS记录由服务商维护，不导入也不计入跳过
+	untracked, skipped := reconcile.Untracked(existing, upstream)
+	now := time.Now()
+	records := make([]models.DNSRecord, 0, len(untracked))
+	failures := make([]importFailure, 0)
+	for _, item := range untracked {
+		record := models.DNSRecord{
+			UserID:   user.ID,
+			DomainID: domain.ID,
+			Status:   models.RecordStatusSynced,
+			SyncedAt: &now,
+			Comment:  "从DNS服务商导入",
+		}
+		providers.ApplyToDNSRecord(item, &record)
+		if record.TTL <= 0 {
+			record.TTL = 600
+		}
+
+		if err := record.ValidateDNSRecord(); err != nil {
+			failures = append(failures, importFailure{
+				Name:  item.Name,
+				Type:  record.Type,
+				Value: record.Value,
+				Error: err.Error(),
+			})
+			continue
+		}
+		records = append(records, record)
+	}
+
+	if !req.DryRun && len(records) > 0 {
+		err := h.db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Create(&records).Error; err != nil {
+				return err
+			}
+			for i := range records {
+				// 导入的记录已存在于主服务商，备用服务商需要写入
+				state := models.RecordSync{
+					RecordID:   records[i].ID,
+					ProviderID: *domain.ProviderID,
+					ExternalID: records[i].ExternalID,
+					Status:     models.RecordStatusSynced,
+					SyncedAt:   &now,
+				}
+				if err := tx.Create(&state).Error; err != nil {
+					return err
+				}
+				if domain.SecondaryProviderID != nil {
+					if err := syncqueue.EnqueueFor(tx, *domain.SecondaryProviderID, models.SyncActionCreate, &records[i], nil); err != nil {
+						return err
+					}
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "导入失败"})
+			return
+		}
+		if domain.SecondaryProviderID != nil {
+			syncqueue.Notify()
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":  "导入完成",
+		"dry_run":  req.DryRun,
+		"total":    skipped + len(untracked),
+		"skipped":  skipped,
+		"imported": len(records),
+		"failed":   failures,
+		"records":  records,
+	})
+}
+
 // validateDomainName 验证域名格式
 func validateDomainName(domain string) error {
 	if len(domain) == 0 {
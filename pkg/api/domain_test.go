@@ -0,0 +1,88 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"domain-max/pkg/dns/models"
+)
+
+func TestUpdateDomainChecksProviderOnlyWhenNeeded(t *testing.T) {
+	tests := []struct {
+		name   string
+		active bool // 更新前域名是否启用
+		body   func(disabledID, enabledID uint) map[string]interface{}
+		want   int
+	}{
+		{
+			name:   "edit description",
+			active: true,
+			body: func(uint, uint) map[string]interface{} {
+				return map[string]interface{}{"description": "新的描述"}
+			},
+			want: http.StatusOK,
+		},
+		{
+			name:   "deactivate",
+			active: true,
+			body: func(uint, uint) map[string]interface{} {
+				return map[string]interface{}{"is_active": false}
+			},
+			want: http.StatusOK,
+		},
+		{
+			name: "activate",
+			body: func(uint, uint) map[string]interface{} {
+				return map[string]interface{}{"is_active": true}
+			},
+			want: http.StatusBadRequest,
+		},
+		{
+			name:   "switch to enabled provider",
+			active: true,
+			body: func(_, enabledID uint) map[string]interface{} {
+				return map[string]interface{}{"provider_id": enabledID}
+			},
+			want: http.StatusOK,
+		},
+		{
+			name:   "bind missing secondary",
+			active: true,
+			body: func(_, enabledID uint) map[string]interface{} {
+				return map[string]interface{}{"secondary_provider_id": enabledID + 100}
+			},
+			want: http.StatusBadRequest,
+		},
+	}
+
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestServer(t)
+			admin := s.createUser("admin@example.com", true)
+
+			zone := fmt.Sprintf("check%d.example", i)
+			disabled := s.createMockProvider("disabled", zone, 0)
+			enabled := s.createMockProvider("enabled", zone, 0)
+			if err := s.db.Model(disabled).Update("is_active", false).Error; err != nil {
+				t.Fatalf("禁用服务商失败: %v", err)
+			}
+
+			domain := models.Domain{Name: zone, IsActive: true, ProviderID: &disabled.ID}
+			if err := s.db.Create(&domain).Error; err != nil {
+				t.Fatalf("创建域名失败: %v", err)
+			}
+			if err := s.db.Model(&domain).Update("is_active", tt.active).Error; err != nil {
+				t.Fatalf("更新域名失败: %v", err)
+			}
+
+			var resp struct {
+				Error string `json:"error"`
+			}
+			path := fmt.Sprintf("/api/domains/%d", domain.ID)
+			if code := s.do(http.MethodPut, path, s.token(admin), tt.body(disabled.ID, enabled.ID), &resp); code != tt.want {
+				t.Fatalf("更新域名返回 %d %s，应为 %d", code, resp.Error, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,149 @@
+package api
+
+import (
+	"domain-max/pkg/dns/models"
+	"domain-max/pkg/dns/reconcile"
+	"domain-max/pkg/middleware"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// DriftHandler DNS记录漂移检测处理器
+type DriftHandler struct {
+	db      *gorm.DB
+	checker *reconcile.Checker
+}
+
+// NewDriftHandler 创建新的漂移检测处理器
+func NewDriftHandler(db *gorm.DB) *DriftHandler {
+	return &DriftHandler{db: db, checker: reconcile.NewChecker(db)}
+}
+
+// ListDriftReports 获取各域名最近一次的漂移检测结果摘要
+func (h *DriftHandler) ListDriftReports(c *gin.Context) {
+	// 检查管理员权限
+	identity, ok := middleware.CurrentUser(c)
+	if !ok || !identity.IsAdmin {
+		c.JSON(http.StatusForbidden, gin.H{"error": "需要管理员权限"})
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "10"))
+	driftOnly := c.Query("drift_only") == "true"
+
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > 100 {
+		pageSize = 10
+	}
+
+	query := h.db.Model(&models.DriftReport{})
+	if driftOnly {
+		query = query.Where("missing > 0 OR extra > 0 OR changed > 0 OR error <> ''")
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "查询失败"})
+		return
+	}
+
+	var reports []models.DriftReport
+	offset := (page - 1) * pageSize
+	if err := query.Preload("Domain").Offset(offset).Limit(pageSize).Order("checked_at DESC").Find(&reports).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "查询失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"reports":   reports,
+		"total":     total,
+		"page":      page,
+		"page_size": pageSize,
+	})
+}
+
+// GetDomainDrift 获取域名在各DNS服务商中最近一次的漂移检测明细
+func (h *DriftHandler) GetDomainDrift(c *gin.Context) {
+	// 检查管理员权限
+	identity, ok := middleware.CurrentUser(c)
+	if !ok || !identity.IsAdmin {
+		c.JSON(http.StatusForbidden, gin.H{"error": "需要管理员权限"})
+		return
+	}
+
+	domain, ok := h.loadDomain(c)
+	if !ok {
+		return
+	}
+
+	reports, err := reconcile.LoadReports(h.db, domain.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "查询失败"})
+		return
+	}
+	if len(reports) == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "该域名尚未进行漂移检测"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"reports": reports,
+	})
+}
+
+// CheckDomainDrift 立即检测域名在主备DNS服务商中的记录漂移
+func (h *DriftHandler) CheckDomainDrift(c *gin.Context) {
+	// 检查管理员权限
+	identity, ok := middleware.CurrentUser(c)
+	if !ok || !identity.IsAdmin {
+		c.JSON(http.StatusForbidden, gin.H{"error": "需要管理员权限"})
+		return
+	}
+
+	domain, ok := h.loadDomain(c)
+	if !ok {
+		return
+	}
+	if domain.ProviderID == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": reconcile.ErrNoProvider.Error()})
+		return
+	}
+
+	reports, err := h.checker.CheckDomain(c.Request.Context(), domain)
+	if err != nil {
+		if reports == nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "保存检测结果失败"})
+			return
+		}
+		c.JSON(http.StatusBadGateway, gin.H{
+			"error":   "检测失败: " + err.Error(),
+			"reports": reports,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "检测完成",
+		"reports": reports,
+	})
+}
+
+// loadDomain 根据路径参数查询域名，失败时已写入响应
+func (h *DriftHandler) loadDomain(c *gin.Context) (*models.Domain, bool) {
+	var domain models.Domain
+	if err := h.db.First(&domain, c.Param("id")).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "域名不存在"})
+			return nil, false
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "查询失败"})
+		return nil, false
+	}
+	return &domain, true
+}
@@ -0,0 +1,132 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"domain-max/pkg/dns/models"
+	"domain-max/pkg/dns/reconcile"
+)
+
+func TestCheckDomainDriftChecksEachProvider(t *testing.T) {
+	s := newTestServer(t)
+	admin := s.createUser("admin@example.com", true)
+	token := s.token(admin)
+
+	const zone = "drift.example"
+	primary := s.createMockProvider("primary", zone, 0)
+	secondary := s.createMockProvider("secondary", zone, 0)
+	domain := models.Domain{Name: zone, IsActive: true, ProviderID: &primary.ID}
+	if err := s.db.Create(&domain).Error; err != nil {
+		t.Fatalf("创建域名失败: %v", err)
+	}
+
+	code := s.do(http.MethodPost, "/api/dns-records", token, map[string]interface{}{
+		"domain_id": domain.ID,
+		"subdomain": "www",
+		"type":      "A",
+		"value":     "1.2.3.4",
+		"ttl":       600,
+	}, nil)
+	if code != http.StatusCreated {
+		t.Fatalf("创建记录返回 %d", code)
+	}
+	s.syncAll()
+
+	// 绑定备用服务商后新建的记录尚未同步到任何服务商
+	path := fmt.Sprintf("/api/domains/%d", domain.ID)
+	if code := s.do(http.MethodPut, path, token, map[string]interface{}{"secondary_provider_id": secondary.ID}, nil); code != http.StatusOK {
+		t.Fatalf("绑定备用服务商返回 %d", code)
+	}
+	var pending struct {
+		Record models.DNSRecord `json:"record"`
+	}
+	code = s.do(http.MethodPost, "/api/dns-records", token, map[string]interface{}{
+		"domain_id": domain.ID,
+		"subdomain": "api",
+		"type":      "A",
+		"value":     "5.6.7.8",
+		"ttl":       600,
+	}, &pending)
+	if code != http.StatusCreated {
+		t.Fatalf("创建记录返回 %d", code)
+	}
+
+	var resp struct {
+		Reports []reconcile.Report `json:"reports"`
+	}
+	checkPath := fmt.Sprintf("/api/domains/%d/drift/check", domain.ID)
+	if code := s.do(http.MethodPost, checkPath, token, nil, &resp); code != http.StatusOK {
+		t.Fatalf("漂移检测返回 %d", code)
+	}
+	if len(resp.Reports) != 2 || resp.Reports[0].ProviderID != primary.ID || resp.Reports[1].ProviderID != secondary.ID {
+		t.Fatalf("检测结果为 %+v", resp.Reports)
+	}
+	for _, r := range resp.Reports {
+		if len(r.Missing) != 0 || len(r.Changed) != 0 || len(r.Unsynced) != 1 || r.Unsynced[0].RecordID != pending.Record.ID {
+			t.Fatalf("服务商 %d 的检测结果为 %+v", r.ProviderID, r)
+		}
+	}
+
+	var saved struct {
+		Reports []reconcile.Report `json:"reports"`
+	}
+	if code := s.do(http.MethodGet, fmt.Sprintf("/api/domains/%d/drift", domain.ID), token, nil, &saved); code != http.StatusOK {
+		t.Fatalf("查询检测结果返回 %d", code)
+	}
+	if len(saved.Reports) != 2 {
+		t.Fatalf("保存的检测结果为 %+v", saved.Reports)
+	}
+
+	// 解除备用服务商后不再保留其检测结果
+	if code := s.do(http.MethodPut, path, token, map[string]interface{}{"secondary_provider_id": 0}, nil); code != http.StatusOK {
+		t.Fatalf("解除备用服务商返回 %d", code)
+	}
+	if code := s.do(http.MethodPost, checkPath, token, nil, &resp); code != http.StatusOK || len(resp.Reports) != 1 {
+		t.Fatalf("漂移检测返回 %d %+v", code, resp.Reports)
+	}
+	var count int64
+	s.db.Model(&models.DriftReport{}).Where("domain_id = ?", domain.ID).Count(&count)
+	if count != 1 {
+		t.Fatalf("保存了 %d 条检测结果", count)
+	}
+}
+
+func TestUpdateDomainZoneIDWithSyncedRecords(t *testing.T) {
+	s := newTestServer(t)
+	admin := s.createUser("admin@example.com", true)
+	token := s.token(admin)
+
+	const zone = "zone-change.example"
+	primary := s.createMockProvider("primary", zone, 0)
+	domain := models.Domain{Name: zone, IsActive: true, ProviderID: &primary.ID}
+	if err := s.db.Create(&domain).Error; err != nil {
+		t.Fatalf("创建域名失败: %v", err)
+	}
+
+	path := fmt.Sprintf("/api/domains/%d", domain.ID)
+	if code := s.do(http.MethodPut, path, token, map[string]interface{}{"zone_id": "zone-1"}, nil); code != http.StatusOK {
+		t.Fatalf("没有记录时修改区域ID返回 %d", code)
+	}
+
+	code := s.do(http.MethodPost, "/api/dns-records", token, map[string]interface{}{
+		"domain_id": domain.ID,
+		"subdomain": "www",
+		"type":      "A",
+		"value":     "1.2.3.4",
+		"ttl":       600,
+	}, nil)
+	if code != http.StatusCreated {
+		t.Fatalf("创建记录返回 %d", code)
+	}
+	s.syncAll()
+
+	if code := s.do(http.MethodPut, path, token, map[string]interface{}{"zone_id": "zone-2"}, nil); code != http.StatusBadRequest {
+		t.Fatalf("有已同步的记录时修改区域ID返回 %d", code)
+	}
+	// 区域ID不变时可以修改其他字段
+	if code := s.do(http.MethodPut, path, token, map[string]interface{}{"zone_id": "zone-1", "description": "新的描述"}, nil); code != http.StatusOK {
+		t.Fatalf("区域ID不变时更新域名返回 %d", code)
+	}
+}
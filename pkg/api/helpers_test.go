@@ -0,0 +1,117 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+
+	authmodels "domain-max/pkg/auth/models"
+	"domain-max/pkg/config"
+	"domain-max/pkg/database"
+	"domain-max/pkg/dns/providers"
+	"domain-max/pkg/middleware"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+const testJWTSecret = "test_jwt_secret_key_for_handler_tests_only_0123456789abcdefghijk"
+
+func init() {
+	gin.SetMode(gin.TestMode)
+	providers.RegisterMock("")
+}
+
+// testServer 使用SQLite内存数据库的API服务器
+type testServer struct {
+	t      *testing.T
+	db     *gorm.DB
+	router *gin.Engine
+}
+
+// newTestServer 创建已执行全部迁移的内存数据库和完整的API路由
+func newTestServer(t *testing.T) *testServer {
+	t.Helper()
+
+	cfg := &config.Config{
+		Environment: "test",
+		BaseURL:     "http://localhost:8080",
+		JWTSecret:   testJWTSecret,
+		DBType:      "sqlite",
+		DBPath:      ":memory:",
+	}
+	db, err := database.Connect(cfg)
+	if err != nil {
+		t.Fatalf("连接数据库失败: %v", err)
+	}
+	db.Logger = logger.Default.LogMode(logger.Silent)
+	if err := database.Migrate(db); err != nil {
+		t.Fatalf("数据库迁移失败: %v", err)
+	}
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+
+	router := gin.New()
+	SetupRoutes(router, db, cfg)
+	return &testServer{t: t, db: db, router: router}
+}
+
+// createUser 创建已激活的用户
+func (s *testServer) createUser(email string, isAdmin bool) *authmodels.User {
+	s.t.Helper()
+
+	user := &authmodels.User{
+		Email:          email,
+		Password:       "unused",
+		IsActive:       true,
+		IsAdmin:        isAdmin,
+		DNSRecordQuota: 10,
+		Status:         "normal",
+	}
+	if err := s.db.Create(user).Error; err != nil {
+		s.t.Fatalf("创建用户失败: %v", err)
+	}
+	return user
+}
+
+// token 为用户签发登录令牌
+func (s *testServer) token(user *authmodels.User) string {
+	s.t.Helper()
+
+	token, _, err := middleware.SignAccessToken(testJWTSecret, user.ID, user.Email, user.IsAdmin)
+	if err != nil {
+		s.t.Fatalf("签发令牌失败: %v", err)
+	}
+	return token
+}
+
+// do 发送JSON请求，out不为空时解析响应
+func (s *testServer) do(method, path, token string, body, out interface{}) int {
+	s.t.Helper()
+
+	var buf bytes.Buffer
+	if body != nil {
+		if err := json.NewEncoder(&buf).Encode(body); err != nil {
+			s.t.Fatalf("编码请求失败: %v", err)
+		}
+	}
+	req := httptest.NewRequest(method, path, &buf)
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	rec := httptest.NewRecorder()
+	s.router.ServeHTTP(rec, req)
+	if out != nil {
+		if err := json.Unmarshal(rec.Body.Bytes(), out); err != nil {
+			s.t.Fatalf("%s %s 响应格式错误: %v: %s", method, path, err, rec.Body.String())
+		}
+	}
+	return rec.Code
+}
@@ -0,0 +1,79 @@
+package api
+
+import (
+	"domain-max/pkg/email/models"
+	"domain-max/pkg/middleware"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// MailHandler 邮件队列处理器
+type MailHandler struct {
+	db *gorm.DB
+}
+
+// NewMailHandler 创建新的邮件队列处理器
+func NewMailHandler(db *gorm.DB) *MailHandler {
+	return &MailHandler{db: db}
+}
+
+// ListMailDeliveries 获取邮件投递记录，包含每次尝试使用的SMTP配置、结果和SMTP错误
+func (h *MailHandler) ListMailDeliveries(c *gin.Context) {
+	// 检查管理员权限
+	identity, ok := middleware.CurrentUser(c)
+	if !ok || !identity.IsAdmin {
+		c.JSON(http.StatusForbidden, gin.H{"error": "需要管理员权限"})
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+	status := c.Query("status")
+	recipient := c.Query("recipient")
+	jobID := c.Query("job_id")
+	smtpConfigID := c.Query("smtp_config_id")
+
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > 100 {
+		pageSize = 20
+	}
+
+	query := h.db.Model(&models.MailDelivery{})
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if recipient != "" {
+		query = query.Where("recipient LIKE ?", "%"+recipient+"%")
+	}
+	if jobID != "" {
+		query = query.Where("job_id = ?", jobID)
+	}
+	if smtpConfigID != "" {
+		query = query.Where("smtp_config_id = ?", smtpConfigID)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "查询失败"})
+		return
+	}
+
+	var deliveries []models.MailDelivery
+	offset := (page - 1) * pageSize
+	if err := query.Preload("Job").Offset(offset).Limit(pageSize).Order("id DESC").Find(&deliveries).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "查询失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"deliveries": deliveries,
+		"total":      total,
+		"page":       page,
+		"page_size":  pageSize,
+	})
+}
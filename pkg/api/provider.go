@@ -1,8 +1,13 @@
 package api
 
 import (
+	"context"
 	"domain-max/pkg/dns/models"
-	"encoding/json"
+	"domain-max/pkg/dns/providers"
+	"domain-max/pkg/middleware"
+	"domain-max/pkg/utils"
+	"errors"
+	"fmt"
 	"net/http"
 	"strconv"
 	"time"
@@ -24,8 +29,8 @@ func NewProviderHandler(db *gorm.DB) *ProviderHandler {
 // ListProviders 获取DNS提供商列表
 func (h *ProviderHandler) ListProviders(c *gin.Context) {
 	// 检查管理员权限
-	isAdmin, exists := c.Get("is_admin")
-	if !exists || !isAdmin.(bool) {
+	identity, ok := middleware.CurrentUser(c)
+	if !ok || !identity.IsAdmin {
 		c.JSON(http.StatusForbidden, gin.H{"error": "需要管理员权限"})
 		return
 	}
@@ -72,6 +77,10 @@ func (h *ProviderHandler) ListProviders(c *gin.Context) {
 		return
 	}
 
+	for i := range providers {
+		maskProviderConfig(&providers[i])
+	}
+
 	c.JSON(http.StatusOK, gin.H{
 		"providers": providers,
 		"total":     total,
@@ -83,8 +92,8 @@ func (h *ProviderHandler) ListProviders(c *gin.Context) {
 // GetProvider 获取单个DNS提供商
 func (h *ProviderHandler) GetProvider(c *gin.Context) {
 	// 检查管理员权限
-	isAdmin, exists := c.Get("is_admin")
-	if !exists || !isAdmin.(bool) {
+	identity, ok := middleware.CurrentUser(c)
+	if !ok || !identity.IsAdmin {
 		c.JSON(http.StatusForbidden, gin.H{"error": "需要管理员权限"})
 		return
 	}
@@ -100,6 +109,7 @@ func (h *ProviderHandler) GetProvider(c *gin.Context) {
 		return
 	}
 
+	maskProviderConfig(&provider)
 	c.JSON(http.StatusOK, gin.H{
 		"provider": provider,
 	})
@@ -108,8 +118,8 @@ func (h *ProviderHandler) GetProvider(c *gin.Context) {
 // CreateProvider 创建DNS提供商
 func (h *ProviderHandler) CreateProvider(c *gin.Context) {
 	// 检查管理员权限
-	isAdmin, exists := c.Get("is_admin")
-	if !exists || !isAdmin.(bool) {
+	identity, ok := middleware.CurrentUser(c)
+	if !ok || !identity.IsAdmin {
 		c.JSON(http.StatusForbidden, gin.H{"error": "需要管理员权限"})
 		return
 	}
@@ -128,10 +138,9 @@ func (h *ProviderHandler) CreateProvider(c *gin.Context) {
 		return
 	}
 
-	// 验证配置JSON格式
-	var configJSON interface{}
-	if err := json.Unmarshal([]byte(req.Config), &configJSON); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "配置格式必须是有效的JSON"})
+	// 按服务商类型的配置字段校验配置
+	if err := providers.ValidateConfig(req.Type, req.Config); err != nil {
+		respondConfigError(c, err)
 		return
 	}
 
@@ -142,11 +151,18 @@ func (h *ProviderHandler) CreateProvider(c *gin.Context) {
 		return
 	}
 
+	// 加密敏感配置字段
+	encryptedConfig, err := providers.EncryptConfig(req.Type, req.Config)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "加密配置失败"})
+		return
+	}
+
 	// 创建DNS提供商
 	provider := models.DNSProvider{
 		Name:        req.Name,
 		Type:        req.Type,
-		Config:      req.Config,
+		Config:      encryptedConfig,
 		IsActive:    req.IsActive,
 		Description: req.Description,
 		SortOrder:   req.SortOrder,
@@ -157,6 +173,7 @@ func (h *ProviderHandler) CreateProvider(c *gin.Context) {
 		return
 	}
 
+	maskProviderConfig(&provider)
 	c.JSON(http.StatusCreated, gin.H{
 		"message":   "创建成功",
 		"provider":  provider,
@@ -166,8 +183,8 @@ func (h *ProviderHandler) CreateProvider(c *gin.Context) {
 // UpdateProvider 更新DNS提供商
 func (h *ProviderHandler) UpdateProvider(c *gin.Context) {
 	// 检查管理员权限
-	isAdmin, exists := c.Get("is_admin")
-	if !exists || !isAdmin.(bool) {
+	identity, ok := middleware.CurrentUser(c)
+	if !ok || !identity.IsAdmin {
 		c.JSON(http.StatusForbidden, gin.H{"error": "需要管理员权限"})
 		return
 	}
@@ -210,14 +227,30 @@ func (h *ProviderHandler) UpdateProvider(c *gin.Context) {
 	if req.Type != "" {
 		provider.Type = req.Type
 	}
-	if req.Config != "" {
-		// 验证配置JSON格式
-		var configJSON interface{}
-		if err := json.Unmarshal([]byte(req.Config), &configJSON); err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "配置格式必须是有效的JSON"})
+	if req.Config != "" || req.Type != "" {
+		config := provider.Config
+		if req.Config != "" {
+			// 提交的掩码表示保持原值
+			merged, err := providers.MergeMaskedConfig(provider.Type, req.Config, provider.Config)
+			if err != nil {
+				respondConfigError(c, &providers.ConfigError{Fields: map[string]string{"config": "配置格式必须是JSON对象"}})
+				return
+			}
+			config = merged
+		}
+
+		// 按服务商类型的配置字段校验配置，类型变化时也需要重新校验
+		if err := providers.ValidateConfig(provider.Type, config); err != nil {
+			respondConfigError(c, err)
+			return
+		}
+
+		encryptedConfig, err := providers.EncryptConfig(provider.Type, config)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "加密配置失败"})
 			return
 		}
-		provider.Config = req.Config
+		provider.Config = encryptedConfig
 	}
 	if req.IsActive != nil {
 		provider.IsActive = *req.IsActive
@@ -234,6 +267,7 @@ func (h *ProviderHandler) UpdateProvider(c *gin.Context) {
 		return
 	}
 
+	maskProviderConfig(&provider)
 	c.JSON(http.StatusOK, gin.H{
 		"message":   "更新成功",
 		"provider":  provider,
@@ -243,8 +277,8 @@ func (h *ProviderHandler) UpdateProvider(c *gin.Context) {
 // DeleteProvider 删除DNS提供商
 func (h *ProviderHandler) DeleteProvider(c *gin.Context) {
 	// 检查管理员权限
-	isAdmin, exists := c.Get("is_admin")
-	if !exists || !isAdmin.(bool) {
+	identity, ok := middleware.CurrentUser(c)
+	if !ok || !identity.IsAdmin {
 		c.JSON(http.StatusForbidden, gin.H{"error": "需要管理员权限"})
 		return
 	}
@@ -262,6 +296,18 @@ func (h *ProviderHandler) DeleteProvider(c *gin.Context) {
 		return
 	}
 
+	// 检查是否有绑定的域名
+	var count int64
+	if err := h.db.Model(&models.Domain{}).Where("provider_id = ? OR secondary_provider_id = ?", provider.ID, provider.ID).Count(&count).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "查询失败"})
+		return
+	}
+
+	if count > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "该DNS提供商下还有绑定的域名，无法删除"})
+		return
+	}
+
 	if err := h.db.Delete(&provider).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "删除失败"})
 		return
@@ -275,8 +321,8 @@ func (h *ProviderHandler) DeleteProvider(c *gin.Context) {
 // TestProvider 测试DNS提供商连接
 func (h *ProviderHandler) TestProvider(c *gin.Context) {
 	// 检查管理员权限
-	isAdmin, exists := c.Get("is_admin")
-	if !exists || !isAdmin.(bool) {
+	identity, ok := middleware.CurrentUser(c)
+	if !ok || !identity.IsAdmin {
 		c.JSON(http.StatusForbidden, gin.H{"error": "需要管理员权限"})
 		return
 	}
@@ -294,31 +340,81 @@ func (h *ProviderHandler) TestProvider(c *gin.Context) {
 		return
 	}
 
-	// TODO: 实现实际的DNS提供商测试逻辑
-	// 这里只是模拟测试结果
-	testResult := "DNS提供商连接测试成功"
+	// 使用配置实例化驱动并发起只读请求
+	start := time.Now()
+	testErr := testProviderConnection(c.Request.Context(), &provider)
+	latency := time.Since(start)
+
+	testResult := fmt.Sprintf("连接测试成功，耗时%dms", latency.Milliseconds())
+	if testErr != nil {
+		testResult = utils.TruncateRunes(fmt.Sprintf("连接测试失败，耗时%dms: %v", latency.Milliseconds(), testErr), 1000)
+	}
 	now := time.Now()
-	
+
 	// 更新测试时间和结果
 	provider.LastTestAt = &now
 	provider.TestResult = testResult
-	if err := h.db.Save(&provider).Error; err != nil {
+	if err := h.db.Model(&provider).Updates(map[string]interface{}{
+		"last_test_at": provider.LastTestAt,
+		"test_result":  provider.TestResult,
+	}).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "更新测试结果失败"})
 		return
 	}
 
+	if testErr != nil {
+		c.JSON(http.StatusBadGateway, gin.H{
+			"error":       "测试失败",
+			"test_result": testResult,
+			"latency_ms":  latency.Milliseconds(),
+			"tested_at":   now,
+		})
+		return
+	}
+
 	c.JSON(http.StatusOK, gin.H{
 		"message":     "测试成功",
 		"test_result": testResult,
+		"latency_ms":  latency.Milliseconds(),
 		"tested_at":   now,
 	})
 }
 
+// testProviderConnection 实例化DNS提供商驱动并验证凭据
+func testProviderConnection(ctx context.Context, provider *models.DNSProvider) error {
+	driver, err := providers.Open(provider)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, providerRequestTimeout)
+	defer cancel()
+	return driver.Test(ctx)
+}
+
+// respondConfigError 返回服务商配置校验错误，字段错误逐项列出
+func respondConfigError(c *gin.Context, err error) {
+	var configErr *providers.ConfigError
+	if errors.As(err, &configErr) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":  "配置校验失败",
+			"fields": configErr.Fields,
+		})
+		return
+	}
+	c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+}
+
+// maskProviderConfig 隐藏服务商配置中的敏感字段，仅用于API响应
+func maskProviderConfig(provider *models.DNSProvider) {
+	provider.Config = providers.MaskConfig(provider.Type, provider.Config)
+}
+
 // ToggleProviderStatus 切换DNS提供商状态
 func (h *ProviderHandler) ToggleProviderStatus(c *gin.Context) {
 	// 检查管理员权限
-	isAdmin, exists := c.Get("is_admin")
-	if !exists || !isAdmin.(bool) {
+	identity, ok := middleware.CurrentUser(c)
+	if !ok || !identity.IsAdmin {
 		c.JSON(http.StatusForbidden, gin.H{"error": "需要管理员权限"})
 		return
 	}
@@ -348,6 +444,7 @@ func (h *ProviderHandler) ToggleProviderStatus(c *gin.Context) {
 		statusText = "禁用"
 	}
 
+	maskProviderConfig(&provider)
 	c.JSON(http.StatusOK, gin.H{
 		"message":   "DNS提供商已" + statusText,
 		"provider": provider,
@@ -357,50 +454,14 @@ func (h *ProviderHandler) ToggleProviderStatus(c *gin.Context) {
 // GetProviderTypes 获取支持的DNS提供商类型
 func (h *ProviderHandler) GetProviderTypes(c *gin.Context) {
 	// 检查管理员权限
-	isAdmin, exists := c.Get("is_admin")
-	if !exists || !isAdmin.(bool) {
+	identity, ok := middleware.CurrentUser(c)
+	if !ok || !identity.IsAdmin {
 		c.JSON(http.StatusForbidden, gin.H{"error": "需要管理员权限"})
 		return
 	}
 
-	// 返回支持的DNS提供商类型
-	providerTypes := []gin.H{
-		{
-			"type":        "dnspod",
-			"name":        "DNSPod",
-			"description": "腾讯云DNSPod",
-			"config_fields": []gin.H{
-				{"name": "api_token", "type": "string", "label": "API Token", "required": true},
-			},
-		},
-		{
-			"type":        "aliyun",
-			"name":        "阿里云DNS",
-			"description": "阿里云域名解析",
-			"config_fields": []gin.H{
-				{"name": "access_key_id", "type": "string", "label": "AccessKey ID", "required": true},
-				{"name": "access_key_secret", "type": "string", "label": "AccessKey Secret", "required": true},
-			},
-		},
-		{
-			"type":        "cloudflare",
-			"name":        "Cloudflare",
-			"description": "Cloudflare DNS",
-			"config_fields": []gin.H{
-				{"name": "api_token", "type": "string", "label": "API Token", "required": true},
-				{"name": "zone_id", "type": "string", "label": "Zone ID", "required": true},
-			},
-		},
-		{
-			"type":        "godaddy",
-			"name":        "GoDaddy",
-			"description": "GoDaddy DNS",
-			"config_fields": []gin.H{
-				{"name": "api_key", "type": "string", "label": "API Key", "required": true},
-				{"name": "api_secret", "type": "string", "label": "API Secret", "required": true},
-			},
-		},
-	}
+	// 返回已注册驱动的DNS提供商类型及配置字段
+	providerTypes := providers.Schemas()
 
 	c.JSON(http.StatusOK, gin.H{
 		"provider_types": providerTypes,
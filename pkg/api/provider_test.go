@@ -0,0 +1,96 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"domain-max/pkg/dns/models"
+)
+
+// testProvider 测试服务商连接，返回状态码和保存的服务商
+func (s *testServer) testProvider(token string, id uint) (int, *models.DNSProvider) {
+	s.t.Helper()
+
+	code := s.do(http.MethodPost, fmt.Sprintf("/api/providers/%d/test", id), token, nil, nil)
+	var provider models.DNSProvider
+	if err := s.db.First(&provider, id).Error; err != nil {
+		s.t.Fatalf("查询服务商失败: %v", err)
+	}
+	return code, &provider
+}
+
+func TestTestProvider(t *testing.T) {
+	s := newTestServer(t)
+	admin := s.token(s.createUser("admin@example.com", true))
+
+	tests := []struct {
+		name      string
+		errorRate int
+		code      int
+		result    string // 保存的测试结果应包含的内容
+	}{
+		{"success", 0, http.StatusOK, "连接测试成功"},
+		{"injected error", 100, http.StatusBadGateway, "模拟服务商注入的错误"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider := s.createMockProvider(tt.name, "example.com", tt.errorRate)
+
+			code, saved := s.testProvider(admin, provider.ID)
+			if code != tt.code {
+				t.Fatalf("测试返回 %d，应为 %d", code, tt.code)
+			}
+			if saved.LastTestAt == nil || !strings.Contains(saved.TestResult, tt.result) {
+				t.Fatalf("保存的测试时间为 %v，结果为 %s", saved.LastTestAt, saved.TestResult)
+			}
+		})
+	}
+
+	user := s.token(s.createUser("user@example.com", false))
+	provider := s.createMockProvider("forbidden", "example.com", 0)
+	if code, saved := s.testProvider(user, provider.ID); code != http.StatusForbidden || saved.LastTestAt != nil {
+		t.Fatalf("普通用户测试返回 %d，测试时间为 %v", code, saved.LastTestAt)
+	}
+}
+
+func TestTestProviderTruncatesResult(t *testing.T) {
+	s := newTestServer(t)
+	admin := s.token(s.createUser("admin@example.com", true))
+
+	// 服务商返回超出测试结果字段长度的错误信息
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"errors":  []map[string]interface{}{{"code": 1000, "message": strings.Repeat("错误", 1000)}},
+		})
+	}))
+	t.Cleanup(server.Close)
+
+	provider := &models.DNSProvider{
+		Name:     "Cloudflare",
+		Type:     "cloudflare",
+		Config:   fmt.Sprintf(`{"api_token": "token", "endpoint": %q}`, server.URL),
+		IsActive: true,
+	}
+	if err := s.db.Create(provider).Error; err != nil {
+		t.Fatalf("创建服务商失败: %v", err)
+	}
+
+	code, saved := s.testProvider(admin, provider.ID)
+	if code != http.StatusBadGateway {
+		t.Fatalf("测试返回 %d", code)
+	}
+	if n := utf8.RuneCountInString(saved.TestResult); n != 1000 || !strings.HasPrefix(saved.TestResult, "连接测试失败") {
+		t.Fatalf("保存的测试结果有 %d 个字符: %.50s", n, saved.TestResult)
+	}
+	if saved.LastTestAt == nil {
+		t.Fatal("没有保存测试时间")
+	}
+}
@@ -1,7 +1,9 @@
 package api
 
 import (
+	"domain-max/pkg/auth/tokens"
 	"domain-max/pkg/config"
+	"domain-max/pkg/dns/providers"
 	"domain-max/pkg/middleware"
 
 	"github.com/gin-gonic/gin"
@@ -10,6 +12,9 @@ import (
 
 // SetupRoutes 设置API路由
 func SetupRoutes(router *gin.Engine, db *gorm.DB, cfg *config.Config) {
+	// 未在服务商配置中填写Token时使用环境变量中的DNSPod Token
+	providers.SetDefaults("dnspod", map[string]string{"api_token": cfg.DNSPodToken})
+
 	// 创建API处理器
 	authHandler := NewAuthHandler(db, cfg)
 	dnsHandler := NewDNSHandler(db)
@@ -17,6 +22,8 @@ func SetupRoutes(router *gin.Engine, db *gorm.DB, cfg *config.Config) {
 	userHandler := NewUserHandler(db)
 	smtpHandler := NewSMTPHandler(db)
 	providerHandler := NewProviderHandler(db)
+	driftHandler := NewDriftHandler(db)
+	mailHandler := NewMailHandler(db)
 
 	// API路由组
 	apiGroup := router.Group("/api")
@@ -30,15 +37,21 @@ func SetupRoutes(router *gin.Engine, db *gorm.DB, cfg *config.Config) {
 	authGroup := apiGroup.Group("/auth")
 	{
 		authGroup.POST("/register", authHandler.Register)
+		authGroup.GET("/verify-email", authHandler.VerifyEmail)
+		authGroup.POST("/verify-email", authHandler.VerifyEmail)
+		authGroup.POST("/resend-verification", authHandler.ResendVerification)
 		authGroup.POST("/login", authHandler.Login)
+		authGroup.POST("/refresh", authHandler.Refresh)
 		authGroup.POST("/forgot-password", authHandler.ForgotPassword)
 		authGroup.POST("/reset-password", authHandler.ResetPassword)
 	}
 
 	// 需要认证的路由
 	authRequiredGroup := apiGroup.Group("")
-	authRequiredGroup.Use(middleware.AuthMiddleware(cfg.JWTSecret))
+	authRequiredGroup.Use(middleware.AuthMiddleware(cfg.JWTSecret, tokens.NewRevocationList(db)))
 	{
+		authRequiredGroup.POST("/auth/logout", authHandler.Logout)
+
 		// 用户资料相关路由
 		authRequiredGroup.GET("/profile", authHandler.GetProfile)
 		authRequiredGroup.PUT("/profile", authHandler.UpdateProfile)
@@ -51,6 +64,7 @@ func SetupRoutes(router *gin.Engine, db *gorm.DB, cfg *config.Config) {
 		authRequiredGroup.POST("/dns-records", dnsHandler.CreateDNSRecord)
 		authRequiredGroup.PUT("/dns-records/:id", dnsHandler.UpdateDNSRecord)
 		authRequiredGroup.DELETE("/dns-records/:id", dnsHandler.DeleteDNSRecord)
+		authRequiredGroup.POST("/dns-records/:id/retry", dnsHandler.RetryDNSRecordSync)
 		authRequiredGroup.POST("/dns-records/batch", dnsHandler.BatchCreateDNSRecords)
 		authRequiredGroup.GET("/dns-records/export", dnsHandler.ExportDNSRecords)
 
@@ -84,6 +98,7 @@ func SetupRoutes(router *gin.Engine, db *gorm.DB, cfg *config.Config) {
 			adminGroup.DELETE("/smtp-configs/:id", smtpHandler.DeleteSMTPConfig)
 			adminGroup.POST("/smtp-configs/:id/test", smtpHandler.TestSMTPConfig)
 			adminGroup.PUT("/smtp-configs/:id/set-default", smtpHandler.SetDefaultSMTPConfig)
+			adminGroup.GET("/mail-deliveries", mailHandler.ListMailDeliveries)
 
 			// DNS提供商管理路由
 			adminGroup.GET("/providers", providerHandler.ListProviders)
@@ -94,6 +109,14 @@ func SetupRoutes(router *gin.Engine, db *gorm.DB, cfg *config.Config) {
 			adminGroup.POST("/providers/:id/test", providerHandler.TestProvider)
 			adminGroup.PUT("/providers/:id/toggle-status", providerHandler.ToggleProviderStatus)
 			adminGroup.GET("/providers/types", providerHandler.GetProviderTypes)
+
+			// DNS记录漂移检测路由
+			adminGroup.GET("/drift-reports", driftHandler.ListDriftReports)
+			adminGroup.GET("/domains/:id/drift", driftHandler.GetDomainDrift)
+			adminGroup.POST("/domains/:id/drift/check", driftHandler.CheckDomainDrift)
+
+			// 从DNS服务商导入域名记录
+			adminGroup.POST("/domains/:id/import", domainHandler.ImportDomainRecords)
 		}
 	}
 }
\ No newline at end of file
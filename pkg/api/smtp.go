@@ -1,7 +1,9 @@
 package api
 
 import (
+	"domain-max/pkg/email"
 	"domain-max/pkg/email/models"
+	"domain-max/pkg/middleware"
 	"net/http"
 	"strconv"
 	"time"
@@ -23,8 +25,8 @@ func NewSMTPHandler(db *gorm.DB) *SMTPHandler {
 // ListSMTPConfigs 获取SMTP配置列表
 func (h *SMTPHandler) ListSMTPConfigs(c *gin.Context) {
 	// 检查管理员权限
-	isAdmin, exists := c.Get("is_admin")
-	if !exists || !isAdmin.(bool) {
+	identity, ok := middleware.CurrentUser(c)
+	if !ok || !identity.IsAdmin {
 		c.JSON(http.StatusForbidden, gin.H{"error": "需要管理员权限"})
 		return
 	}
@@ -78,8 +80,8 @@ func (h *SMTPHandler) ListSMTPConfigs(c *gin.Context) {
 // GetSMTPConfig 获取单个SMTP配置
 func (h *SMTPHandler) GetSMTPConfig(c *gin.Context) {
 	// 检查管理员权限
-	isAdmin, exists := c.Get("is_admin")
-	if !exists || !isAdmin.(bool) {
+	identity, ok := middleware.CurrentUser(c)
+	if !ok || !identity.IsAdmin {
 		c.JSON(http.StatusForbidden, gin.H{"error": "需要管理员权限"})
 		return
 	}
@@ -103,8 +105,8 @@ func (h *SMTPHandler) GetSMTPConfig(c *gin.Context) {
 // CreateSMTPConfig 创建SMTP配置
 func (h *SMTPHandler) CreateSMTPConfig(c *gin.Context) {
 	// 检查管理员权限
-	isAdmin, exists := c.Get("is_admin")
-	if !exists || !isAdmin.(bool) {
+	identity, ok := middleware.CurrentUser(c)
+	if !ok || !identity.IsAdmin {
 		c.JSON(http.StatusForbidden, gin.H{"error": "需要管理员权限"})
 		return
 	}
@@ -120,6 +122,7 @@ func (h *SMTPHandler) CreateSMTPConfig(c *gin.Context) {
 		UseTLS      bool   `json:"use_tls"`
 		IsActive    bool   `json:"is_active"`
 		IsDefault   bool   `json:"is_default"`
+		SortOrder   int    `json:"sort_order"`
 		Description string `json:"description"`
 	}
 
@@ -155,6 +158,7 @@ func (h *SMTPHandler) CreateSMTPConfig(c *gin.Context) {
 		UseTLS:      req.UseTLS,
 		IsActive:    req.IsActive,
 		IsDefault:   req.IsDefault,
+		SortOrder:   req.SortOrder,
 		Description: req.Description,
 	}
 
@@ -172,8 +176,8 @@ func (h *SMTPHandler) CreateSMTPConfig(c *gin.Context) {
 // UpdateSMTPConfig 更新SMTP配置
 func (h *SMTPHandler) UpdateSMTPConfig(c *gin.Context) {
 	// 检查管理员权限
-	isAdmin, exists := c.Get("is_admin")
-	if !exists || !isAdmin.(bool) {
+	identity, ok := middleware.CurrentUser(c)
+	if !ok || !identity.IsAdmin {
 		c.JSON(http.StatusForbidden, gin.H{"error": "需要管理员权限"})
 		return
 	}
@@ -200,6 +204,7 @@ func (h *SMTPHandler) UpdateSMTPConfig(c *gin.Context) {
 		UseTLS      *bool  `json:"use_tls"`
 		IsActive    *bool  `json:"is_active"`
 		IsDefault   *bool  `json:"is_default"`
+		SortOrder   *int   `json:"sort_order"`
 		Description string `json:"description"`
 	}
 
@@ -252,6 +257,9 @@ func (h *SMTPHandler) UpdateSMTPConfig(c *gin.Context) {
 		}
 		config.IsDefault = *req.IsDefault
 	}
+	if req.SortOrder != nil {
+		config.SortOrder = *req.SortOrder
+	}
 	if req.Description != "" {
 		config.Description = req.Description
 	}
@@ -270,8 +278,8 @@ func (h *SMTPHandler) UpdateSMTPConfig(c *gin.Context) {
 // DeleteSMTPConfig 删除SMTP配置
 func (h *SMTPHandler) DeleteSMTPConfig(c *gin.Context) {
 	// 检查管理员权限
-	isAdmin, exists := c.Get("is_admin")
-	if !exists || !isAdmin.(bool) {
+	identity, ok := middleware.CurrentUser(c)
+	if !ok || !identity.IsAdmin {
 		c.JSON(http.StatusForbidden, gin.H{"error": "需要管理员权限"})
 		return
 	}
@@ -308,8 +316,8 @@ func (h *SMTPHandler) DeleteSMTPConfig(c *gin.Context) {
 // TestSMTPConfig 测试SMTP配置
 func (h *SMTPHandler) TestSMTPConfig(c *gin.Context) {
 	// 检查管理员权限
-	isAdmin, exists := c.Get("is_admin")
-	if !exists || !isAdmin.(bool) {
+	identity, ok := middleware.CurrentUser(c)
+	if !ok || !identity.IsAdmin {
 		c.JSON(http.StatusForbidden, gin.H{"error": "需要管理员权限"})
 		return
 	}
@@ -327,19 +335,61 @@ func (h *SMTPHandler) TestSMTPConfig(c *gin.Context) {
 		return
 	}
 
-	// TODO: 实现实际的SMTP测试逻辑
-	// 这里只是模拟测试结果
-	testResult := "SMTP连接测试成功"
+	// 测试邮件默认发送给当前管理员
+	var req struct {
+		To     string `json:"to" binding:"omitempty,email"`
+		Locale string `json:"locale"`
+	}
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	if req.To == "" {
+		req.To = identity.Email
+	}
+	if req.Locale == "" {
+		req.Locale = email.MatchLocale(c.GetHeader("Accept-Language"))
+	}
+
 	now := time.Now()
-	
+	msg, err := email.Render(email.TemplateTest, req.Locale, email.TestData{
+		ConfigName: config.Name,
+		Host:       config.Host,
+		Port:       config.Port,
+		SentAt:     now,
+	})
+	if err == nil {
+		msg.To = req.To
+		err = email.Send(&config, msg)
+	}
+
+	testResult := "SMTP连接测试成功，测试邮件已发送到 " + req.To
+	if err != nil {
+		testResult = "SMTP连接测试失败: " + err.Error()
+	}
+
 	// 更新测试时间和结果
 	config.LastTestAt = &now
 	config.TestResult = testResult
-	if err := h.db.Save(&config).Error; err != nil {
+	if err := h.db.Model(&config).UpdateColumns(map[string]interface{}{
+		"last_test_at": now,
+		"test_result":  testResult,
+	}).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "更新测试结果失败"})
 		return
 	}
 
+	if err != nil {
+		c.JSON(http.StatusBadGateway, gin.H{
+			"error":       "测试失败",
+			"test_result": testResult,
+			"tested_at":   now,
+		})
+		return
+	}
+
 	c.JSON(http.StatusOK, gin.H{
 		"message":     "测试成功",
 		"test_result": testResult,
@@ -350,8 +400,8 @@ func (h *SMTPHandler) TestSMTPConfig(c *gin.Context) {
 // SetDefaultSMTPConfig 设置默认SMTP配置
 func (h *SMTPHandler) SetDefaultSMTPConfig(c *gin.Context) {
 	// 检查管理员权限
-	isAdmin, exists := c.Get("is_admin")
-	if !exists || !isAdmin.(bool) {
+	identity, ok := middleware.CurrentUser(c)
+	if !ok || !identity.IsAdmin {
 		c.JSON(http.StatusForbidden, gin.H{"error": "需要管理员权限"})
 		return
 	}
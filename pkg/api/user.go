@@ -2,9 +2,12 @@ package api
 
 import (
 	authmodels "domain-max/pkg/auth/models"
+	"domain-max/pkg/auth/tokens"
 	dnsmodels "domain-max/pkg/dns/models"
+	"domain-max/pkg/middleware"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"golang.org/x/crypto/bcrypt"
@@ -24,8 +27,8 @@ func NewUserHandler(db *gorm.DB) *UserHandler {
 // ListUsers 获取用户列表（管理员功能）
 func (h *UserHandler) ListUsers(c *gin.Context) {
 	// 检查管理员权限
-	isAdmin, exists := c.Get("is_admin")
-	if !exists || !isAdmin.(bool) {
+	identity, ok := middleware.CurrentUser(c)
+	if !ok || !identity.IsAdmin {
 		c.JSON(http.StatusForbidden, gin.H{"error": "需要管理员权限"})
 		return
 	}
@@ -87,8 +90,8 @@ func (h *UserHandler) ListUsers(c *gin.Context) {
 // GetUser 获取单个用户信息（管理员功能）
 func (h *UserHandler) GetUser(c *gin.Context) {
 	// 检查管理员权限
-	isAdmin, exists := c.Get("is_admin")
-	if !exists || !isAdmin.(bool) {
+	identity, ok := middleware.CurrentUser(c)
+	if !ok || !identity.IsAdmin {
 		c.JSON(http.StatusForbidden, gin.H{"error": "需要管理员权限"})
 		return
 	}
@@ -112,8 +115,8 @@ func (h *UserHandler) GetUser(c *gin.Context) {
 // CreateUser 创建用户（管理员功能）
 func (h *UserHandler) CreateUser(c *gin.Context) {
 	// 检查管理员权限
-	isAdmin, exists := c.Get("is_admin")
-	if !exists || !isAdmin.(bool) {
+	identity, ok := middleware.CurrentUser(c)
+	if !ok || !identity.IsAdmin {
 		c.JSON(http.StatusForbidden, gin.H{"error": "需要管理员权限"})
 		return
 	}
@@ -164,15 +167,17 @@ func (h *UserHandler) CreateUser(c *gin.Context) {
 		return
 	}
 
-	// 创建用户
+	// 创建用户，管理员创建的账户不需要验证邮箱
+	now := time.Now()
 	user := authmodels.User{
-		Email:          req.Email,
-		Password:       string(hashedPassword),
-		Nickname:       req.Nickname,
-		IsActive:       req.IsActive,
-		IsAdmin:        req.IsAdmin,
-		DNSRecordQuota: req.DNSRecordQuota,
-		Status:         "normal",
+		Email:           req.Email,
+		Password:        string(hashedPassword),
+		Nickname:        req.Nickname,
+		IsActive:        req.IsActive,
+		EmailVerifiedAt: &now,
+		IsAdmin:         req.IsAdmin,
+		DNSRecordQuota:  req.DNSRecordQuota,
+		Status:          "normal",
 	}
 
 	if user.DNSRecordQuota == 0 {
@@ -193,8 +198,8 @@ func (h *UserHandler) CreateUser(c *gin.Context) {
 // UpdateUser 更新用户信息（管理员功能）
 func (h *UserHandler) UpdateUser(c *gin.Context) {
 	// 检查管理员权限
-	isAdmin, exists := c.Get("is_admin")
-	if !exists || !isAdmin.(bool) {
+	identity, ok := middleware.CurrentUser(c)
+	if !ok || !identity.IsAdmin {
 		c.JSON(http.StatusForbidden, gin.H{"error": "需要管理员权限"})
 		return
 	}
@@ -250,6 +255,7 @@ func (h *UserHandler) UpdateUser(c *gin.Context) {
 	if req.IsActive != nil {
 		user.IsActive = *req.IsActive
 	}
+	wasAdmin := user.IsAdmin
 	if req.IsAdmin != nil {
 		user.IsAdmin = *req.IsAdmin
 	}
@@ -272,7 +278,18 @@ func (h *UserHandler) UpdateUser(c *gin.Context) {
 		user.Status = req.Status
 	}
 
-	if err := h.db.Save(&user).Error; err != nil {
+	// 禁用用户或取消管理员权限时吊销其全部会话，已签发的令牌中仍带有旧的权限
+	revoke := !user.IsActive || user.Status != "normal" || (wasAdmin && !user.IsAdmin)
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(&user).Error; err != nil {
+			return err
+		}
+		if revoke {
+			return tokens.RevokeUser(tx, user.ID)
+		}
+		return nil
+	})
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "更新失败"})
 		return
 	}
@@ -286,8 +303,8 @@ func (h *UserHandler) UpdateUser(c *gin.Context) {
 // DeleteUser 删除用户（管理员功能）
 func (h *UserHandler) DeleteUser(c *gin.Context) {
 	// 检查管理员权限
-	isAdmin, exists := c.Get("is_admin")
-	if !exists || !isAdmin.(bool) {
+	identity, ok := middleware.CurrentUser(c)
+	if !ok || !identity.IsAdmin {
 		c.JSON(http.StatusForbidden, gin.H{"error": "需要管理员权限"})
 		return
 	}
@@ -306,8 +323,7 @@ func (h *UserHandler) DeleteUser(c *gin.Context) {
 	}
 
 	// 不能删除自己
-	currentUserID, exists := c.Get("user_id")
-	if exists && currentUserID.(uint) == user.ID {
+	if identity.UserID == user.ID {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "不能删除自己的账户"})
 		return
 	}
@@ -324,7 +340,13 @@ func (h *UserHandler) DeleteUser(c *gin.Context) {
 		return
 	}
 
-	if err := h.db.Delete(&user).Error; err != nil {
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(&user).Error; err != nil {
+			return err
+		}
+		return tokens.RevokeUser(tx, user.ID)
+	})
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "删除失败"})
 		return
 	}
@@ -337,8 +359,8 @@ func (h *UserHandler) DeleteUser(c *gin.Context) {
 // ResetUserPassword 重置用户密码（管理员功能）
 func (h *UserHandler) ResetUserPassword(c *gin.Context) {
 	// 检查管理员权限
-	isAdmin, exists := c.Get("is_admin")
-	if !exists || !isAdmin.(bool) {
+	identity, ok := middleware.CurrentUser(c)
+	if !ok || !identity.IsAdmin {
 		c.JSON(http.StatusForbidden, gin.H{"error": "需要管理员权限"})
 		return
 	}
@@ -378,8 +400,14 @@ func (h *UserHandler) ResetUserPassword(c *gin.Context) {
 		return
 	}
 
-	// 更新密码
-	if err := h.db.Model(&user).Update("password", string(hashedPassword)).Error; err != nil {
+	// 更新密码并吊销全部会话
+	err = h.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&user).Update("password", string(hashedPassword)).Error; err != nil {
+			return err
+		}
+		return tokens.RevokeUser(tx, user.ID)
+	})
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "密码重置失败"})
 		return
 	}
@@ -391,22 +419,22 @@ func (h *UserHandler) ResetUserPassword(c *gin.Context) {
 
 // GetUserStats 获取用户统计信息
 func (h *UserHandler) GetUserStats(c *gin.Context) {
-	userID, exists := c.Get("user_id")
-	if !exists {
+	identity, ok := middleware.CurrentUser(c)
+	if !ok {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "用户未认证"})
 		return
 	}
 
 	// 获取用户信息
 	var user authmodels.User
-	if err := h.db.First(&user, userID).Error; err != nil {
+	if err := h.db.First(&user, identity.UserID).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "用户不存在"})
 		return
 	}
 
 	// 统计DNS记录数量
 	var dnsRecordCount int64
-	if err := h.db.Model(&dnsmodels.DNSRecord{}).Where("user_id = ?", userID).Count(&dnsRecordCount).Error; err != nil {
+	if err := h.db.Model(&dnsmodels.DNSRecord{}).Where("user_id = ?", identity.UserID).Count(&dnsRecordCount).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "查询失败"})
 		return
 	}
@@ -418,7 +446,7 @@ func (h *UserHandler) GetUserStats(c *gin.Context) {
 	}
 
 	if err := h.db.Model(&dnsmodels.DNSRecord{}).
-		Where("user_id = ?", userID).
+		Where("user_id = ?", identity.UserID).
 		Select("type, count(*) as count").
 		Group("type").
 		Find(&typeStats).Error; err != nil {
@@ -441,8 +469,8 @@ func (h *UserHandler) GetUserStats(c *gin.Context) {
 // GetSystemStats 获取系统统计信息（管理员功能）
 func (h *UserHandler) GetSystemStats(c *gin.Context) {
 	// 检查管理员权限
-	isAdmin, exists := c.Get("is_admin")
-	if !exists || !isAdmin.(bool) {
+	identity, ok := middleware.CurrentUser(c)
+	if !ok || !identity.IsAdmin {
 		c.JSON(http.StatusForbidden, gin.H{"error": "需要管理员权限"})
 		return
 	}
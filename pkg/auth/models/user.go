@@ -11,42 +11,69 @@ import (
 
 // User 用户模型
 type User struct {
-	ID             uint           `json:"id" gorm:"primaryKey"`
-	Email          string         `json:"email" gorm:"uniqueIndex;not null;size:255"`
-	Password       string         `json:"-" gorm:"not null;size:255"` // bcrypt哈希后的密码
-	Nickname       string         `json:"nickname" gorm:"size:100"`   // 用户昵称
-	Avatar         string         `json:"avatar" gorm:"size:500"`     // 头像URL
-	IsActive       bool           `json:"is_active" gorm:"default:false;index"`
-	IsAdmin        bool           `json:"is_admin" gorm:"default:false;index"`
-	LastLoginAt    *time.Time     `json:"last_login_at"`                        // 最后登录时间
-	LoginCount     int            `json:"login_count" gorm:"default:0"`         // 登录次数
-	DNSRecordQuota int            `json:"dns_record_quota" gorm:"default:10"`   // DNS记录配额
-	Status         string         `json:"status" gorm:"default:normal;size:20"` // 用户状态：normal, suspended, banned
-	CreatedAt      time.Time      `json:"created_at" gorm:"index"`
-	UpdatedAt      time.Time      `json:"updated_at"`
-	DeletedAt      gorm.DeletedAt `json:"-" gorm:"index"`
-}
-
-// EmailVerification 邮箱验证模型
+	ID              uint           `json:"id" gorm:"primaryKey"`
+	Email           string         `json:"email" gorm:"uniqueIndex;not null;size:255"`
+	Password        string         `json:"-" gorm:"not null;size:255"`           // bcrypt哈希后的密码
+	Nickname        string         `json:"nickname" gorm:"size:100"`             // 用户昵称
+	Avatar          string         `json:"avatar" gorm:"size:500"`               // 头像URL
+	IsActive        bool           `json:"is_active" gorm:"default:false;index"` // 账户是否启用，由管理员控制
+	EmailVerifiedAt *time.Time     `json:"email_verified_at"`                    // 邮箱验证时间，为空表示尚未验证
+	IsAdmin         bool           `json:"is_admin" gorm:"default:false;index"`
+	LastLoginAt     *time.Time     `json:"last_login_at"`                        // 最后登录时间
+	LoginCount      int            `json:"login_count" gorm:"default:0"`         // 登录次数
+	DNSRecordQuota  int            `json:"dns_record_quota" gorm:"default:10"`   // DNS记录配额
+	Status          string         `json:"status" gorm:"default:normal;size:20"` // 用户状态：normal, suspended, banned
+	CreatedAt       time.Time      `json:"created_at" gorm:"index"`
+	UpdatedAt       time.Time      `json:"updated_at"`
+	DeletedAt       gorm.DeletedAt `json:"-" gorm:"index"`
+}
+
+// EmailVerification 邮箱验证模型，Token保存验证令牌的SHA-256哈希
 type EmailVerification struct {
 	ID        uint      `json:"id" gorm:"primaryKey"`
 	Email     string    `json:"email" gorm:"not null"`
-	Token     string    `json:"token" gorm:"uniqueIndex;not null"`
+	Token     string    `json:"-" gorm:"uniqueIndex;not null"`
 	ExpiresAt time.Time `json:"expires_at" gorm:"not null"`
 	Used      bool      `json:"used" gorm:"default:false"`
 	CreatedAt time.Time `json:"created_at"`
 }
 
-// PasswordReset 密码重置模型
+// PasswordReset 密码重置模型，Token保存重置令牌的SHA-256哈希
 type PasswordReset struct {
 	ID        uint      `json:"id" gorm:"primaryKey"`
 	Email     string    `json:"email" gorm:"not null"`
-	Token     string    `json:"token" gorm:"uniqueIndex;not null"`
+	Token     string    `json:"-" gorm:"uniqueIndex;not null"`
 	ExpiresAt time.Time `json:"expires_at" gorm:"not null"`
 	Used      bool      `json:"used" gorm:"default:false"`
 	CreatedAt time.Time `json:"created_at"`
 }
 
+// RefreshToken 刷新令牌，只保存令牌的SHA-256哈希
+//
+// 同一次登录签发的刷新令牌属于同一家族。每次刷新后旧令牌标记为已使用，
+// 已使用的令牌再次出现说明令牌可能泄露，此时吊销整个家族。
+type RefreshToken struct {
+	ID              uint       `json:"id" gorm:"primaryKey"`
+	UserID          uint       `json:"user_id" gorm:"not null;index"`
+	FamilyID        string     `json:"family_id" gorm:"not null;size:32;index"`
+	TokenHash       string     `json:"-" gorm:"not null;size:64;uniqueIndex"`
+	AccessTokenID   string     `json:"-" gorm:"size:32"` // 同时签发的登录令牌的jti，吊销家族时一并吊销
+	AccessExpiresAt time.Time  `json:"-"`                // 同时签发的登录令牌的过期时间
+	ExpiresAt       time.Time  `json:"expires_at" gorm:"not null;index"`
+	UsedAt          *time.Time `json:"used_at"`    // 刷新时间，已刷新的令牌不能再次使用
+	RevokedAt       *time.Time `json:"revoked_at"` // 吊销时间
+	CreatedAt       time.Time  `json:"created_at"`
+}
+
+// RevokedToken 已吊销的登录令牌，过期后删除
+type RevokedToken struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	JTI       string    `json:"jti" gorm:"not null;size:32;uniqueIndex"`
+	UserID    uint      `json:"user_id" gorm:"index"`
+	ExpiresAt time.Time `json:"expires_at" gorm:"not null;index"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
 // 请求和响应结构体
 
 // RegisterRequest 用户注册请求
@@ -65,9 +92,31 @@ type LoginRequest struct {
 
 // LoginResponse 登录响应
 type LoginResponse struct {
-	Token        string `json:"token"`
-	RefreshToken string `json:"refresh_token,omitempty"`
-	User         User   `json:"user"`
+	Token            string    `json:"token"`
+	ExpiresAt        time.Time `json:"expires_at"` // 登录令牌的过期时间
+	RefreshToken     string    `json:"refresh_token,omitempty"`
+	RefreshExpiresAt time.Time `json:"refresh_expires_at"`
+	User             User      `json:"user"`
+}
+
+// RefreshRequest 刷新令牌请求
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
+// LogoutRequest 退出登录请求，未提供刷新令牌时吊销当前登录令牌所属的会话
+type LogoutRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+// VerifyEmailRequest 邮箱验证请求
+type VerifyEmailRequest struct {
+	Token string `json:"token" form:"token" binding:"required"`
+}
+
+// ResendVerificationRequest 重新发送验证邮件请求
+type ResendVerificationRequest struct {
+	Email string `json:"email" binding:"required,email"`
 }
 
 // ForgotPasswordRequest 忘记密码请求
@@ -0,0 +1,63 @@
+package tokens
+
+import (
+	"errors"
+	"time"
+
+	"domain-max/pkg/auth/models"
+
+	"gorm.io/gorm"
+)
+
+const (
+	// PasswordResetTTL 密码重置链接的有效期
+	PasswordResetTTL = time.Hour
+	// passwordResetInterval 同一邮箱两次发送重置邮件的最短间隔
+	passwordResetInterval = time.Minute
+)
+
+var (
+	// ErrInvalidResetToken 重置令牌不存在、已使用或已过期
+	ErrInvalidResetToken = errors.New("重置链接无效或已过期")
+	// ErrPasswordResetThrottled 重置邮件发送过于频繁
+	ErrPasswordResetThrottled = errors.New("重置邮件发送过于频繁，请稍后再试")
+)
+
+// IssuePasswordReset 为邮箱生成密码重置令牌，同一邮箱之前未使用的令牌随即失效
+//
+// 只保存令牌的哈希，返回的令牌用于生成重置链接。
+func IssuePasswordReset(tx *gorm.DB, email string) (string, error) {
+	return passwordResetToken.issue(tx, email)
+}
+
+// ResetPassword 使用重置令牌更新用户密码并吊销其全部会话，令牌只能使用一次
+//
+// passwordHash为bcrypt哈希后的新密码。
+func ResetPassword(db *gorm.DB, token, passwordHash string) (*models.User, error) {
+	var user models.User
+	err := db.Transaction(func(tx *gorm.DB) error {
+		email, err := passwordResetToken.consume(tx, token)
+		if err != nil {
+			return err
+		}
+
+		if err := tx.Where("email = ?", email).First(&user).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrInvalidResetToken
+			}
+			return err
+		}
+		if !user.IsActive || user.Status != "normal" || user.EmailVerifiedAt == nil {
+			return ErrUserUnavailable
+		}
+
+		if err := tx.Model(&user).Update("password", passwordHash).Error; err != nil {
+			return err
+		}
+		return RevokeUser(tx, user.ID)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
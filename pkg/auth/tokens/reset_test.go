@@ -0,0 +1,133 @@
+package tokens
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"domain-max/pkg/auth/models"
+
+	"gorm.io/gorm"
+)
+
+// issueReset 为用户签发密码重置令牌
+func issueReset(t *testing.T, db *gorm.DB, email string) string {
+	t.Helper()
+
+	token, err := IssuePasswordReset(db, email)
+	if err != nil {
+		t.Fatalf("签发重置令牌失败: %v", err)
+	}
+	return token
+}
+
+// assertResetUsed 检查重置令牌的使用状态
+func assertResetUsed(t *testing.T, db *gorm.DB, token string, want bool) {
+	t.Helper()
+
+	var reset models.PasswordReset
+	if err := db.Where("token = ?", hashToken(token)).First(&reset).Error; err != nil {
+		t.Fatalf("查询重置令牌失败: %v", err)
+	}
+	if reset.Used != want {
+		t.Fatalf("重置令牌的使用状态为 %v，应为 %v", reset.Used, want)
+	}
+}
+
+func TestResetPassword(t *testing.T) {
+	db := newTestDB(t)
+	user := createUser(t, db, "user@example.com")
+	pair, identity := issuePair(t, db, user)
+	token := issueReset(t, db, user.Email)
+
+	got, err := ResetPassword(db, token, "new-hash")
+	if err != nil {
+		t.Fatalf("重置密码失败: %v", err)
+	}
+	if got.ID != user.ID {
+		t.Fatalf("重置了用户 %d 的密码", got.ID)
+	}
+	var stored models.User
+	db.First(&stored, user.ID)
+	if stored.Password != "new-hash" {
+		t.Fatalf("密码为 %s", stored.Password)
+	}
+
+	// 重置密码后吊销全部会话
+	if _, _, err := Rotate(db, testSecret, pair.RefreshToken); !errors.Is(err, ErrInvalidRefreshToken) {
+		t.Fatalf("重置密码后使用旧的刷新令牌返回 %v", err)
+	}
+	assertRevoked(t, db, identity, true)
+
+	if _, err := ResetPassword(db, token, "other-hash"); !errors.Is(err, ErrInvalidResetToken) {
+		t.Fatalf("再次使用重置令牌返回 %v", err)
+	}
+}
+
+func TestResetPasswordExpired(t *testing.T) {
+	db := newTestDB(t)
+	user := createUser(t, db, "user@example.com")
+	token := issueReset(t, db, user.Email)
+	db.Model(&models.PasswordReset{}).Where("token = ?", hashToken(token)).
+		Update("expires_at", time.Now().Add(-time.Minute))
+
+	if _, err := ResetPassword(db, token, "new-hash"); !errors.Is(err, ErrInvalidResetToken) {
+		t.Fatalf("使用过期的重置令牌返回 %v", err)
+	}
+	if _, err := ResetPassword(db, "unknown", "new-hash"); !errors.Is(err, ErrInvalidResetToken) {
+		t.Fatalf("使用不存在的重置令牌返回 %v", err)
+	}
+}
+
+func TestIssuePasswordResetThrottled(t *testing.T) {
+	db := newTestDB(t)
+	user := createUser(t, db, "user@example.com")
+	first := issueReset(t, db, user.Email)
+
+	if _, err := IssuePasswordReset(db, user.Email); !errors.Is(err, ErrPasswordResetThrottled) {
+		t.Fatalf("间隔内再次签发返回 %v", err)
+	}
+
+	db.Model(&models.PasswordReset{}).Where("email = ?", user.Email).
+		Update("created_at", time.Now().Add(-passwordResetInterval))
+	second := issueReset(t, db, user.Email)
+
+	// 新令牌签发后旧令牌失效
+	if _, err := ResetPassword(db, first, "new-hash"); !errors.Is(err, ErrInvalidResetToken) {
+		t.Fatalf("使用被替换的重置令牌返回 %v", err)
+	}
+	if _, err := ResetPassword(db, second, "new-hash"); err != nil {
+		t.Fatalf("使用新的重置令牌失败: %v", err)
+	}
+}
+
+func TestResetPasswordUnavailableUser(t *testing.T) {
+	tests := []struct {
+		name   string
+		column string
+		value  interface{}
+	}{
+		{"disabled", "is_active", false},
+		{"unverified", "email_verified_at", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := newTestDB(t)
+			user := createUser(t, db, "user@example.com")
+			token := issueReset(t, db, user.Email)
+			db.Model(user).Update(tt.column, tt.value)
+
+			if _, err := ResetPassword(db, token, "new-hash"); !errors.Is(err, ErrUserUnavailable) {
+				t.Fatalf("返回的错误为 %v，应为 %v", err, ErrUserUnavailable)
+			}
+			// 事务回滚，令牌保持未使用，密码不变
+			assertResetUsed(t, db, token, false)
+			var stored models.User
+			db.First(&stored, user.ID)
+			if stored.Password != user.Password {
+				t.Fatalf("密码被修改为 %s", stored.Password)
+			}
+		})
+	}
+}
@@ -0,0 +1,108 @@
+package tokens
+
+import (
+	"errors"
+	"time"
+
+	"domain-max/pkg/auth/models"
+
+	"gorm.io/gorm"
+)
+
+// singleUseToken 通过邮件发送的一次性令牌，数据库中只保存令牌的哈希
+//
+// 邮箱验证和密码重置使用不同的表，表结构相同。
+type singleUseToken struct {
+	model     interface{}   // 令牌表的模型
+	ttl       time.Duration // 令牌的有效期
+	interval  time.Duration // 同一邮箱两次签发令牌的最短间隔
+	throttled error         // 签发过于频繁时返回的错误
+	invalid   error         // 令牌不存在、已使用或已过期时返回的错误
+}
+
+// singleUseRow 一次性令牌表中的公共字段
+type singleUseRow struct {
+	ID        uint
+	Email     string
+	ExpiresAt time.Time
+	Used      bool
+	CreatedAt time.Time
+}
+
+var (
+	emailVerificationToken = singleUseToken{
+		model:     &models.EmailVerification{},
+		ttl:       EmailVerificationTTL,
+		interval:  emailVerificationInterval,
+		throttled: ErrVerificationThrottled,
+		invalid:   ErrInvalidVerificationToken,
+	}
+	passwordResetToken = singleUseToken{
+		model:     &models.PasswordReset{},
+		ttl:       PasswordResetTTL,
+		interval:  passwordResetInterval,
+		throttled: ErrPasswordResetThrottled,
+		invalid:   ErrInvalidResetToken,
+	}
+)
+
+// issue 为邮箱生成令牌，同一邮箱之前未使用的令牌随即失效，返回的令牌用于生成邮件中的链接
+func (s singleUseToken) issue(tx *gorm.DB, email string) (string, error) {
+	var last singleUseRow
+	err := tx.Model(s.model).Where("email = ?", email).Order("created_at DESC").First(&last).Error
+	if err == nil && time.Since(last.CreatedAt) < s.interval {
+		return "", s.throttled
+	}
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return "", err
+	}
+
+	if err := tx.Model(s.model).
+		Where("email = ? AND used = ?", email, false).
+		Update("used", true).Error; err != nil {
+		return "", err
+	}
+
+	token, err := randomHex(32)
+	if err != nil {
+		return "", err
+	}
+	now := time.Now()
+	if err := tx.Model(s.model).Create(map[string]interface{}{
+		"email":      email,
+		"token":      hashToken(token),
+		"expires_at": now.Add(s.ttl),
+		"used":       false,
+		"created_at": now,
+	}).Error; err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// consume 将令牌标记为已使用并返回令牌对应的邮箱，同一令牌并发使用时只有一个请求成功
+//
+// 应在事务中调用，后续操作失败时回滚事务，令牌保持未使用。
+func (s singleUseToken) consume(tx *gorm.DB, token string) (string, error) {
+	var row singleUseRow
+	if err := tx.Model(s.model).Where("token = ?", hashToken(token)).First(&row).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return "", s.invalid
+		}
+		return "", err
+	}
+	if row.Used || time.Now().After(row.ExpiresAt) {
+		return "", s.invalid
+	}
+
+	result := tx.Model(s.model).
+		Where("id = ? AND used = ?", row.ID, false).
+		Update("used", true)
+	if result.Error != nil {
+		return "", result.Error
+	}
+	if result.RowsAffected == 0 {
+		return "", s.invalid
+	}
+	return row.Email, nil
+}
@@ -0,0 +1,273 @@
+package tokens
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"domain-max/pkg/auth/models"
+	"domain-max/pkg/middleware"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// RefreshTokenTTL 刷新令牌的有效期
+const RefreshTokenTTL = 30 * 24 * time.Hour
+
+var (
+	// ErrInvalidRefreshToken 刷新令牌不存在、已过期或已被吊销
+	ErrInvalidRefreshToken = errors.New("刷新令牌无效或已过期")
+	// ErrRefreshTokenReused 已使用的刷新令牌再次被使用，该令牌所属的会话已被吊销，同时属于ErrInvalidRefreshToken
+	ErrRefreshTokenReused = fmt.Errorf("%w，该令牌已被使用，会话已被吊销，请重新登录", ErrInvalidRefreshToken)
+	// ErrUserUnavailable 用户不存在、未激活或状态异常
+	ErrUserUnavailable = errors.New("账户不可用，请联系管理员")
+)
+
+// Pair 同时签发的登录令牌和刷新令牌
+type Pair struct {
+	AccessToken      string
+	AccessExpiresAt  time.Time
+	RefreshToken     string
+	RefreshExpiresAt time.Time
+}
+
+// Issue 用户登录时签发登录令牌和新家族的刷新令牌
+func Issue(db *gorm.DB, secret string, user *models.User) (*Pair, error) {
+	family, err := randomHex(16)
+	if err != nil {
+		return nil, err
+	}
+	return issue(db, secret, user, family)
+}
+
+// Rotate 使用刷新令牌换取新的登录令牌和刷新令牌，旧的刷新令牌随即失效
+//
+// 已刷新过的令牌再次出现时吊销其所属家族的全部令牌并返回ErrRefreshTokenReused。
+func Rotate(db *gorm.DB, secret, refreshToken string) (*Pair, *models.User, error) {
+	var (
+		pair   *Pair
+		user   models.User
+		reused bool
+	)
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var token models.RefreshToken
+		if err := tx.Where("token_hash = ?", hashToken(refreshToken)).First(&token).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrInvalidRefreshToken
+			}
+			return err
+		}
+		if token.RevokedAt != nil || time.Now().After(token.ExpiresAt) {
+			return ErrInvalidRefreshToken
+		}
+		if token.UsedAt != nil {
+			reused = true
+			return revokeFamily(tx, token.FamilyID)
+		}
+
+		// 并发刷新时只有一个请求能标记成功，其余请求按重复使用处理
+		result := tx.Model(&models.RefreshToken{}).
+			Where("id = ? AND used_at IS NULL AND revoked_at IS NULL", token.ID).
+			Update("used_at", time.Now())
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			reused = true
+			return revokeFamily(tx, token.FamilyID)
+		}
+
+		if err := tx.First(&user, token.UserID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrUserUnavailable
+			}
+			return err
+		}
+		if !user.IsActive || user.Status != "normal" {
+			return ErrUserUnavailable
+		}
+
+		var err error
+		pair, err = issue(tx, secret, &user, token.FamilyID)
+		return err
+	})
+	if reused && err == nil {
+		err = ErrRefreshTokenReused
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	return pair, &user, nil
+}
+
+// Logout 吊销当前登录令牌及其所属会话的刷新令牌
+//
+// refreshToken为空时按登录令牌的jti查找会话。
+func Logout(db *gorm.DB, identity *middleware.Identity, refreshToken string) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := revokeAccessToken(tx, identity.TokenID, identity.UserID, identity.ExpiresAt); err != nil {
+			return err
+		}
+
+		query := tx.Model(&models.RefreshToken{}).Where("user_id = ?", identity.UserID)
+		if refreshToken != "" {
+			query = query.Where("token_hash = ?", hashToken(refreshToken))
+		} else {
+			query = query.Where("access_token_id = ?", identity.TokenID)
+		}
+		var families []string
+		if err := query.Distinct().Pluck("family_id", &families).Error; err != nil {
+			return err
+		}
+		for _, family := range families {
+			if err := revokeFamily(tx, family); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// RevokeUser 吊销用户的全部会话，用于重置密码、禁用或删除用户
+func RevokeUser(tx *gorm.DB, userID uint) error {
+	var families []string
+	if err := tx.Model(&models.RefreshToken{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Distinct().Pluck("family_id", &families).Error; err != nil {
+		return err
+	}
+	for _, family := range families {
+		if err := revokeFamily(tx, family); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RevocationList 按jti查询已吊销的登录令牌，实现middleware.RevocationChecker
+type RevocationList struct {
+	db *gorm.DB
+}
+
+// NewRevocationList 创建吊销列表
+func NewRevocationList(db *gorm.DB) *RevocationList {
+	return &RevocationList{db: db}
+}
+
+// IsRevoked 登录令牌是否已被吊销
+func (l *RevocationList) IsRevoked(jti string) (bool, error) {
+	var count int64
+	if err := l.db.Model(&models.RevokedToken{}).Where("jti = ?", jti).Count(&count).Error; err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// PurgeExpired 删除已过期的刷新令牌和吊销记录，过期的令牌本身已无法通过验证
+func PurgeExpired(db *gorm.DB) error {
+	now := time.Now()
+	if err := db.Where("expires_at < ?", now).Delete(&models.RevokedToken{}).Error; err != nil {
+		return err
+	}
+	return db.Where("expires_at < ?", now).Delete(&models.RefreshToken{}).Error
+}
+
+// RunCleanup 定期清理过期的令牌记录，直到ctx取消
+func RunCleanup(ctx context.Context, db *gorm.DB, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := PurgeExpired(db); err != nil {
+				log.Printf("清理过期令牌失败: %v", err)
+			}
+		}
+	}
+}
+
+// issue 签发登录令牌和指定家族的刷新令牌
+func issue(tx *gorm.DB, secret string, user *models.User, family string) (*Pair, error) {
+	access, identity, err := middleware.SignAccessToken(secret, user.ID, user.Email, user.IsAdmin)
+	if err != nil {
+		return nil, err
+	}
+	refresh, err := randomHex(32)
+	if err != nil {
+		return nil, err
+	}
+
+	token := models.RefreshToken{
+		UserID:          user.ID,
+		FamilyID:        family,
+		TokenHash:       hashToken(refresh),
+		AccessTokenID:   identity.TokenID,
+		AccessExpiresAt: identity.ExpiresAt,
+		ExpiresAt:       time.Now().Add(RefreshTokenTTL),
+	}
+	if err := tx.Create(&token).Error; err != nil {
+		return nil, err
+	}
+
+	return &Pair{
+		AccessToken:      access,
+		AccessExpiresAt:  identity.ExpiresAt,
+		RefreshToken:     refresh,
+		RefreshExpiresAt: token.ExpiresAt,
+	}, nil
+}
+
+// revokeFamily 吊销家族中的全部刷新令牌，以及随之签发且尚未过期的登录令牌
+func revokeFamily(tx *gorm.DB, family string) error {
+	var tokens []models.RefreshToken
+	if err := tx.Where("family_id = ?", family).Find(&tokens).Error; err != nil {
+		return err
+	}
+
+	now := time.Now()
+	for _, token := range tokens {
+		if token.AccessTokenID == "" || token.AccessExpiresAt.Before(now) {
+			continue
+		}
+		if err := revokeAccessToken(tx, token.AccessTokenID, token.UserID, token.AccessExpiresAt); err != nil {
+			return err
+		}
+	}
+
+	return tx.Model(&models.RefreshToken{}).
+		Where("family_id = ? AND revoked_at IS NULL", family).
+		Update("revoked_at", now).Error
+}
+
+// revokeAccessToken 将登录令牌加入吊销列表
+func revokeAccessToken(tx *gorm.DB, jti string, userID uint, expiresAt time.Time) error {
+	return tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&models.RevokedToken{
+		JTI:       jti,
+		UserID:    userID,
+		ExpiresAt: expiresAt,
+	}).Error
+}
+
+// hashToken 刷新令牌的SHA-256哈希
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// randomHex 生成n字节的随机数并编码为十六进制
+func randomHex(n int) (string, error) {
+	buf := make([]byte, n)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
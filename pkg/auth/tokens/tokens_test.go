@@ -0,0 +1,272 @@
+package tokens
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"domain-max/pkg/auth/models"
+	"domain-max/pkg/config"
+	"domain-max/pkg/database"
+	"domain-max/pkg/middleware"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+const testSecret = "test_jwt_secret_key_for_token_tests_only_0123456789abcdefghijklmn"
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+// newTestDB 创建已执行全部迁移的SQLite内存数据库
+func newTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+
+	db, err := database.Connect(&config.Config{Environment: "test", DBType: "sqlite", DBPath: ":memory:"})
+	if err != nil {
+		t.Fatalf("连接数据库失败: %v", err)
+	}
+	db.Logger = logger.Default.LogMode(logger.Silent)
+	if err := database.Migrate(db); err != nil {
+		t.Fatalf("数据库迁移失败: %v", err)
+	}
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+	return db
+}
+
+// createUser 创建已启用并已验证邮箱的用户
+func createUser(t *testing.T, db *gorm.DB, email string) *models.User {
+	t.Helper()
+
+	now := time.Now()
+	user := &models.User{
+		Email:           email,
+		Password:        "unused",
+		IsActive:        true,
+		EmailVerifiedAt: &now,
+		Status:          "normal",
+	}
+	if err := db.Create(user).Error; err != nil {
+		t.Fatalf("创建用户失败: %v", err)
+	}
+	return user
+}
+
+// issuePair 签发令牌并返回登录令牌中的身份
+func issuePair(t *testing.T, db *gorm.DB, user *models.User) (*Pair, *middleware.Identity) {
+	t.Helper()
+
+	pair, err := Issue(db, testSecret, user)
+	if err != nil {
+		t.Fatalf("签发令牌失败: %v", err)
+	}
+	identity, err := middleware.ParseAccessToken(testSecret, pair.AccessToken)
+	if err != nil {
+		t.Fatalf("解析登录令牌失败: %v", err)
+	}
+	return pair, identity
+}
+
+// assertRevoked 检查登录令牌的jti是否已被吊销
+func assertRevoked(t *testing.T, db *gorm.DB, identity *middleware.Identity, want bool) {
+	t.Helper()
+
+	revoked, err := NewRevocationList(db).IsRevoked(identity.TokenID)
+	if err != nil {
+		t.Fatalf("查询吊销列表失败: %v", err)
+	}
+	if revoked != want {
+		t.Fatalf("登录令牌 %s 的吊销状态为 %v，应为 %v", identity.TokenID, revoked, want)
+	}
+}
+
+func TestIssue(t *testing.T) {
+	db := newTestDB(t)
+	user := createUser(t, db, "admin@example.com")
+	db.Model(user).Update("is_admin", true)
+
+	pair, identity := issuePair(t, db, user)
+	if pair.RefreshToken == "" {
+		t.Fatal("没有签发刷新令牌")
+	}
+	if identity.UserID != user.ID || identity.Email != user.Email || !identity.IsAdmin || identity.TokenID == "" {
+		t.Fatalf("登录令牌中的身份为 %+v", identity)
+	}
+	if _, err := middleware.ParseAccessToken("another_secret", pair.AccessToken); err == nil {
+		t.Fatal("使用其他密钥解析登录令牌成功")
+	}
+}
+
+func TestRotate(t *testing.T) {
+	db := newTestDB(t)
+	user := createUser(t, db, "user@example.com")
+	old, _ := issuePair(t, db, user)
+
+	pair, got, err := Rotate(db, testSecret, old.RefreshToken)
+	if err != nil {
+		t.Fatalf("刷新令牌失败: %v", err)
+	}
+	if got.ID != user.ID || pair.RefreshToken == old.RefreshToken || pair.AccessToken == old.AccessToken {
+		t.Fatalf("刷新后的令牌为 %+v", pair)
+	}
+	if _, err := middleware.ParseAccessToken(testSecret, pair.AccessToken); err != nil {
+		t.Fatalf("新的登录令牌无效: %v", err)
+	}
+
+	if _, _, err := Rotate(db, testSecret, old.RefreshToken); !errors.Is(err, ErrInvalidRefreshToken) {
+		t.Fatalf("再次使用旧的刷新令牌返回 %v", err)
+	}
+}
+
+func TestRotateReuseRevokesFamily(t *testing.T) {
+	db := newTestDB(t)
+	user := createUser(t, db, "user@example.com")
+	first, firstIdentity := issuePair(t, db, user)
+	// 其他会话不受影响
+	other, otherIdentity := issuePair(t, db, user)
+
+	second, _, err := Rotate(db, testSecret, first.RefreshToken)
+	if err != nil {
+		t.Fatalf("刷新令牌失败: %v", err)
+	}
+	secondIdentity, err := middleware.ParseAccessToken(testSecret, second.AccessToken)
+	if err != nil {
+		t.Fatalf("解析登录令牌失败: %v", err)
+	}
+
+	if _, _, err := Rotate(db, testSecret, first.RefreshToken); !errors.Is(err, ErrRefreshTokenReused) {
+		t.Fatalf("重复使用刷新令牌返回 %v", err)
+	}
+	if _, _, err := Rotate(db, testSecret, second.RefreshToken); !errors.Is(err, ErrInvalidRefreshToken) {
+		t.Fatalf("家族被吊销后使用最新的刷新令牌返回 %v", err)
+	}
+	assertRevoked(t, db, firstIdentity, true)
+	assertRevoked(t, db, secondIdentity, true)
+
+	assertRevoked(t, db, otherIdentity, false)
+	if _, _, err := Rotate(db, testSecret, other.RefreshToken); err != nil {
+		t.Fatalf("其他会话的刷新令牌失效: %v", err)
+	}
+}
+
+func TestRotateRejectsInvalidTokens(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(db *gorm.DB, pair *Pair) string
+		want   error
+	}{
+		{
+			name:   "unknown",
+			modify: func(*gorm.DB, *Pair) string { return "unknown" },
+			want:   ErrInvalidRefreshToken,
+		},
+		{
+			name: "expired",
+			modify: func(db *gorm.DB, pair *Pair) string {
+				db.Model(&models.RefreshToken{}).Where("token_hash = ?", hashToken(pair.RefreshToken)).
+					Update("expires_at", time.Now().Add(-time.Minute))
+				return pair.RefreshToken
+			},
+			want: ErrInvalidRefreshToken,
+		},
+		{
+			name: "revoked",
+			modify: func(db *gorm.DB, pair *Pair) string {
+				db.Model(&models.RefreshToken{}).Where("token_hash = ?", hashToken(pair.RefreshToken)).
+					Update("revoked_at", time.Now())
+				return pair.RefreshToken
+			},
+			want: ErrInvalidRefreshToken,
+		},
+		{
+			name: "disabled user",
+			modify: func(db *gorm.DB, pair *Pair) string {
+				db.Model(&models.User{}).Where("email = ?", "user@example.com").Update("is_active", false)
+				return pair.RefreshToken
+			},
+			want: ErrUserUnavailable,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := newTestDB(t)
+			pair, _ := issuePair(t, db, createUser(t, db, "user@example.com"))
+
+			if _, _, err := Rotate(db, testSecret, tt.modify(db, pair)); !errors.Is(err, tt.want) {
+				t.Fatalf("返回的错误为 %v，应为 %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestLogout(t *testing.T) {
+	db := newTestDB(t)
+	user := createUser(t, db, "user@example.com")
+	pair, identity := issuePair(t, db, user)
+
+	router := gin.New()
+	router.GET("/profile", middleware.AuthMiddleware(testSecret, NewRevocationList(db)), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+	request := func() int {
+		req := httptest.NewRequest(http.MethodGet, "/profile", nil)
+		req.Header.Set("Authorization", "Bearer "+pair.AccessToken)
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	if code := request(); code != http.StatusOK {
+		t.Fatalf("退出登录前请求返回 %d", code)
+	}
+	// 未提供刷新令牌时按登录令牌的jti找到会话
+	if err := Logout(db, identity, ""); err != nil {
+		t.Fatalf("退出登录失败: %v", err)
+	}
+	if code := request(); code != http.StatusUnauthorized {
+		t.Fatalf("退出登录后请求返回 %d", code)
+	}
+	if _, _, err := Rotate(db, testSecret, pair.RefreshToken); !errors.Is(err, ErrInvalidRefreshToken) {
+		t.Fatalf("退出登录后使用刷新令牌返回 %v", err)
+	}
+}
+
+func TestRevokeUser(t *testing.T) {
+	db := newTestDB(t)
+	user := createUser(t, db, "user@example.com")
+	other := createUser(t, db, "other@example.com")
+
+	var pairs []*Pair
+	var identities []*middleware.Identity
+	for i := 0; i < 2; i++ {
+		pair, identity := issuePair(t, db, user)
+		pairs = append(pairs, pair)
+		identities = append(identities, identity)
+	}
+	otherPair, otherIdentity := issuePair(t, db, other)
+
+	if err := RevokeUser(db, user.ID); err != nil {
+		t.Fatalf("吊销用户会话失败: %v", err)
+	}
+	for i := range pairs {
+		if _, _, err := Rotate(db, testSecret, pairs[i].RefreshToken); !errors.Is(err, ErrInvalidRefreshToken) {
+			t.Fatalf("会话 %d 的刷新令牌返回 %v", i, err)
+		}
+		assertRevoked(t, db, identities[i], true)
+	}
+
+	assertRevoked(t, db, otherIdentity, false)
+	if _, _, err := Rotate(db, testSecret, otherPair.RefreshToken); err != nil {
+		t.Fatalf("其他用户的刷新令牌失效: %v", err)
+	}
+}
@@ -0,0 +1,61 @@
+package tokens
+
+import (
+	"errors"
+	"time"
+
+	"domain-max/pkg/auth/models"
+
+	"gorm.io/gorm"
+)
+
+const (
+	// EmailVerificationTTL 邮箱验证链接的有效期
+	EmailVerificationTTL = 24 * time.Hour
+	// emailVerificationInterval 同一邮箱两次发送验证邮件的最短间隔
+	emailVerificationInterval = time.Minute
+)
+
+var (
+	// ErrInvalidVerificationToken 验证令牌不存在、已使用或已过期
+	ErrInvalidVerificationToken = errors.New("验证链接无效或已过期")
+	// ErrVerificationThrottled 验证邮件发送过于频繁
+	ErrVerificationThrottled = errors.New("验证邮件发送过于频繁，请稍后再试")
+)
+
+// IssueEmailVerification 为邮箱生成验证令牌，同一邮箱之前未使用的令牌随即失效
+//
+// 只保存令牌的哈希，返回的令牌用于生成验证链接。
+func IssueEmailVerification(tx *gorm.DB, email string) (string, error) {
+	return emailVerificationToken.issue(tx, email)
+}
+
+// VerifyEmail 使用验证令牌标记对应用户的邮箱已验证，令牌只能使用一次
+//
+// 只设置邮箱验证时间，不修改账户的启用状态，管理员禁用的账户验证后仍保持禁用。
+func VerifyEmail(db *gorm.DB, token string) (*models.User, error) {
+	var user models.User
+	err := db.Transaction(func(tx *gorm.DB) error {
+		email, err := emailVerificationToken.consume(tx, token)
+		if err != nil {
+			return err
+		}
+
+		if err := tx.Where("email = ?", email).First(&user).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrInvalidVerificationToken
+			}
+			return err
+		}
+		if user.EmailVerifiedAt != nil {
+			return nil
+		}
+		now := time.Now()
+		user.EmailVerifiedAt = &now
+		return tx.Model(&user).Update("email_verified_at", now).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
@@ -0,0 +1,49 @@
+package tokens
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"domain-max/pkg/auth/models"
+)
+
+func TestVerifyEmail(t *testing.T) {
+	db := newTestDB(t)
+	user := createUser(t, db, "user@example.com")
+	db.Model(user).Update("email_verified_at", nil)
+
+	token, err := IssueEmailVerification(db, user.Email)
+	if err != nil {
+		t.Fatalf("签发验证令牌失败: %v", err)
+	}
+	if _, err := IssueEmailVerification(db, user.Email); !errors.Is(err, ErrVerificationThrottled) {
+		t.Fatalf("间隔内再次签发返回 %v", err)
+	}
+
+	got, err := VerifyEmail(db, token)
+	if err != nil {
+		t.Fatalf("验证邮箱失败: %v", err)
+	}
+	if got.EmailVerifiedAt == nil {
+		t.Fatal("邮箱验证时间未设置")
+	}
+	if _, err := VerifyEmail(db, token); !errors.Is(err, ErrInvalidVerificationToken) {
+		t.Fatalf("再次使用验证令牌返回 %v", err)
+	}
+}
+
+func TestVerifyEmailExpired(t *testing.T) {
+	db := newTestDB(t)
+	user := createUser(t, db, "user@example.com")
+	token, err := IssueEmailVerification(db, user.Email)
+	if err != nil {
+		t.Fatalf("签发验证令牌失败: %v", err)
+	}
+	db.Model(&models.EmailVerification{}).Where("token = ?", hashToken(token)).
+		Update("expires_at", time.Now().Add(-time.Minute))
+
+	if _, err := VerifyEmail(db, token); !errors.Is(err, ErrInvalidVerificationToken) {
+		t.Fatalf("使用过期的验证令牌返回 %v", err)
+	}
+}
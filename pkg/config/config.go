@@ -23,7 +23,8 @@ type Config struct {
 	DBUser     string
 	DBPassword string
 	DBName     string
-	DBType     string // postgres 或 mysql
+	DBType     string // postgres、mysql 或 sqlite
+	DBPath     string // SQLite数据库文件路径，:memory: 表示内存数据库
 
 	// JWT配置
 	JWTSecret string
@@ -40,15 +41,42 @@ type Config struct {
 
 	// DNSPod配置
 	DNSPodToken string
+
+	// DNS记录漂移检测间隔（分钟），0表示不启用
+	DriftCheckInterval int
+
+	// 并发发送邮件的后台任务数量
+	MailWorkers int
 }
 
+// Load 读取并验证配置，配置无效时panic
 func Load() *Config {
+	cfg := Parse()
+
+	// 验证必要的配置项
+	if err := cfg.Validate(); err != nil {
+		panic("配置验证失败: " + err.Error())
+	}
+
+	return cfg
+}
+
+// Parse 从环境变量和.env文件读取配置，不做验证
+func Parse() *Config {
 	// 尝试加载.env文件
 	godotenv.Load()
 
+	environment := getEnv("ENVIRONMENT", "development")
+
+	// 开发环境默认使用SQLite，无需安装数据库
+	defaultDBType := "postgres"
+	if environment == "development" {
+		defaultDBType = "sqlite"
+	}
+
 	cfg := &Config{
 		Port:        getEnv("PORT", "8080"),
-		Environment: getEnv("ENVIRONMENT", "development"),
+		Environment: environment,
 		BaseURL:     getEnv("BASE_URL", ""), // 为空时将自动检测
 
 		DBHost:     getEnv("DB_HOST", "localhost"),
@@ -56,7 +84,8 @@ func Load() *Config {
 		DBUser:     getEnv("DB_USER", "postgres"),
 		DBPassword: getEnv("DB_PASSWORD", ""), // 移除默认密码，强制用户设置
 		DBName:     getEnv("DB_NAME", "domain_manager"),
-		DBType:     getEnv("DB_TYPE", "postgres"),
+		DBType:     getEnv("DB_TYPE", defaultDBType),
+		DBPath:     getEnv("DB_PATH", "data/domain-max.db"),
 
 		JWTSecret: getEnv("JWT_SECRET", ""), // 移除默认JWT密钥，强制用户设置
 
@@ -69,6 +98,10 @@ func Load() *Config {
 		SMTPFrom:     getEnv("SMTP_FROM", "noreply@example.com"),
 
 		DNSPodToken: getEnv("DNSPOD_TOKEN", ""),
+
+		DriftCheckInterval: getEnvInt("DRIFT_CHECK_INTERVAL", 60),
+
+		MailWorkers: getEnvInt("MAIL_WORKERS", 2),
 	}
 
 	// 如果没有设置BASE_URL，根据环境和端口自动生成
@@ -81,11 +114,6 @@ func Load() *Config {
 		}
 	}
 
-	// 验证必要的配置项
-	if err := cfg.validate(); err != nil {
-		panic("配置验证失败: " + err.Error())
-	}
-
 	return cfg
 }
 
@@ -105,8 +133,8 @@ func getEnvInt(key string, defaultValue int) int {
 	return defaultValue
 }
 
-// validate 验证配置项的有效性
-func (c *Config) validate() error {
+// Validate 验证配置项的有效性，开发环境中未设置的密钥使用默认值
+func (c *Config) Validate() error {
 	isProduction := c.Environment == "production"
 	
 	// 验证端口
@@ -116,10 +144,13 @@ func (c *Config) validate() error {
 	
 	// 验证必要的配置项
 	requiredConfigs := map[string]string{
-		"DB_PASSWORD":    c.DBPassword,
 		"JWT_SECRET":     c.JWTSecret,
 		"ENCRYPTION_KEY": c.EncryptionKey,
 	}
+	// SQLite不需要数据库密码
+	if c.DBType != "sqlite" {
+		requiredConfigs["DB_PASSWORD"] = c.DBPassword
+	}
 	
 	for key, value := range requiredConfigs {
 		if value == "" {
@@ -160,10 +191,13 @@ func (c *Config) validate() error {
 	}
 	
 	// 验证数据库类型
-	validDBTypes := []string{"postgres", "mysql"}
+	validDBTypes := []string{"postgres", "mysql", "sqlite"}
 	if !contains(validDBTypes, c.DBType) {
 		return fmt.Errorf("不支持的数据库类型: %s，支持的类型: %s", c.DBType, strings.Join(validDBTypes, ", "))
 	}
+	if c.DBType == "sqlite" && c.DBPath == "" {
+		return errors.New("使用SQLite时 DB_PATH 不能为空")
+	}
 	
 	return nil
 }
@@ -0,0 +1,205 @@
+package database
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// 第1个迁移创建的表结构快照
+//
+// 快照与迁移一同固定下来，之后修改 pkg/*/models 中的模型不会影响已发布的迁移；
+// 模型新增或修改字段时应另外编写迁移。字段和标签与引入版本迁移前的模型保持一致，
+// 以便已通过AutoMigrate建表的数据库可以直接执行该迁移。
+
+type baselineUser struct {
+	ID             uint   `gorm:"primaryKey"`
+	Email          string `gorm:"uniqueIndex;not null;size:255"`
+	Password       string `gorm:"not null;size:255"`
+	Nickname       string `gorm:"size:100"`
+	Avatar         string `gorm:"size:500"`
+	IsActive       bool   `gorm:"default:false;index"`
+	IsAdmin        bool   `gorm:"default:false;index"`
+	LastLoginAt    *time.Time
+	LoginCount     int       `gorm:"default:0"`
+	DNSRecordQuota int       `gorm:"default:10"`
+	Status         string    `gorm:"default:normal;size:20"`
+	CreatedAt      time.Time `gorm:"index"`
+	UpdatedAt      time.Time
+	DeletedAt      gorm.DeletedAt `gorm:"index"`
+}
+
+func (baselineUser) TableName() string { return "users" }
+
+type baselineEmailVerification struct {
+	ID        uint      `gorm:"primaryKey"`
+	Email     string    `gorm:"not null"`
+	Token     string    `gorm:"uniqueIndex;not null"`
+	ExpiresAt time.Time `gorm:"not null"`
+	Used      bool      `gorm:"default:false"`
+	CreatedAt time.Time
+}
+
+func (baselineEmailVerification) TableName() string { return "email_verifications" }
+
+type baselinePasswordReset struct {
+	ID        uint      `gorm:"primaryKey"`
+	Email     string    `gorm:"not null"`
+	Token     string    `gorm:"uniqueIndex;not null"`
+	ExpiresAt time.Time `gorm:"not null"`
+	Used      bool      `gorm:"default:false"`
+	CreatedAt time.Time
+}
+
+func (baselinePasswordReset) TableName() string { return "password_resets" }
+
+type baselineDomain struct {
+	ID                  uint   `gorm:"primaryKey"`
+	Name                string `gorm:"uniqueIndex;not null"`
+	DomainType          string
+	IsActive            bool `gorm:"default:true"`
+	Description         string
+	ProviderID          *uint  `gorm:"index"`
+	ZoneID              string `gorm:"size:100"`
+	SecondaryProviderID *uint  `gorm:"index"`
+	SecondaryZoneID     string `gorm:"size:100"`
+	CreatedAt           time.Time
+	UpdatedAt           time.Time
+	DeletedAt           gorm.DeletedAt `gorm:"index"`
+
+	DNSRecords []baselineDNSRecord `gorm:"foreignKey:DomainID"`
+}
+
+func (baselineDomain) TableName() string { return "domains" }
+
+type baselineDNSRecord struct {
+	ID         uint   `gorm:"primaryKey"`
+	UserID     uint   `gorm:"not null;index"`
+	DomainID   uint   `gorm:"not null;index"`
+	Subdomain  string `gorm:"not null;size:63"`
+	Type       string `gorm:"not null;size:10;index"`
+	Value      string `gorm:"not null;size:500"`
+	TTL        int    `gorm:"default:600;check:ttl >= 1 AND ttl <= 604800"`
+	Priority   int    `gorm:"default:0"`
+	Weight     int    `gorm:"default:0"`
+	Port       int    `gorm:"default:0"`
+	Proxied    bool   `gorm:"default:false"`
+	ExternalID string `gorm:"size:100"`
+	Status     string `gorm:"default:pending;size:20;index"`
+	SyncError  string `gorm:"size:1000"`
+	SyncedAt   *time.Time
+	Comment    string    `gorm:"size:500"`
+	CreatedAt  time.Time `gorm:"index"`
+	UpdatedAt  time.Time
+	DeletedAt  gorm.DeletedAt `gorm:"index"`
+
+	Domain baselineDomain `json:"-" gorm:"foreignKey:DomainID;constraint:OnDelete:CASCADE"`
+}
+
+func (baselineDNSRecord) TableName() string { return "dns_records" }
+
+type baselineDNSProvider struct {
+	ID          uint   `gorm:"primaryKey"`
+	Name        string `gorm:"not null;size:100;uniqueIndex"`
+	Type        string `gorm:"not null;size:50;index"`
+	Config      string `gorm:"type:text"`
+	IsActive    bool   `gorm:"default:false;index"`
+	Description string `gorm:"size:500"`
+	SortOrder   int    `gorm:"default:0"`
+	LastTestAt  *time.Time
+	TestResult  string    `gorm:"size:1000"`
+	CreatedAt   time.Time `gorm:"index"`
+	UpdatedAt   time.Time
+	DeletedAt   gorm.DeletedAt `gorm:"index"`
+}
+
+func (baselineDNSProvider) TableName() string { return "dns_providers" }
+
+type baselineDriftReport struct {
+	ID         uint `gorm:"primaryKey"`
+	DomainID   uint `gorm:"not null;uniqueIndex"`
+	ProviderID uint `gorm:"index"`
+	Missing    int
+	Extra      int
+	Changed    int
+	Details    string    `gorm:"type:text"`
+	Error      string    `gorm:"size:1000"`
+	CheckedAt  time.Time `gorm:"index"`
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+
+	Domain baselineDomain `gorm:"foreignKey:DomainID;constraint:OnDelete:CASCADE"`
+}
+
+func (baselineDriftReport) TableName() string { return "drift_reports" }
+
+type baselineSyncJob struct {
+	ID          uint      `gorm:"primaryKey"`
+	RecordID    uint      `gorm:"not null;index"`
+	DomainID    uint      `gorm:"not null;index"`
+	ProviderID  uint      `gorm:"index"`
+	Action      string    `gorm:"not null;size:10"`
+	Snapshot    string    `gorm:"type:text"`
+	Status      string    `gorm:"not null;size:20;index"`
+	Attempts    int       `gorm:"default:0"`
+	MaxAttempts int       `gorm:"default:8"`
+	LastError   string    `gorm:"size:1000"`
+	NextRunAt   time.Time `gorm:"index"`
+	LockedAt    *time.Time
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+func (baselineSyncJob) TableName() string { return "sync_jobs" }
+
+type baselineRecordSync struct {
+	ID         uint   `gorm:"primaryKey"`
+	RecordID   uint   `gorm:"not null;uniqueIndex:idx_record_syncs_record_provider"`
+	ProviderID uint   `gorm:"not null;uniqueIndex:idx_record_syncs_record_provider"`
+	ExternalID string `gorm:"size:100"`
+	Status     string `gorm:"size:20"`
+	SyncError  string `gorm:"size:1000"`
+	SyncedAt   *time.Time
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+}
+
+func (baselineRecordSync) TableName() string { return "record_syncs" }
+
+type baselineSMTPConfig struct {
+	ID          uint   `gorm:"primaryKey"`
+	Name        string `gorm:"not null;size:100"`
+	Host        string `gorm:"not null;size:255"`
+	Port        int    `gorm:"not null;default:587"`
+	Username    string `gorm:"not null;size:255"`
+	Password    string `gorm:"not null;size:255"`
+	FromEmail   string `gorm:"not null;size:255"`
+	FromName    string `gorm:"size:100"`
+	IsActive    bool   `gorm:"default:false;index"`
+	IsDefault   bool   `gorm:"default:false"`
+	UseTLS      bool   `gorm:"default:true"`
+	Description string `gorm:"size:500"`
+	LastTestAt  *time.Time
+	TestResult  string    `gorm:"size:1000"`
+	CreatedAt   time.Time `gorm:"index"`
+	UpdatedAt   time.Time
+	DeletedAt   gorm.DeletedAt `gorm:"index"`
+}
+
+func (baselineSMTPConfig) TableName() string { return "smtp_configs" }
+
+// baselineTables 第1个迁移创建的表，按依赖顺序排列
+func baselineTables() []interface{} {
+	return []interface{}{
+		&baselineUser{},
+		&baselineEmailVerification{},
+		&baselinePasswordReset{},
+		&baselineDomain{},
+		&baselineDNSRecord{},
+		&baselineDNSProvider{},
+		&baselineDriftReport{},
+		&baselineSyncJob{},
+		&baselineRecordSync{},
+		&baselineSMTPConfig{},
+	}
+}
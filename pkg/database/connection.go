@@ -4,7 +4,10 @@ import (
 	"domain-max/pkg/config"
 	"fmt"
 	"log"
+	"os"
+	"path/filepath"
 
+	"github.com/glebarez/sqlite"
 	"gorm.io/driver/mysql"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
@@ -24,6 +27,12 @@ func Connect(cfg *config.Config) (*gorm.DB, error) {
 		dsn := fmt.Sprintf("%s:%s@tcp(%s:%s)/%s?charset=utf8mb4&parseTime=True&loc=Local",
 			cfg.DBUser, cfg.DBPassword, cfg.DBHost, cfg.DBPort, cfg.DBName)
 		dialector = mysql.Open(dsn)
+	case "sqlite":
+		dsn, err := sqliteDSN(cfg.DBPath)
+		if err != nil {
+			return nil, err
+		}
+		dialector = sqlite.Open(dsn)
 	default:
 		return nil, fmt.Errorf("不支持的数据库类型: %s", cfg.DBType)
 	}
@@ -51,7 +60,31 @@ func Connect(cfg *config.Config) (*gorm.DB, error) {
 	// 设置连接池参数
 	sqlDB.SetMaxIdleConns(10)
 	sqlDB.SetMaxOpenConns(100)
+	if cfg.DBType == "sqlite" && cfg.DBPath == sqliteMemory {
+		// 内存数据库随连接关闭而销毁，只使用一个常驻连接
+		sqlDB.SetMaxIdleConns(1)
+		sqlDB.SetMaxOpenConns(1)
+	}
 	
 	log.Println("数据库连接成功")
 	return db, nil
+}
+
+// sqliteMemory 表示SQLite内存数据库的路径
+const sqliteMemory = ":memory:"
+
+// sqliteDSN 生成SQLite连接串，数据库文件所在目录不存在时自动创建
+func sqliteDSN(path string) (string, error) {
+	// 启用外键约束；文件数据库使用WAL模式并在锁冲突时等待，允许后台任务与请求并发读写
+	pragmas := "_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)"
+	if path == sqliteMemory {
+		return "file::memory:?" + pragmas, nil
+	}
+
+	if dir := filepath.Dir(path); dir != "." {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return "", fmt.Errorf("创建SQLite数据库目录失败: %v", err)
+		}
+	}
+	return "file:" + path + "?" + pragmas + "&_pragma=journal_mode(WAL)", nil
 }
\ No newline at end of file
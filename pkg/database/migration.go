@@ -1,43 +1,343 @@
 package database
 
 import (
-	authmodels "domain-max/pkg/auth/models"
-	dnsmodels "domain-max/pkg/dns/models"
-	emailmodels "domain-max/pkg/email/models"
-	"log"
+	"encoding/json"
+	"time"
 
 	"gorm.io/gorm"
 )
 
-// Migrate 执行数据库迁移
-func Migrate(db *gorm.DB) error {
-	log.Println("开始数据库迁移...")
-	
-	// 用户相关表
-	if err := db.AutoMigrate(
-		&authmodels.User{},
-		&authmodels.EmailVerification{},
-		&authmodels.PasswordReset{},
-	); err != nil {
+// migrations 全部数据库迁移，新迁移追加在末尾并使用递增的版本号
+//
+// 迁移中只使用本包内的表结构快照，不引用 pkg/*/models 中的模型，
+// 否则模型新增字段后，新数据库执行早期迁移时会访问尚不存在的列。
+var migrations = []Migration{
+	{
+		Version:     1,
+		Description: "创建初始表结构",
+		Up: func(tx *gorm.DB) error {
+			// 已通过AutoMigrate建表的数据库执行该迁移时只补齐缺少的列和索引
+			return tx.AutoMigrate(baselineTables()...)
+		},
+		Down: func(tx *gorm.DB) error {
+			tables := baselineTables()
+			for i := len(tables) - 1; i >= 0; i-- {
+				if err := tx.Migrator().DropTable(tables[i]); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	},
+	{
+		Version:     2,
+		Description: "旧版本的记录状态active改为pending，由同步任务确认",
+		Up:          backfillRecordStatus,
+		// 无法区分原本为active的记录，回滚时保持不变
+		Down: func(tx *gorm.DB) error { return nil },
+	},
+	{
+		Version:     3,
+		Description: "写入初始数据",
+		Up:          seedInitialData,
+		// 初始数据可能已被管理员修改，回滚时保留
+		Down: func(tx *gorm.DB) error { return nil },
+	},
+	{
+		Version:     4,
+		Description: "创建刷新令牌和已吊销令牌表",
+		Up: func(tx *gorm.DB) error {
+			return tx.AutoMigrate(&refreshTokenV4{}, &revokedTokenV4{})
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropTable(&revokedTokenV4{}, &refreshTokenV4{})
+		},
+	},
+	{
+		Version:     5,
+		Description: "添加SMTP配置排序字段和邮件队列表",
+		Up: func(tx *gorm.DB) error {
+			// 旧版本的列表查询已按sort_order排序，列可能已由AutoMigrate创建
+			if !tx.Migrator().HasColumn(&smtpConfigV5{}, "SortOrder") {
+				if err := tx.Migrator().AddColumn(&smtpConfigV5{}, "SortOrder"); err != nil {
+					return err
+				}
+			}
+			return tx.AutoMigrate(&mailJobV5{}, &mailDeliveryV5{})
+		},
+		Down: func(tx *gorm.DB) error {
+			if err := tx.Migrator().DropTable(&mailDeliveryV5{}, &mailJobV5{}); err != nil {
+				return err
+			}
+			return tx.Migrator().DropColumn(&smtpConfigV5{}, "SortOrder")
+		},
+	},
+	{
+		Version:     6,
+		Description: "用户添加邮箱验证时间，启用状态只表示管理员是否禁用账户",
+		Up: func(tx *gorm.DB) error {
+			if err := tx.Migrator().AddColumn(&userV6{}, "EmailVerifiedAt"); err != nil {
+				return err
+			}
+			// 已启用的用户已验证邮箱或由管理员创建
+			if err := tx.Model(&userV6{}).Where("is_active = ?", true).
+				Update("email_verified_at", gorm.Expr("created_at")).Error; err != nil {
+				return err
+			}
+			// 有验证记录但从未完成验证的用户是等待验证的注册用户，不是被管理员禁用的账户
+			verified := tx.Model(&emailVerificationV6{}).Select("email").Where("used = ?", true)
+			pending := tx.Model(&emailVerificationV6{}).Select("email")
+			return tx.Model(&userV6{}).
+				Where("is_active = ? AND email IN (?) AND email NOT IN (?)", false, pending, verified).
+				Update("is_active", true).Error
+		},
+		Down: func(tx *gorm.DB) error {
+			// 旧版本以启用状态表示邮箱已验证，未验证的用户恢复为未启用
+			if err := tx.Model(&userV6{}).Where("email_verified_at IS NULL").
+				Update("is_active", false).Error; err != nil {
+				return err
+			}
+			return tx.Migrator().DropColumn(&userV6{}, "EmailVerifiedAt")
+		},
+	},
+	{
+		Version:     7,
+		Description: "漂移检测结果按域名和服务商分别保存",
+		Up: func(tx *gorm.DB) error {
+			// 先创建新索引，MySQL的外键需要domain_id上的索引
+			if err := tx.Migrator().CreateIndex(&driftReportV7{}, "idx_drift_reports_domain_provider"); err != nil {
+				return err
+			}
+			return tx.Migrator().DropIndex(&driftReportV6{}, "idx_drift_reports_domain_id")
+		},
+		Down: func(tx *gorm.DB) error {
+			// 只保留主DNS服务商的检测结果
+			if err := tx.Where("NOT EXISTS (SELECT 1 FROM domains WHERE domains.id = drift_reports.domain_id AND domains.provider_id = drift_reports.provider_id)").
+				Delete(&driftReportV7{}).Error; err != nil {
+				return err
+			}
+			if err := tx.Migrator().CreateIndex(&driftReportV6{}, "idx_drift_reports_domain_id"); err != nil {
+				return err
+			}
+			return tx.Migrator().DropIndex(&driftReportV7{}, "idx_drift_reports_domain_provider")
+		},
+	},
+}
+
+// refreshTokenV4 第4个迁移创建的刷新令牌表
+type refreshTokenV4 struct {
+	ID              uint   `gorm:"primaryKey"`
+	UserID          uint   `gorm:"not null;index"`
+	FamilyID        string `gorm:"not null;size:32;index"`
+	TokenHash       string `gorm:"not null;size:64;uniqueIndex"`
+	AccessTokenID   string `gorm:"size:32"`
+	AccessExpiresAt time.Time
+	ExpiresAt       time.Time `gorm:"not null;index"`
+	UsedAt          *time.Time
+	RevokedAt       *time.Time
+	CreatedAt       time.Time
+}
+
+func (refreshTokenV4) TableName() string { return "refresh_tokens" }
+
+// revokedTokenV4 第4个迁移创建的已吊销令牌表
+type revokedTokenV4 struct {
+	ID        uint      `gorm:"primaryKey"`
+	JTI       string    `gorm:"not null;size:32;uniqueIndex"`
+	UserID    uint      `gorm:"index"`
+	ExpiresAt time.Time `gorm:"not null;index"`
+	CreatedAt time.Time
+}
+
+func (revokedTokenV4) TableName() string { return "revoked_tokens" }
+
+// smtpConfigV5 第5个迁移为SMTP配置表添加的列
+type smtpConfigV5 struct {
+	SortOrder int `gorm:"default:0"`
+}
+
+func (smtpConfigV5) TableName() string { return "smtp_configs" }
+
+// mailJobV5 第5个迁移创建的邮件任务表
+type mailJobV5 struct {
+	ID           uint   `gorm:"primaryKey"`
+	Recipient    string `gorm:"not null;size:255;index"`
+	Subject      string `gorm:"not null;size:255"`
+	TextBody     string `gorm:"type:text"`
+	HTMLBody     string `gorm:"type:text"`
+	Template     string `gorm:"size:50"`
+	Status       string `gorm:"not null;size:20;index"`
+	Attempts     int    `gorm:"default:0"`
+	MaxAttempts  int    `gorm:"default:6"`
+	LastError    string `gorm:"size:1000"`
+	SMTPConfigID *uint
+	NextRunAt    time.Time `gorm:"index"`
+	LockedAt     *time.Time
+	SentAt       *time.Time
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+}
+
+func (mailJobV5) TableName() string { return "mail_jobs" }
+
+// mailDeliveryV5 第5个迁移创建的邮件投递记录表
+type mailDeliveryV5 struct {
+	ID             uint `gorm:"primaryKey"`
+	JobID          uint `gorm:"not null;index"`
+	Attempt        int
+	SMTPConfigID   uint   `gorm:"index"`
+	SMTPConfigName string `gorm:"size:100"`
+	Recipient      string `gorm:"size:255"`
+	Status         string `gorm:"not null;size:20;index"`
+	Error          string `gorm:"size:1000"`
+	DurationMs     int64
+	CreatedAt      time.Time `gorm:"index"`
+}
+
+func (mailDeliveryV5) TableName() string { return "mail_deliveries" }
+
+// userV6 第6个迁移为用户表添加的列
+type userV6 struct {
+	ID              uint `gorm:"primaryKey"`
+	IsActive        bool
+	EmailVerifiedAt *time.Time
+}
+
+func (userV6) TableName() string { return "users" }
+
+// emailVerificationV6 第6个迁移读取的邮箱验证表
+type emailVerificationV6 struct {
+	Email string
+	Used  bool
+}
+
+func (emailVerificationV6) TableName() string { return "email_verifications" }
+
+// driftReportV6 第7个迁移之前漂移检测结果表的唯一索引，每个域名一条
+type driftReportV6 struct {
+	ID       uint `gorm:"primaryKey"`
+	DomainID uint `gorm:"not null;uniqueIndex:idx_drift_reports_domain_id"`
+}
+
+func (driftReportV6) TableName() string { return "drift_reports" }
+
+// driftReportV7 第7个迁移为漂移检测结果表添加的唯一索引，每个域名的每个服务商一条
+type driftReportV7 struct {
+	ID         uint `gorm:"primaryKey"`
+	DomainID   uint `gorm:"not null;uniqueIndex:idx_drift_reports_domain_provider"`
+	ProviderID uint `gorm:"uniqueIndex:idx_drift_reports_domain_provider"`
+}
+
+func (driftReportV7) TableName() string { return "drift_reports" }
+
+// backfillRecordStatus 将旧版本状态为active的记录改为等待同步
+//
+// 旧版本不论DNS服务商是否写入成功都将记录标记为active，因此为绑定了服务商的记录写入更新任务，
+// 由同步任务将记录重新写入服务商后再标记为已同步。域名未绑定服务商的记录直接视为已同步。
+func backfillRecordStatus(tx *gorm.DB) error {
+	var records []baselineDNSRecord
+	if err := tx.Preload("Domain").Where("status = ?", "active").Find(&records).Error; err != nil {
 		return err
 	}
-	
-	// DNS相关表
-	if err := db.AutoMigrate(
-		&dnsmodels.Domain{},
-		&dnsmodels.DNSRecord{},
-		&dnsmodels.DNSProvider{},
-	); err != nil {
+
+	now := time.Now()
+	for _, record := range records {
+		var providerIDs []uint
+		for _, id := range []*uint{record.Domain.ProviderID, record.Domain.SecondaryProviderID} {
+			if id != nil && (len(providerIDs) == 0 || providerIDs[0] != *id) {
+				providerIDs = append(providerIDs, *id)
+			}
+		}
+		if len(providerIDs) == 0 {
+			if err := tx.Model(&record).UpdateColumns(map[string]interface{}{"status": "synced", "synced_at": now}).Error; err != nil {
+				return err
+			}
+			continue
+		}
+
+		// 更新任务以快照作为服务商中原有的内容
+		snapshot, err := json.Marshal(record)
+		if err != nil {
+			return err
+		}
+		primaryID := record.Domain.ProviderID
+		for _, providerID := range providerIDs {
+			job := baselineSyncJob{
+				RecordID:    record.ID,
+				DomainID:    record.DomainID,
+				ProviderID:  providerID,
+				Action:      "update",
+				Snapshot:    string(snapshot),
+				Status:      "pending",
+				MaxAttempts: 8,
+				NextRunAt:   now,
+			}
+			if err := tx.Create(&job).Error; err != nil {
+				return err
+			}
+			// 旧版本只在记录中保存主服务商的记录ID
+			state := baselineRecordSync{RecordID: record.ID, ProviderID: providerID, Status: "pending"}
+			if primaryID != nil && *primaryID == providerID {
+				state.ExternalID = record.ExternalID
+			}
+			if err := tx.Create(&state).Error; err != nil {
+				return err
+			}
+		}
+		if err := tx.Model(&record).UpdateColumn("status", "pending").Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// seedInitialData 写入示例配置
+//
+// 只向没有数据的表写入示例数据，因此在已运行的数据库上执行也不会引入示例数据。
+// 迁移在所有环境中执行，不创建管理员账号，管理员使用 create-admin 命令创建。
+func seedInitialData(tx *gorm.DB) error {
+	// 示例域名
+	if err := seedTable(tx, &[]baselineDomain{
+		{Name: "example.com", DomainType: "二级域名", IsActive: true, Description: "示例域名"},
+		{Name: "test.org", DomainType: "二级域名", IsActive: true, Description: "测试域名"},
+	}); err != nil {
 		return err
 	}
-	
-	// 邮件相关表
-	if err := db.AutoMigrate(
-		&emailmodels.SMTPConfig{},
-	); err != nil {
+
+	// 示例DNS服务商，默认禁用
+	if err := seedTable(tx, &[]baselineDNSProvider{{
+		Name:        "DNSPod",
+		Type:        "dnspod",
+		Config:      `{"api_token": "请在管理后台配置实际的API Token"}`,
+		Description: "腾讯云DNSPod服务商",
+		SortOrder:   1,
+	}}); err != nil {
 		return err
 	}
-	
-	log.Println("数据库迁移完成")
-	return nil
-}
\ No newline at end of file
+
+	// 示例SMTP配置，默认禁用
+	return seedTable(tx, &[]baselineSMTPConfig{{
+		Name:        "默认SMTP配置",
+		Host:        "smtp.gmail.com",
+		Port:        587,
+		Username:    "your_email@gmail.com",
+		Password:    "请在管理后台配置实际的密码",
+		FromEmail:   "noreply@example.com",
+		FromName:    "Domain MAX",
+		IsDefault:   true,
+		UseTLS:      true,
+		Description: "默认邮件发送配置，请在管理后台修改为实际配置",
+	}})
+}
+
+// seedTable 表中没有数据（包括已软删除的数据）时写入rows
+func seedTable(tx *gorm.DB, rows interface{}) error {
+	var count int64
+	if err := tx.Unscoped().Model(rows).Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return nil
+	}
+	return tx.Create(rows).Error
+}
@@ -0,0 +1,196 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ErrIrreversibleMigration 迁移不支持回滚
+var ErrIrreversibleMigration = errors.New("迁移不支持回滚")
+
+// Migration 一个版本的数据库迁移
+//
+// 已发布的迁移不应再修改，结构或数据的变化需要追加新版本。Up和Down在同一事务中执行，
+// Down为空表示该迁移不支持回滚。
+type Migration struct {
+	Version     int64
+	Description string
+	Up          func(tx *gorm.DB) error
+	Down        func(tx *gorm.DB) error
+}
+
+// SchemaMigration 已执行的迁移版本
+type SchemaMigration struct {
+	Version     int64     `gorm:"primaryKey;autoIncrement:false"`
+	Description string    `gorm:"size:255"`
+	AppliedAt   time.Time `gorm:"not null"`
+}
+
+// TableName 迁移记录表名
+func (SchemaMigration) TableName() string {
+	return "schema_migrations"
+}
+
+// MigrationState 迁移及其执行状态
+type MigrationState struct {
+	Version     int64
+	Description string
+	AppliedAt   *time.Time // 为空表示尚未执行
+	Unknown     bool       // 数据库中已执行但当前程序中不存在，通常由更新版本的程序执行
+}
+
+// Migrate 执行全部未执行的迁移
+func Migrate(db *gorm.DB) error {
+	log.Println("开始数据库迁移...")
+
+	applied, err := MigrateUp(db, 0)
+	if err != nil {
+		return err
+	}
+	for _, m := range applied {
+		log.Printf("已执行迁移 %d: %s", m.Version, m.Description)
+	}
+
+	log.Println("数据库迁移完成")
+	return nil
+}
+
+// MigrateUp 按版本顺序执行未执行的迁移，steps为0时执行全部，返回本次执行的迁移
+func MigrateUp(db *gorm.DB, steps int) ([]Migration, error) {
+	if err := db.AutoMigrate(&SchemaMigration{}); err != nil {
+		return nil, fmt.Errorf("创建迁移记录表失败: %v", err)
+	}
+	done, err := appliedVersions(db)
+	if err != nil {
+		return nil, err
+	}
+
+	var applied []Migration
+	for _, m := range sortedMigrations() {
+		if steps > 0 && len(applied) >= steps {
+			break
+		}
+		if _, ok := done[m.Version]; ok {
+			continue
+		}
+
+		err := db.Transaction(func(tx *gorm.DB) error {
+			if err := m.Up(tx); err != nil {
+				return err
+			}
+			return tx.Create(&SchemaMigration{
+				Version:     m.Version,
+				Description: m.Description,
+				AppliedAt:   time.Now(),
+			}).Error
+		})
+		if err != nil {
+			return applied, fmt.Errorf("执行迁移 %d（%s）失败: %v", m.Version, m.Description, err)
+		}
+		applied = append(applied, m)
+	}
+	return applied, nil
+}
+
+// MigrateDown 按版本倒序回滚最近执行的steps个迁移，返回本次回滚的迁移
+func MigrateDown(db *gorm.DB, steps int) ([]Migration, error) {
+	done, err := appliedVersions(db)
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[int64]Migration, len(migrations))
+	for _, m := range migrations {
+		byVersion[m.Version] = m
+	}
+	versions := make([]int64, 0, len(done))
+	for version := range done {
+		versions = append(versions, version)
+	}
+	sort.Slice(versions, func(i, j int) bool { return versions[i] > versions[j] })
+
+	var reverted []Migration
+	for _, version := range versions {
+		if len(reverted) >= steps {
+			break
+		}
+		m, ok := byVersion[version]
+		if !ok {
+			return reverted, fmt.Errorf("迁移 %d 不存在于当前程序中，请使用执行该迁移的程序版本回滚", version)
+		}
+		if m.Down == nil {
+			return reverted, fmt.Errorf("回滚迁移 %d（%s）失败: %w", m.Version, m.Description, ErrIrreversibleMigration)
+		}
+
+		err := db.Transaction(func(tx *gorm.DB) error {
+			if err := m.Down(tx); err != nil {
+				return err
+			}
+			return tx.Delete(&SchemaMigration{}, "version = ?", m.Version).Error
+		})
+		if err != nil {
+			return reverted, fmt.Errorf("回滚迁移 %d（%s）失败: %v", m.Version, m.Description, err)
+		}
+		reverted = append(reverted, m)
+	}
+	return reverted, nil
+}
+
+// MigrationStatus 返回全部迁移的执行状态，按版本排序
+func MigrationStatus(db *gorm.DB) ([]MigrationState, error) {
+	done, err := appliedVersions(db)
+	if err != nil {
+		return nil, err
+	}
+
+	var states []MigrationState
+	for _, m := range sortedMigrations() {
+		state := MigrationState{Version: m.Version, Description: m.Description}
+		if row, ok := done[m.Version]; ok {
+			appliedAt := row.AppliedAt
+			state.AppliedAt = &appliedAt
+			delete(done, m.Version)
+		}
+		states = append(states, state)
+	}
+	for _, row := range done {
+		appliedAt := row.AppliedAt
+		states = append(states, MigrationState{
+			Version:     row.Version,
+			Description: row.Description,
+			AppliedAt:   &appliedAt,
+			Unknown:     true,
+		})
+	}
+	sort.Slice(states, func(i, j int) bool { return states[i].Version < states[j].Version })
+	return states, nil
+}
+
+// appliedVersions 读取已执行的迁移，迁移记录表不存在时视为没有执行过迁移
+func appliedVersions(db *gorm.DB) (map[int64]SchemaMigration, error) {
+	if !db.Migrator().HasTable(&SchemaMigration{}) {
+		return map[int64]SchemaMigration{}, nil
+	}
+
+	var rows []SchemaMigration
+	if err := db.Find(&rows).Error; err != nil {
+		return nil, fmt.Errorf("读取迁移记录失败: %v", err)
+	}
+	done := make(map[int64]SchemaMigration, len(rows))
+	for _, row := range rows {
+		done[row.Version] = row
+	}
+	return done, nil
+}
+
+// sortedMigrations 按版本排序的迁移列表
+func sortedMigrations() []Migration {
+	sorted := append([]Migration(nil), migrations...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Version < sorted[j].Version })
+	return sorted
+}
@@ -0,0 +1,96 @@
+package database
+
+import (
+	"path/filepath"
+	"testing"
+
+	"domain-max/pkg/config"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func newTestDB(t *testing.T, path string) *gorm.DB {
+	t.Helper()
+
+	db, err := Connect(&config.Config{Environment: "test", DBType: "sqlite", DBPath: path})
+	if err != nil {
+		t.Fatalf("连接数据库失败: %v", err)
+	}
+	db.Logger = logger.Default.LogMode(logger.Silent)
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+	return db
+}
+
+func TestMigrateSQLiteFile(t *testing.T) {
+	// 数据库文件所在目录不存在时自动创建
+	db := newTestDB(t, filepath.Join(t.TempDir(), "data", "domain-max.db"))
+
+	if err := Migrate(db); err != nil {
+		t.Fatalf("数据库迁移失败: %v", err)
+	}
+	if applied, err := MigrateUp(db, 0); err != nil || len(applied) != 0 {
+		t.Fatalf("重复迁移执行了 %d 个迁移: %v", len(applied), err)
+	}
+
+	reverted, err := MigrateDown(db, len(migrations))
+	if err != nil {
+		t.Fatalf("回滚迁移失败: %v", err)
+	}
+	if len(reverted) != len(migrations) {
+		t.Fatalf("回滚了 %d 个迁移", len(reverted))
+	}
+	if db.Migrator().HasTable(&baselineDNSRecord{}) {
+		t.Fatal("回滚后记录表仍然存在")
+	}
+
+	if err := Migrate(db); err != nil {
+		t.Fatalf("回滚后重新迁移失败: %v", err)
+	}
+}
+
+func TestBackfillRecordStatus(t *testing.T) {
+	db := newTestDB(t, sqliteMemory)
+	if _, err := MigrateUp(db, 1); err != nil {
+		t.Fatalf("数据库迁移失败: %v", err)
+	}
+
+	// 旧版本写入的数据
+	provider := baselineDNSProvider{Name: "DNSPod", Type: "dnspod", IsActive: true}
+	db.Create(&provider)
+	bound := baselineDomain{Name: "bound.example", ProviderID: &provider.ID}
+	unbound := baselineDomain{Name: "unbound.example"}
+	db.Create(&bound)
+	db.Create(&unbound)
+	records := []baselineDNSRecord{
+		{UserID: 1, DomainID: bound.ID, Subdomain: "www", Type: "A", Value: "1.1.1.1", TTL: 600, ExternalID: "rec-1", Status: "active"},
+		{UserID: 1, DomainID: unbound.ID, Subdomain: "www", Type: "A", Value: "1.1.1.1", TTL: 600, Status: "active"},
+	}
+	db.Create(&records)
+
+	if err := Migrate(db); err != nil {
+		t.Fatalf("数据库迁移失败: %v", err)
+	}
+
+	var statuses []string
+	db.Model(&baselineDNSRecord{}).Order("id").Pluck("status", &statuses)
+	if len(statuses) != 2 || statuses[0] != "pending" || statuses[1] != "synced" {
+		t.Fatalf("记录状态为 %v", statuses)
+	}
+
+	var jobs []baselineSyncJob
+	db.Find(&jobs)
+	if len(jobs) != 1 || jobs[0].RecordID != records[0].ID || jobs[0].ProviderID != provider.ID ||
+		jobs[0].Action != "update" || jobs[0].Status != "pending" {
+		t.Fatalf("同步任务为 %+v", jobs)
+	}
+	var states []baselineRecordSync
+	db.Find(&states)
+	if len(states) != 1 || states[0].ExternalID != "rec-1" || states[0].Status != "pending" {
+		t.Fatalf("同步状态为 %+v", states)
+	}
+}
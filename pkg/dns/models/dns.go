@@ -14,19 +14,35 @@ import (
 
 // Domain 域名模型
 type Domain struct {
-	ID          uint           `json:"id" gorm:"primaryKey"`
-	Name        string         `json:"name" gorm:"uniqueIndex;not null"` // 主域名，如 example.com
-	DomainType  string         `json:"domain_type"`                      // 域名类型：二级域名、三级域名等
-	IsActive    bool           `json:"is_active" gorm:"default:true"`
-	Description string         `json:"description"` // 域名描述
-	CreatedAt   time.Time      `json:"created_at"`
-	UpdatedAt   time.Time      `json:"updated_at"`
-	DeletedAt   gorm.DeletedAt `json:"-" gorm:"index"`
+	ID                  uint           `json:"id" gorm:"primaryKey"`
+	Name                string         `json:"name" gorm:"uniqueIndex;not null"` // 主域名，如 example.com
+	DomainType          string         `json:"domain_type"`                      // 域名类型：二级域名、三级域名等
+	IsActive            bool           `json:"is_active" gorm:"default:true"`
+	Description         string         `json:"description"`                        // 域名描述
+	ProviderID          *uint          `json:"provider_id" gorm:"index"`           // 托管该域名的DNS服务商，为空时只在本地管理记录
+	ZoneID              string         `json:"zone_id" gorm:"size:100"`            // DNS服务商侧的区域ID，为空时按域名查找
+	SecondaryProviderID *uint          `json:"secondary_provider_id" gorm:"index"` // 备用DNS服务商，记录同时写入主备两个服务商
+	SecondaryZoneID     string         `json:"secondary_zone_id" gorm:"size:100"`  // 备用DNS服务商侧的区域ID
+	CreatedAt           time.Time      `json:"created_at"`
+	UpdatedAt           time.Time      `json:"updated_at"`
+	DeletedAt           gorm.DeletedAt `json:"-" gorm:"index"`
 
 	// 关联
 	DNSRecords []DNSRecord `json:"dns_records,omitempty" gorm:"foreignKey:DomainID"`
 }
 
+// ProviderIDs 返回域名绑定的DNS服务商，主服务商在前
+func (d *Domain) ProviderIDs() []uint {
+	var ids []uint
+	if d.ProviderID != nil {
+		ids = append(ids, *d.ProviderID)
+	}
+	if d.SecondaryProviderID != nil {
+		ids = append(ids, *d.SecondaryProviderID)
+	}
+	return ids
+}
+
 // DNSRecord DNS记录模型
 type DNSRecord struct {
 	ID         uint           `json:"id" gorm:"primaryKey"`
@@ -39,8 +55,11 @@ type DNSRecord struct {
 	Priority   int            `json:"priority" gorm:"default:0"`                               // MX和SRV记录优先级
 	Weight     int            `json:"weight" gorm:"default:0"`                                 // SRV记录权重
 	Port       int            `json:"port" gorm:"default:0"`                                   // SRV记录端口
-	ExternalID string         `json:"external_id" gorm:"size:100"`                             // DNS服务商记录ID
-	Status     string         `json:"status" gorm:"default:active;size:20"`                    // 记录状态
+	Proxied    bool           `json:"proxied" gorm:"default:false"`                            // 是否开启CDN代理（仅Cloudflare支持）
+	ExternalID string         `json:"external_id" gorm:"size:100"`                             // 主DNS服务商记录ID，各服务商的记录ID见RecordSync
+	Status     string         `json:"status" gorm:"default:pending;size:20;index"`             // 各服务商汇总的同步状态：pending、synced、failed
+	SyncError  string         `json:"sync_error" gorm:"size:1000"`                             // 最近一次同步失败的原因
+	SyncedAt   *time.Time     `json:"synced_at"`                                               // 最近一次同步成功的时间
 	Comment    string         `json:"comment" gorm:"size:500"`                                 // 记录备注，增加长度
 	CreatedAt  time.Time      `json:"created_at" gorm:"index"`                                 // 添加时间索引
 	UpdatedAt  time.Time      `json:"updated_at"`
@@ -66,6 +85,77 @@ type DNSProvider struct {
 	DeletedAt   gorm.DeletedAt `json:"-" gorm:"index"`
 }
 
+// DriftReport 域名DNS记录的漂移检测结果，每个域名在每个绑定的服务商中只保留最近一次
+type DriftReport struct {
+	ID         uint      `json:"id" gorm:"primaryKey"`
+	DomainID   uint      `json:"domain_id" gorm:"not null;uniqueIndex:idx_drift_reports_domain_provider"`
+	ProviderID uint      `json:"provider_id" gorm:"index;uniqueIndex:idx_drift_reports_domain_provider"`
+	Missing    int       `json:"missing"`                 // 本地存在但服务商中缺失的记录数
+	Extra      int       `json:"extra"`                   // 服务商中存在但本地没有的记录数
+	Changed    int       `json:"changed"`                 // 两侧内容不一致的记录数
+	Details    string    `json:"-" gorm:"type:text"`      // JSON格式的漂移明细
+	Error      string    `json:"error" gorm:"size:1000"`  // 检测失败原因
+	CheckedAt  time.Time `json:"checked_at" gorm:"index"` // 检测时间
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+
+	// 关联
+	Domain Domain `json:"domain,omitempty" gorm:"foreignKey:DomainID;constraint:OnDelete:CASCADE"`
+}
+
+// DNS记录的同步状态
+const (
+	RecordStatusPending = "pending" // 等待同步到DNS服务商
+	RecordStatusSynced  = "synced"  // 已同步，或域名未绑定服务商
+	RecordStatusFailed  = "failed"  // 多次重试后仍同步失败
+)
+
+// 同步任务的操作类型
+const (
+	SyncActionCreate = "create"
+	SyncActionUpdate = "update"
+	SyncActionDelete = "delete"
+)
+
+// 同步任务的状态
+const (
+	SyncJobPending = "pending" // 等待执行或等待重试
+	SyncJobRunning = "running" // 执行中
+	SyncJobDone    = "done"    // 执行成功
+	SyncJobFailed  = "failed"  // 达到最大重试次数
+)
+
+// SyncJob DNS记录同步任务，记录变更时写入，由后台任务同步到DNS服务商
+type SyncJob struct {
+	ID          uint       `json:"id" gorm:"primaryKey"`
+	RecordID    uint       `json:"record_id" gorm:"not null;index"`
+	DomainID    uint       `json:"domain_id" gorm:"not null;index"`
+	ProviderID  uint       `json:"provider_id" gorm:"index"`             // 目标DNS服务商，为0时表示域名的主服务商
+	Action      string     `json:"action" gorm:"not null;size:10"`       // 操作类型：create、update、delete
+	Snapshot    string     `json:"-" gorm:"type:text"`                   // 变更前记录的JSON快照，更新和删除时使用
+	Status      string     `json:"status" gorm:"not null;size:20;index"` // 任务状态
+	Attempts    int        `json:"attempts" gorm:"default:0"`            // 已执行次数
+	MaxAttempts int        `json:"max_attempts" gorm:"default:8"`        // 最大执行次数
+	LastError   string     `json:"last_error" gorm:"size:1000"`          // 最近一次失败的原因
+	NextRunAt   time.Time  `json:"next_run_at" gorm:"index"`             // 下次执行时间
+	LockedAt    *time.Time `json:"locked_at"`                            // 开始执行的时间，用于回收中断的任务
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
+
+// RecordSync DNS记录在单个DNS服务商中的同步状态
+type RecordSync struct {
+	ID         uint       `json:"id" gorm:"primaryKey"`
+	RecordID   uint       `json:"record_id" gorm:"not null;uniqueIndex:idx_record_syncs_record_provider"`
+	ProviderID uint       `json:"provider_id" gorm:"not null;uniqueIndex:idx_record_syncs_record_provider"`
+	ExternalID string     `json:"external_id" gorm:"size:100"` // 该服务商中的记录ID
+	Status     string     `json:"status" gorm:"size:20"`       // 同步状态：pending、synced、failed
+	SyncError  string     `json:"sync_error" gorm:"size:1000"` // 最近一次同步失败的原因
+	SyncedAt   *time.Time `json:"synced_at"`                   // 最近一次同步成功的时间
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+}
+
 // 请求和响应结构体
 
 // CreateDNSRecordRequest DNS记录创建请求
@@ -78,6 +168,7 @@ type CreateDNSRecordRequest struct {
 	Priority       int    `json:"priority"`        // MX和SRV记录的优先级
 	Weight         int    `json:"weight"`          // SRV记录的权重
 	Port           int    `json:"port"`            // SRV记录的端口
+	Proxied        bool   `json:"proxied"`         // 是否开启CDN代理
 	Comment        string `json:"comment"`         // 记录备注
 	AllowPrivateIP bool   `json:"allow_private_ip"` // 是否允许私有IP
 }
@@ -91,6 +182,7 @@ type UpdateDNSRecordRequest struct {
 	Priority       int    `json:"priority"`
 	Weight         int    `json:"weight"`
 	Port           int    `json:"port"`
+	Proxied        *bool  `json:"proxied"` // 使用指针以区分false和未设置
 	Comment        string `json:"comment"`
 	AllowPrivateIP bool   `json:"allow_private_ip"`
 }
@@ -115,6 +207,7 @@ type DNSRecordExport struct {
 	Priority  int    `json:"priority,omitempty"`
 	Weight    int    `json:"weight,omitempty"`
 	Port      int    `json:"port,omitempty"`
+	Proxied   bool   `json:"proxied,omitempty"`
 	Comment   string `json:"comment,omitempty"`
 	Domain    string `json:"domain"`
 }
@@ -0,0 +1,333 @@
+package providers
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// aliyunDefaultEndpoint 阿里云DNS接口地址
+const aliyunDefaultEndpoint = "https://alidns.aliyuncs.com"
+
+// aliyunAPIVersion 阿里云DNS接口版本
+const aliyunAPIVersion = "2015-01-09"
+
+func init() {
+	Register("aliyun", newAliyun)
+	RegisterSchema(Schema{
+		Type:        "aliyun",
+		Name:        "阿里云DNS",
+		Description: "阿里云域名解析",
+		Fields: []Field{
+			{Name: "access_key_id", Type: FieldString, Label: "AccessKey ID", Required: true},
+			{Name: "access_key_secret", Type: FieldString, Label: "AccessKey Secret", Required: true, Secret: true},
+			{Name: "endpoint", Type: FieldString, Label: "接口地址", Format: FormatURL},
+		},
+	})
+}
+
+// aliyunConfig 阿里云DNS配置
+type aliyunConfig struct {
+	AccessKeyID     string `json:"access_key_id"`
+	AccessKeySecret string `json:"access_key_secret"`
+	Endpoint        string `json:"endpoint"` // 可选，自定义接口地址
+}
+
+// aliyunProvider 阿里云DNS驱动
+type aliyunProvider struct {
+	accessKeyID     string
+	accessKeySecret string
+	endpoint        string
+	client          *http.Client
+	now             func() time.Time
+}
+
+func newAliyun(config []byte) (Provider, error) {
+	var cfg aliyunConfig
+	if err := json.Unmarshal(config, &cfg); err != nil {
+		return nil, fmt.Errorf("阿里云DNS配置格式错误: %v", err)
+	}
+	if cfg.AccessKeyID == "" || cfg.AccessKeySecret == "" {
+		return nil, errors.New("阿里云DNS配置缺少access_key_id或access_key_secret")
+	}
+	if cfg.Endpoint == "" {
+		cfg.Endpoint = aliyunDefaultEndpoint
+	}
+
+	return &aliyunProvider{
+		accessKeyID:     cfg.AccessKeyID,
+		accessKeySecret: cfg.AccessKeySecret,
+		endpoint:        strings.TrimSuffix(cfg.Endpoint, "/"),
+		client:          newHTTPClient(),
+		now:             time.Now,
+	}, nil
+}
+
+// aliyunRecord 阿里云解析记录
+type aliyunRecord struct {
+	RecordID string     `json:"RecordId"`
+	RR       string     `json:"RR"`
+	Type     string     `json:"Type"`
+	Value    string     `json:"Value"`
+	TTL      flexString `json:"TTL"`
+	Priority flexString `json:"Priority"`
+}
+
+// aliyunPercentEncode 按阿里云签名规范进行URL编码
+func aliyunPercentEncode(s string) string {
+	encoded := url.QueryEscape(s)
+	encoded = strings.ReplaceAll(encoded, "+", "%20")
+	encoded = strings.ReplaceAll(encoded, "*", "%2A")
+	encoded = strings.ReplaceAll(encoded, "%7E", "~")
+	return encoded
+}
+
+// aliyunSign 计算RPC风格请求的签名（签名算法版本1.0）
+func aliyunSign(method string, params url.Values, secret string) string {
+	keys := make([]string, 0, len(params))
+	for k := range params {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	pairs := make([]string, 0, len(keys))
+	for _, k := range keys {
+		pairs = append(pairs, aliyunPercentEncode(k)+"="+aliyunPercentEncode(params.Get(k)))
+	}
+	canonicalized := strings.Join(pairs, "&")
+
+	stringToSign := method + "&" + aliyunPercentEncode("/") + "&" + aliyunPercentEncode(canonicalized)
+
+	mac := hmac.New(sha1.New, []byte(secret+"&"))
+	mac.Write([]byte(stringToSign))
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// call 调用阿里云DNS接口
+func (p *aliyunProvider) call(ctx context.Context, action string, params url.Values, out interface{}) error {
+	nonce := make([]byte, 16)
+	if _, err := rand.Read(nonce); err != nil {
+		return err
+	}
+
+	query := url.Values{}
+	for k, v := range params {
+		query[k] = v
+	}
+	query.Set("Action", action)
+	query.Set("Format", "JSON")
+	query.Set("Version", aliyunAPIVersion)
+	query.Set("AccessKeyId", p.accessKeyID)
+	query.Set("SignatureMethod", "HMAC-SHA1")
+	query.Set("SignatureVersion", "1.0")
+	query.Set("SignatureNonce", hex.EncodeToString(nonce))
+	query.Set("Timestamp", p.now().UTC().Format("2006-01-02T15:04:05Z"))
+	query.Set("Signature", aliyunSign(http.MethodGet, query, p.accessKeySecret))
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.endpoint+"/?"+query.Encode(), nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("请求阿里云DNS失败: %v", err)
+	}
+	body, err := readBody(resp)
+	if err != nil {
+		return fmt.Errorf("读取阿里云DNS响应失败: %v", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		var result struct {
+			Code    string `json:"Code"`
+			Message string `json:"Message"`
+		}
+		json.Unmarshal(body, &result)
+
+		// DomainRecordNotBelongToUser表示记录属于其他账户，通常是AccessKey配置错误，不能当作记录已删除
+		switch result.Code {
+		case "InvalidRecordId.NotFound":
+			return fmt.Errorf("%w: %s", ErrRecordNotFound, result.Message)
+		case "InvalidDomainName.NoExist", "IncorrectDomainUser":
+			return fmt.Errorf("%w: %s", ErrZoneNotFound, result.Message)
+		}
+		return &APIError{Provider: "阿里云DNS", StatusCode: resp.StatusCode, Code: result.Code, Message: result.Message}
+	}
+
+	if out != nil {
+		if err := json.Unmarshal(body, out); err != nil {
+			return fmt.Errorf("解析阿里云DNS响应失败: %v", err)
+		}
+	}
+	return nil
+}
+
+// ListZones 列出账户下的域名
+func (p *aliyunProvider) ListZones(ctx context.Context) ([]Zone, error) {
+	const pageSize = 100
+	var zones []Zone
+
+	for page := 1; ; page++ {
+		var result struct {
+			TotalCount int `json:"TotalCount"`
+			Domains    struct {
+				Domain []struct {
+					DomainID   string `json:"DomainId"`
+					DomainName string `json:"DomainName"`
+				} `json:"Domain"`
+			} `json:"Domains"`
+		}
+		params := url.Values{
+			"PageNumber": {strconv.Itoa(page)},
+			"PageSize":   {strconv.Itoa(pageSize)},
+		}
+		if err := p.call(ctx, "DescribeDomains", params, &result); err != nil {
+			return nil, err
+		}
+
+		for _, d := range result.Domains.Domain {
+			zones = append(zones, Zone{ID: d.DomainID, Name: d.DomainName})
+		}
+		if len(result.Domains.Domain) < pageSize || len(zones) >= result.TotalCount {
+			return zones, nil
+		}
+	}
+}
+
+// ListRecords 列出域名下的解析记录
+func (p *aliyunProvider) ListRecords(ctx context.Context, zone Zone) ([]Record, error) {
+	const pageSize = 500
+	var records []Record
+
+	for page := 1; ; page++ {
+		var result struct {
+			TotalCount    int `json:"TotalCount"`
+			DomainRecords struct {
+				Record []aliyunRecord `json:"Record"`
+			} `json:"DomainRecords"`
+		}
+		params := url.Values{
+			"DomainName": {zone.Name},
+			"PageNumber": {strconv.Itoa(page)},
+			"PageSize":   {strconv.Itoa(pageSize)},
+		}
+		if err := p.call(ctx, "DescribeDomainRecords", params, &result); err != nil {
+			return nil, err
+		}
+
+		for _, r := range result.DomainRecords.Record {
+			records = append(records, r.toRecord())
+		}
+		if len(result.DomainRecords.Record) < pageSize || len(records) >= result.TotalCount {
+			return records, nil
+		}
+	}
+}
+
+// CreateRecord 添加解析记录
+func (p *aliyunProvider) CreateRecord(ctx context.Context, zone Zone, record Record) (string, error) {
+	params := p.recordParams(record)
+	params.Set("DomainName", zone.Name)
+
+	var result struct {
+		RecordID string `json:"RecordId"`
+	}
+	if err := p.call(ctx, "AddDomainRecord", params, &result); err != nil {
+		return "", err
+	}
+	return result.RecordID, nil
+}
+
+// UpdateRecord 修改解析记录
+func (p *aliyunProvider) UpdateRecord(ctx context.Context, zone Zone, old, record Record) (string, error) {
+	params := p.recordParams(record)
+	params.Set("RecordId", old.ID)
+
+	var result struct {
+		RecordID string `json:"RecordId"`
+	}
+	err := p.call(ctx, "UpdateDomainRecord", params, &result)
+
+	// 记录内容未变化时阿里云返回DomainRecordDuplicate，视为成功
+	var apiErr *APIError
+	if errors.As(err, &apiErr) && apiErr.Code == "DomainRecordDuplicate" {
+		return old.ID, nil
+	}
+	if err != nil {
+		return "", err
+	}
+	if result.RecordID == "" {
+		return old.ID, nil
+	}
+	return result.RecordID, nil
+}
+
+// DeleteRecord 删除解析记录
+func (p *aliyunProvider) DeleteRecord(ctx context.Context, zone Zone, record Record) error {
+	return p.call(ctx, "DeleteDomainRecord", url.Values{"RecordId": {record.ID}}, nil)
+}
+
+// Test 通过查询域名列表验证AccessKey
+func (p *aliyunProvider) Test(ctx context.Context) error {
+	params := url.Values{
+		"PageNumber": {"1"},
+		"PageSize":   {"1"},
+	}
+	return p.call(ctx, "DescribeDomains", params, nil)
+}
+
+// recordParams 构造添加和修改记录的请求参数，RR/Type/Value对应本地的子域名/类型/记录值
+func (p *aliyunProvider) recordParams(record Record) url.Values {
+	params := url.Values{
+		"RR":   {record.Name},
+		"Type": {strings.ToUpper(record.Type)},
+	}
+	if record.TTL > 0 {
+		params.Set("TTL", strconv.Itoa(record.TTL))
+	}
+
+	switch strings.ToUpper(record.Type) {
+	case "MX":
+		params.Set("Value", record.Value)
+		params.Set("Priority", strconv.Itoa(record.Priority))
+	case "SRV":
+		// 阿里云的SRV记录值格式为"优先级 权重 端口 目标地址"
+		params.Set("Value", RecordValue(record))
+	default:
+		params.Set("Value", record.Value)
+	}
+	return params
+}
+
+// toRecord 转换为驱动记录
+func (r aliyunRecord) toRecord() Record {
+	record := Record{
+		ID:    r.RecordID,
+		Name:  r.RR,
+		Type:  strings.ToUpper(r.Type),
+		Value: r.Value,
+		TTL:   r.TTL.Int(),
+	}
+
+	switch record.Type {
+	case "MX":
+		record.Priority = r.Priority.Int()
+	case "SRV":
+		splitSRVValue(&record, r.Value)
+	}
+	record.Value = normalizeValue(record.Type, record.Value)
+	return record
+}
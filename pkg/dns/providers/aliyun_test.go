@@ -0,0 +1,192 @@
+package providers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"sync"
+	"testing"
+)
+
+// fakeAliyun 模拟阿里云DNS的RPC接口，只有example.com一个域名，other-account为其他账户的记录
+type fakeAliyun struct {
+	mu      sync.Mutex
+	nextID  int
+	records map[string]aliyunRecord
+}
+
+func (f *fakeAliyun) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	signature := query.Get("Signature")
+	query.Del("Signature")
+	if query.Get("AccessKeyId") != "key" || signature != aliyunSign(http.MethodGet, query, "secret") {
+		aliyunError(w, http.StatusBadRequest, "SignatureDoesNotMatch", "Specified signature is not matched with our calculation.")
+		return
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	id := query.Get("RecordId")
+	if id == "other-account" {
+		aliyunError(w, http.StatusBadRequest, "DomainRecordNotBelongToUser", "The DNS record does not belong to you.")
+		return
+	}
+	if _, ok := f.records[id]; !ok && id != "" {
+		aliyunError(w, http.StatusBadRequest, "InvalidRecordId.NotFound", "The specified record does not exist.")
+		return
+	}
+
+	switch query.Get("Action") {
+	case "AddDomainRecord":
+		if query.Get("DomainName") != "example.com" {
+			aliyunError(w, http.StatusBadRequest, "InvalidDomainName.NoExist", "The specified domain name does not exist.")
+			return
+		}
+		f.nextID++
+		id = strconv.Itoa(f.nextID)
+		f.records[id] = aliyunRecord{RecordID: id, RR: query.Get("RR"), Type: query.Get("Type"), Value: query.Get("Value")}
+		json.NewEncoder(w).Encode(map[string]string{"RecordId": id})
+	case "UpdateDomainRecord":
+		if f.records[id].Value == query.Get("Value") {
+			aliyunError(w, http.StatusBadRequest, "DomainRecordDuplicate", "The DNS record already exists.")
+			return
+		}
+		f.records[id] = aliyunRecord{RecordID: id, RR: query.Get("RR"), Type: query.Get("Type"), Value: query.Get("Value")}
+		json.NewEncoder(w).Encode(map[string]string{"RecordId": id})
+	case "DeleteDomainRecord":
+		delete(f.records, id)
+		json.NewEncoder(w).Encode(map[string]string{"RecordId": id})
+	default:
+		aliyunError(w, http.StatusBadRequest, "InvalidAction.NotFound", "Specified api is not found.")
+	}
+}
+
+func aliyunError(w http.ResponseWriter, status int, code, message string) {
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{"Code": code, "Message": message})
+}
+
+func newTestAliyun(t *testing.T, secret string) (Provider, *fakeAliyun) {
+	t.Helper()
+
+	fake := &fakeAliyun{records: map[string]aliyunRecord{}}
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+
+	config, _ := json.Marshal(aliyunConfig{AccessKeyID: "key", AccessKeySecret: secret, Endpoint: server.URL})
+	p, err := newAliyun(config)
+	if err != nil {
+		t.Fatalf("创建驱动失败: %v", err)
+	}
+	return p, fake
+}
+
+func TestAliyunSign(t *testing.T) {
+	// 阿里云签名文档中的示例
+	params := url.Values{
+		"AccessKeyId":      {"testid"},
+		"Action":           {"DescribeDomainRecords"},
+		"DomainName":       {"example.com"},
+		"Format":           {"XML"},
+		"SignatureMethod":  {"HMAC-SHA1"},
+		"SignatureNonce":   {"f59ed6a9-83fc-473b-9cc6-99c95df3856e"},
+		"SignatureVersion": {"1.0"},
+		"Timestamp":        {"2016-03-24T16:41:54Z"},
+		"Version":          {"2015-01-09"},
+	}
+	if got := aliyunSign(http.MethodGet, params, "testsecret"); got != "uRpHwaSEt3J+6KQD//svCh/x+pI=" {
+		t.Fatalf("签名为 %s", got)
+	}
+}
+
+func TestAliyunRecords(t *testing.T) {
+	ctx := context.Background()
+	p, fake := newTestAliyun(t, "secret")
+	zone := Zone{Name: "example.com"}
+	record := Record{Name: "www", Type: "A", Value: "1.1.1.1", TTL: 600}
+
+	id, err := p.CreateRecord(ctx, zone, record)
+	if err != nil {
+		t.Fatalf("创建记录失败: %v", err)
+	}
+	record.ID = id
+
+	// 内容未变化时服务商返回DomainRecordDuplicate
+	if got, err := p.UpdateRecord(ctx, zone, record, record); err != nil || got != id {
+		t.Fatalf("更新内容相同的记录返回 %q %v", got, err)
+	}
+	if _, err := p.UpdateRecord(ctx, zone, record, Record{Name: "www", Type: "A", Value: "2.2.2.2", TTL: 600}); err != nil {
+		t.Fatalf("更新记录失败: %v", err)
+	}
+	if got := fake.records[id].Value; got != "2.2.2.2" {
+		t.Fatalf("更新后的记录值为 %s", got)
+	}
+
+	if err := p.DeleteRecord(ctx, zone, record); err != nil {
+		t.Fatalf("删除记录失败: %v", err)
+	}
+	if _, ok := fake.records[id]; ok {
+		t.Fatal("记录未删除")
+	}
+}
+
+func TestAliyunErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		secret string
+		run    func(p Provider) error
+		check  func(error) bool
+	}{
+		{
+			name:   "bad signature",
+			secret: "wrong",
+			run:    func(p Provider) error { return p.Test(context.Background()) },
+			check: func(err error) bool {
+				var apiErr *APIError
+				return errors.As(err, &apiErr) && apiErr.Code == "SignatureDoesNotMatch"
+			},
+		},
+		{
+			name:   "unknown domain",
+			secret: "secret",
+			run: func(p Provider) error {
+				_, err := p.CreateRecord(context.Background(), Zone{Name: "missing.com"}, Record{Name: "www", Type: "A", Value: "1.1.1.1"})
+				return err
+			},
+			check: func(err error) bool { return errors.Is(err, ErrZoneNotFound) },
+		},
+		{
+			name:   "record not found",
+			secret: "secret",
+			run: func(p Provider) error {
+				return p.DeleteRecord(context.Background(), Zone{Name: "example.com"}, Record{ID: "404"})
+			},
+			check: func(err error) bool { return errors.Is(err, ErrRecordNotFound) },
+		},
+		{
+			name:   "record of another account",
+			secret: "secret",
+			run: func(p Provider) error {
+				return p.DeleteRecord(context.Background(), Zone{Name: "example.com"}, Record{ID: "other-account"})
+			},
+			check: func(err error) bool {
+				var apiErr *APIError
+				return !errors.Is(err, ErrRecordNotFound) && errors.As(err, &apiErr) && apiErr.Code == "DomainRecordNotBelongToUser"
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, _ := newTestAliyun(t, tt.secret)
+			if err := tt.run(p); !tt.check(err) {
+				t.Fatalf("返回的错误为 %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,407 @@
+package providers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// cloudflareDefaultEndpoint Cloudflare v4接口地址
+const cloudflareDefaultEndpoint = "https://api.cloudflare.com/client/v4"
+
+// cloudflarePageSize 列表接口每页数量
+const cloudflarePageSize = 100
+
+func init() {
+	Register("cloudflare", newCloudflare)
+	RegisterSchema(Schema{
+		Type:        "cloudflare",
+		Name:        "Cloudflare",
+		Description: "Cloudflare DNS",
+		Fields: []Field{
+			{Name: "api_token", Type: FieldString, Label: "API Token", Required: true, Secret: true},
+			{Name: "zone_id", Type: FieldString, Label: "Zone ID"},
+			{Name: "endpoint", Type: FieldString, Label: "接口地址", Format: FormatURL},
+		},
+	})
+}
+
+// cloudflareConfig Cloudflare配置
+type cloudflareConfig struct {
+	APIToken string `json:"api_token"`
+	ZoneID   string `json:"zone_id"`  // 可选，默认区域ID，域名未指定区域ID时使用
+	Endpoint string `json:"endpoint"` // 可选，自定义接口地址
+}
+
+// cloudflareProvider Cloudflare驱动
+type cloudflareProvider struct {
+	token    string
+	zoneID   string
+	endpoint string
+	client   *http.Client
+}
+
+func newCloudflare(config []byte) (Provider, error) {
+	var cfg cloudflareConfig
+	if err := json.Unmarshal(config, &cfg); err != nil {
+		return nil, fmt.Errorf("Cloudflare配置格式错误: %v", err)
+	}
+	if cfg.APIToken == "" {
+		return nil, errors.New("Cloudflare配置缺少api_token")
+	}
+	if cfg.Endpoint == "" {
+		cfg.Endpoint = cloudflareDefaultEndpoint
+	}
+
+	return &cloudflareProvider{
+		token:    cfg.APIToken,
+		zoneID:   cfg.ZoneID,
+		endpoint: strings.TrimSuffix(cfg.Endpoint, "/"),
+		client:   newHTTPClient(),
+	}, nil
+}
+
+// cloudflareResultInfo 分页信息
+type cloudflareResultInfo struct {
+	Page       int `json:"page"`
+	PerPage    int `json:"per_page"`
+	TotalPages int `json:"total_pages"`
+	Count      int `json:"count"`
+	TotalCount int `json:"total_count"`
+}
+
+// cloudflareResponse Cloudflare接口通用响应
+type cloudflareResponse struct {
+	Success bool `json:"success"`
+	Errors  []struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	} `json:"errors"`
+	Result     json.RawMessage       `json:"result"`
+	ResultInfo *cloudflareResultInfo `json:"result_info"`
+}
+
+// cloudflareRecord Cloudflare记录
+type cloudflareRecord struct {
+	ID       string                 `json:"id,omitempty"`
+	Type     string                 `json:"type"`
+	Name     string                 `json:"name"`
+	Content  string                 `json:"content,omitempty"`
+	TTL      int                    `json:"ttl"`
+	Priority *int                   `json:"priority,omitempty"`
+	Proxied  *bool                  `json:"proxied,omitempty"`
+	Data     map[string]interface{} `json:"data,omitempty"`
+}
+
+// do 调用Cloudflare接口，out为result字段的解析目标
+func (p *cloudflareProvider) do(ctx context.Context, method, path string, query url.Values, body, out interface{}) (*cloudflareResultInfo, error) {
+	reqURL := p.endpoint + path
+	if len(query) > 0 {
+		reqURL += "?" + query.Encode()
+	}
+
+	reqBody, err := jsonBody(body)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, method, reqURL, reqBody)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+p.token)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("请求Cloudflare失败: %v", err)
+	}
+	data, err := readBody(resp)
+	if err != nil {
+		return nil, fmt.Errorf("读取Cloudflare响应失败: %v", err)
+	}
+
+	var result cloudflareResponse
+	if err := json.Unmarshal(data, &result); err != nil {
+		return nil, &APIError{Provider: "Cloudflare", StatusCode: resp.StatusCode, Message: "响应格式错误"}
+	}
+
+	if !result.Success {
+		apiErr := &APIError{Provider: "Cloudflare", StatusCode: resp.StatusCode, Message: http.StatusText(resp.StatusCode)}
+		if len(result.Errors) > 0 {
+			apiErr.Code = strconv.Itoa(result.Errors[0].Code)
+			apiErr.Message = result.Errors[0].Message
+		}
+		// 81044: 记录不存在。其他路径返回404通常是区域ID或接口地址配置错误，不能当作记录已删除
+		if apiErr.Code == "81044" || (resp.StatusCode == http.StatusNotFound && strings.Contains(path, "/dns_records/")) {
+			return nil, fmt.Errorf("%w: %s", ErrRecordNotFound, apiErr.Message)
+		}
+		return nil, apiErr
+	}
+
+	if out != nil && len(result.Result) > 0 {
+		if err := json.Unmarshal(result.Result, out); err != nil {
+			return nil, fmt.Errorf("解析Cloudflare响应失败: %v", err)
+		}
+	}
+	return result.ResultInfo, nil
+}
+
+// ListZones 列出账户下的全部区域
+func (p *cloudflareProvider) ListZones(ctx context.Context) ([]Zone, error) {
+	var zones []Zone
+
+	for page := 1; ; page++ {
+		var result []struct {
+			ID   string `json:"id"`
+			Name string `json:"name"`
+		}
+		query := url.Values{
+			"page":     {strconv.Itoa(page)},
+			"per_page": {"50"},
+		}
+		info, err := p.do(ctx, http.MethodGet, "/zones", query, nil, &result)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, z := range result {
+			zones = append(zones, Zone{ID: z.ID, Name: z.Name})
+		}
+		if info == nil || page >= info.TotalPages || len(result) == 0 {
+			return zones, nil
+		}
+	}
+}
+
+// resolveZoneID 获取区域ID：优先使用域名绑定的区域ID，其次为配置中的zone_id，最后按域名查询
+//
+// 配置中的zone_id对该服务商下的所有域名生效，使用前检查区域名称与域名一致，避免把记录写入其他区域。
+func (p *cloudflareProvider) resolveZoneID(ctx context.Context, zone Zone) (string, error) {
+	if zone.ID != "" {
+		return zone.ID, nil
+	}
+	if p.zoneID != "" {
+		var result struct {
+			Name string `json:"name"`
+		}
+		if _, err := p.do(ctx, http.MethodGet, "/zones/"+url.PathEscape(p.zoneID), nil, nil, &result); err != nil {
+			return "", err
+		}
+		if !strings.EqualFold(strings.TrimSuffix(result.Name, "."), strings.TrimSuffix(zone.Name, ".")) {
+			return "", fmt.Errorf("%w: 配置的区域 %s 属于 %s，与域名 %s 不一致", ErrZoneNotFound, p.zoneID, result.Name, zone.Name)
+		}
+		return p.zoneID, nil
+	}
+
+	var result []struct {
+		ID string `json:"id"`
+	}
+	if _, err := p.do(ctx, http.MethodGet, "/zones", url.Values{"name": {zone.Name}}, nil, &result); err != nil {
+		return "", err
+	}
+	if len(result) == 0 {
+		return "", fmt.Errorf("%w: %s", ErrZoneNotFound, zone.Name)
+	}
+	return result[0].ID, nil
+}
+
+// ListRecords 分页列出区域内的全部记录
+func (p *cloudflareProvider) ListRecords(ctx context.Context, zone Zone) ([]Record, error) {
+	zoneID, err := p.resolveZoneID(ctx, zone)
+	if err != nil {
+		return nil, err
+	}
+
+	var records []Record
+	for page := 1; ; page++ {
+		var result []cloudflareRecord
+		query := url.Values{
+			"page":     {strconv.Itoa(page)},
+			"per_page": {strconv.Itoa(cloudflarePageSize)},
+		}
+		info, err := p.do(ctx, http.MethodGet, "/zones/"+zoneID+"/dns_records", query, nil, &result)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, r := range result {
+			records = append(records, r.toRecord(zone.Name))
+		}
+		if info == nil || page >= info.TotalPages || len(result) == 0 {
+			return records, nil
+		}
+	}
+}
+
+// CreateRecord 创建记录
+func (p *cloudflareProvider) CreateRecord(ctx context.Context, zone Zone, record Record) (string, error) {
+	zoneID, err := p.resolveZoneID(ctx, zone)
+	if err != nil {
+		return "", err
+	}
+
+	body, err := newCloudflareRecord(zone.Name, record)
+	if err != nil {
+		return "", err
+	}
+
+	var result cloudflareRecord
+	if _, err := p.do(ctx, http.MethodPost, "/zones/"+zoneID+"/dns_records", nil, body, &result); err != nil {
+		return "", err
+	}
+	return result.ID, nil
+}
+
+// UpdateRecord 覆盖更新记录
+func (p *cloudflareProvider) UpdateRecord(ctx context.Context, zone Zone, old, record Record) (string, error) {
+	zoneID, err := p.resolveZoneID(ctx, zone)
+	if err != nil {
+		return "", err
+	}
+
+	body, err := newCloudflareRecord(zone.Name, record)
+	if err != nil {
+		return "", err
+	}
+
+	var result cloudflareRecord
+	if _, err := p.do(ctx, http.MethodPut, "/zones/"+zoneID+"/dns_records/"+url.PathEscape(old.ID), nil, body, &result); err != nil {
+		return "", err
+	}
+	if result.ID == "" {
+		return old.ID, nil
+	}
+	return result.ID, nil
+}
+
+// DeleteRecord 删除记录
+func (p *cloudflareProvider) DeleteRecord(ctx context.Context, zone Zone, record Record) error {
+	zoneID, err := p.resolveZoneID(ctx, zone)
+	if err != nil {
+		return err
+	}
+
+	_, err = p.do(ctx, http.MethodDelete, "/zones/"+zoneID+"/dns_records/"+url.PathEscape(record.ID), nil, nil, nil)
+	return err
+}
+
+// SupportsProxy Cloudflare支持CDN代理
+func (p *cloudflareProvider) SupportsProxy() bool {
+	return true
+}
+
+// Test 校验API Token是否有效
+func (p *cloudflareProvider) Test(ctx context.Context) error {
+	_, err := p.do(ctx, http.MethodGet, "/user/tokens/verify", nil, nil, nil)
+	return err
+}
+
+// newCloudflareRecord 构造Cloudflare记录请求体，SRV和CAA记录使用结构化的data字段
+func newCloudflareRecord(zoneName string, record Record) (*cloudflareRecord, error) {
+	recordType := strings.ToUpper(record.Type)
+	body := &cloudflareRecord{
+		Type: recordType,
+		Name: FQDN(record.Name, zoneName),
+		TTL:  record.TTL,
+	}
+
+	switch recordType {
+	case "A", "AAAA", "CNAME":
+		body.Content = record.Value
+		proxied := record.Proxied
+		body.Proxied = &proxied
+		if proxied {
+			// 开启代理时TTL只能为自动
+			body.TTL = 1
+		}
+	case "MX":
+		priority := record.Priority
+		body.Content = record.Value
+		body.Priority = &priority
+	case "SRV":
+		body.Data = map[string]interface{}{
+			"priority": record.Priority,
+			"weight":   record.Weight,
+			"port":     record.Port,
+			"target":   record.Value,
+		}
+	case "CAA":
+		flags, tag, value, err := parseCAAValue(record.Value)
+		if err != nil {
+			return nil, err
+		}
+		body.Data = map[string]interface{}{
+			"flags": flags,
+			"tag":   tag,
+			"value": value,
+		}
+	default:
+		body.Content = record.Value
+	}
+
+	return body, nil
+}
+
+// toRecord 转换为驱动记录
+func (r cloudflareRecord) toRecord(zoneName string) Record {
+	record := Record{
+		ID:    r.ID,
+		Name:  RelativeName(r.Name, zoneName),
+		Type:  strings.ToUpper(r.Type),
+		Value: r.Content,
+		TTL:   r.TTL,
+	}
+	if r.Proxied != nil {
+		record.Proxied = *r.Proxied
+	}
+	if r.Priority != nil {
+		record.Priority = *r.Priority
+	}
+
+	switch record.Type {
+	case "SRV":
+		if r.Data != nil {
+			record.Priority = dataInt(r.Data, "priority")
+			record.Weight = dataInt(r.Data, "weight")
+			record.Port = dataInt(r.Data, "port")
+			record.Value = fmt.Sprint(r.Data["target"])
+		}
+	case "CAA":
+		if r.Data != nil {
+			record.Value = fmt.Sprintf("%d %v %v", dataInt(r.Data, "flags"), r.Data["tag"], r.Data["value"])
+		}
+	}
+	record.Value = normalizeValue(record.Type, record.Value)
+	return record
+}
+
+// dataInt 读取data字段中的整数值
+func dataInt(data map[string]interface{}, key string) int {
+	switch v := data[key].(type) {
+	case float64:
+		return int(v)
+	case string:
+		n, _ := strconv.Atoi(v)
+		return n
+	default:
+		return 0
+	}
+}
+
+// parseCAAValue 解析"flags tag value"格式的CAA记录值，value两侧的引号会被去掉
+func parseCAAValue(value string) (int, string, string, error) {
+	parts := strings.Fields(value)
+	if len(parts) < 3 {
+		return 0, "", "", errors.New("CAA记录格式应为：flags tag value")
+	}
+	flags, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return 0, "", "", errors.New("CAA记录flags必须是数字")
+	}
+	return flags, parts[1], strings.Trim(strings.Join(parts[2:], " "), `"`), nil
+}
@@ -0,0 +1,301 @@
+package providers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeCloudflare 模拟Cloudflare v4接口，只有ID为zone1的example.com一个区域
+type fakeCloudflare struct {
+	mu      sync.Mutex
+	nextID  int
+	records map[string]cloudflareRecord
+}
+
+func (f *fakeCloudflare) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("Authorization") != "Bearer token" {
+		cloudflareError(w, http.StatusForbidden, 10000, "Authentication error")
+		return
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if r.URL.Path == "/zones" {
+		result := []map[string]string{}
+		if r.URL.Query().Get("name") == "example.com" {
+			result = append(result, map[string]string{"id": "zone1", "name": "example.com"})
+		}
+		cloudflareResult(w, result)
+		return
+	}
+	if r.URL.Path == "/zones/zone1" {
+		cloudflareResult(w, map[string]string{"id": "zone1", "name": "example.com"})
+		return
+	}
+
+	const prefix = "/zones/zone1/dns_records"
+	if !strings.HasPrefix(r.URL.Path, prefix) {
+		cloudflareError(w, http.StatusNotFound, 7003, "Could not route to "+r.URL.Path+", perhaps your object identifier is invalid?")
+		return
+	}
+	id := strings.TrimPrefix(strings.TrimPrefix(r.URL.Path, prefix), "/")
+
+	switch {
+	case id == "" && r.Method == http.MethodGet:
+		result := []cloudflareRecord{}
+		for _, record := range f.records {
+			result = append(result, record)
+		}
+		cloudflareResult(w, result)
+		return
+	case id == "" && r.Method == http.MethodPost:
+		var record cloudflareRecord
+		json.NewDecoder(r.Body).Decode(&record)
+		f.nextID++
+		record.ID = fmt.Sprintf("rec%d", f.nextID)
+		f.records[record.ID] = record
+		cloudflareResult(w, record)
+		return
+	case id == "legacy":
+		// 早期接口删除不存在的记录时只返回404
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"success":false,"errors":[]}`))
+		return
+	}
+
+	if _, ok := f.records[id]; !ok {
+		cloudflareError(w, http.StatusNotFound, 81044, "Record does not exist.")
+		return
+	}
+	switch r.Method {
+	case http.MethodPut:
+		var record cloudflareRecord
+		json.NewDecoder(r.Body).Decode(&record)
+		record.ID = id
+		f.records[id] = record
+		cloudflareResult(w, record)
+	case http.MethodDelete:
+		delete(f.records, id)
+		cloudflareResult(w, map[string]string{"id": id})
+	}
+}
+
+func cloudflareResult(w http.ResponseWriter, result interface{}) {
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success":     true,
+		"errors":      []interface{}{},
+		"result":      result,
+		"result_info": map[string]int{"page": 1, "total_pages": 1},
+	})
+}
+
+func cloudflareError(w http.ResponseWriter, status, code int, message string) {
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": false,
+		"errors":  []map[string]interface{}{{"code": code, "message": message}},
+	})
+}
+
+func newTestCloudflare(t *testing.T, token string) (Provider, *fakeCloudflare) {
+	t.Helper()
+
+	fake := &fakeCloudflare{records: map[string]cloudflareRecord{}}
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+
+	config, _ := json.Marshal(cloudflareConfig{APIToken: token, Endpoint: server.URL})
+	p, err := newCloudflare(config)
+	if err != nil {
+		t.Fatalf("创建驱动失败: %v", err)
+	}
+	return p, fake
+}
+
+func TestCloudflareRecords(t *testing.T) {
+	ctx := context.Background()
+	p, fake := newTestCloudflare(t, "token")
+	zone := Zone{Name: "example.com"}
+
+	tests := []struct {
+		name   string
+		record Record
+		want   Record // 读取时的记录，开启代理时TTL为自动
+	}{
+		{
+			name:   "proxied A",
+			record: Record{Name: "www", Type: "A", Value: "1.1.1.1", TTL: 600, Proxied: true},
+			want:   Record{Name: "www", Type: "A", Value: "1.1.1.1", TTL: 1, Proxied: true},
+		},
+		{
+			name:   "MX",
+			record: Record{Name: "@", Type: "MX", Value: "mail.example.com", Priority: 10, TTL: 600},
+			want:   Record{Name: "@", Type: "MX", Value: "mail.example.com", Priority: 10, TTL: 600},
+		},
+		{
+			name:   "CAA",
+			record: Record{Name: "@", Type: "CAA", Value: "0 issue letsencrypt.org", TTL: 600},
+			want:   Record{Name: "@", Type: "CAA", Value: "0 issue letsencrypt.org", TTL: 600},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			id, err := p.CreateRecord(ctx, zone, tt.record)
+			if err != nil {
+				t.Fatalf("创建记录失败: %v", err)
+			}
+			if name := fake.records[id].Name; name != FQDN(tt.record.Name, zone.Name) {
+				t.Fatalf("写入的记录名为 %s", name)
+			}
+
+			records, err := p.ListRecords(ctx, zone)
+			if err != nil {
+				t.Fatalf("读取记录失败: %v", err)
+			}
+			var got *Record
+			for i := range records {
+				if records[i].ID == id {
+					got = &records[i]
+				}
+			}
+			want := tt.want
+			want.ID = id
+			if got == nil || *got != want {
+				t.Fatalf("读取的记录为 %+v，应为 %+v", got, want)
+			}
+
+			tt.record.ID = id
+			if err := p.DeleteRecord(ctx, zone, tt.record); err != nil {
+				t.Fatalf("删除记录失败: %v", err)
+			}
+		})
+	}
+}
+
+func TestCloudflareConfigZoneID(t *testing.T) {
+	ctx := context.Background()
+	server := httptest.NewServer(&fakeCloudflare{records: map[string]cloudflareRecord{}})
+	t.Cleanup(server.Close)
+
+	tests := []struct {
+		name   string
+		zoneID string
+		zone   Zone
+		check  func(error) bool
+	}{
+		{
+			name:   "matching zone",
+			zoneID: "zone1",
+			zone:   Zone{Name: "example.com"},
+			check:  func(err error) bool { return err == nil },
+		},
+		{
+			name:   "zone of another domain",
+			zoneID: "zone1",
+			zone:   Zone{Name: "other.com"},
+			check:  func(err error) bool { return errors.Is(err, ErrZoneNotFound) },
+		},
+		{
+			name:   "unknown zone ID",
+			zoneID: "zone2",
+			zone:   Zone{Name: "example.com"},
+			check: func(err error) bool {
+				var apiErr *APIError
+				return errors.As(err, &apiErr) && apiErr.Code == "7003"
+			},
+		},
+		{
+			name:   "domain zone ID takes precedence",
+			zoneID: "zone2",
+			zone:   Zone{ID: "zone1", Name: "example.com"},
+			check:  func(err error) bool { return err == nil },
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config, _ := json.Marshal(cloudflareConfig{APIToken: "token", ZoneID: tt.zoneID, Endpoint: server.URL})
+			p, err := newCloudflare(config)
+			if err != nil {
+				t.Fatalf("创建驱动失败: %v", err)
+			}
+			if _, err := p.ListRecords(ctx, tt.zone); !tt.check(err) {
+				t.Fatalf("返回的错误为 %v", err)
+			}
+		})
+	}
+}
+
+func TestCloudflareErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		token string
+		run   func(p Provider) error
+		check func(error) bool
+	}{
+		{
+			name:  "bad token",
+			token: "wrong",
+			run:   func(p Provider) error { return p.Test(context.Background()) },
+			check: func(err error) bool {
+				var apiErr *APIError
+				return errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusForbidden && apiErr.Code == "10000"
+			},
+		},
+		{
+			name:  "unknown zone name",
+			token: "token",
+			run: func(p Provider) error {
+				_, err := p.ListRecords(context.Background(), Zone{Name: "missing.com"})
+				return err
+			},
+			check: func(err error) bool { return errors.Is(err, ErrZoneNotFound) },
+		},
+		{
+			name:  "wrong zone ID",
+			token: "token",
+			run: func(p Provider) error {
+				_, err := p.CreateRecord(context.Background(), Zone{ID: "zone2", Name: "example.com"}, Record{Name: "www", Type: "A", Value: "1.1.1.1", TTL: 600})
+				return err
+			},
+			check: func(err error) bool {
+				var apiErr *APIError
+				return !errors.Is(err, ErrRecordNotFound) && errors.As(err, &apiErr) && apiErr.Code == "7003"
+			},
+		},
+		{
+			name:  "record not found",
+			token: "token",
+			run: func(p Provider) error {
+				return p.DeleteRecord(context.Background(), Zone{Name: "example.com"}, Record{ID: "missing"})
+			},
+			check: func(err error) bool { return errors.Is(err, ErrRecordNotFound) },
+		},
+		{
+			name:  "404 on record path",
+			token: "token",
+			run: func(p Provider) error {
+				return p.DeleteRecord(context.Background(), Zone{Name: "example.com"}, Record{ID: "legacy"})
+			},
+			check: func(err error) bool { return errors.Is(err, ErrRecordNotFound) },
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, _ := newTestCloudflare(t, tt.token)
+			if err := tt.run(p); !tt.check(err) {
+				t.Fatalf("返回的错误为 %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,289 @@
+package providers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// dnspodDefaultEndpoint DNSPod接口地址
+const dnspodDefaultEndpoint = "https://dnsapi.cn"
+
+// dnspodDefaultLine DNSPod默认线路
+const dnspodDefaultLine = "默认"
+
+func init() {
+	Register("dnspod", newDNSPod)
+	RegisterSchema(Schema{
+		Type:        "dnspod",
+		Name:        "DNSPod",
+		Description: "腾讯云DNSPod",
+		Fields: []Field{
+			{Name: "api_token", Type: FieldString, Label: "API Token", Required: true, Secret: true},
+			{Name: "endpoint", Type: FieldString, Label: "接口地址", Format: FormatURL},
+		},
+	})
+}
+
+// dnspodConfig DNSPod配置
+type dnspodConfig struct {
+	APIToken string `json:"api_token"` // 格式为"ID,Token"
+	Endpoint string `json:"endpoint"`  // 可选，自定义接口地址
+}
+
+// dnspodProvider DNSPod驱动
+type dnspodProvider struct {
+	token    string
+	endpoint string
+	client   *http.Client
+}
+
+func newDNSPod(config []byte) (Provider, error) {
+	var cfg dnspodConfig
+	if err := json.Unmarshal(config, &cfg); err != nil {
+		return nil, fmt.Errorf("DNSPod配置格式错误: %v", err)
+	}
+	if cfg.APIToken == "" {
+		return nil, errors.New("DNSPod配置缺少api_token")
+	}
+	if cfg.Endpoint == "" {
+		cfg.Endpoint = dnspodDefaultEndpoint
+	}
+
+	return &dnspodProvider{
+		token:    cfg.APIToken,
+		endpoint: strings.TrimSuffix(cfg.Endpoint, "/"),
+		client:   newHTTPClient(),
+	}, nil
+}
+
+// dnspodStatus DNSPod接口通用状态
+type dnspodStatus struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// dnspodRecord DNSPod记录
+type dnspodRecord struct {
+	ID    flexString `json:"id"`
+	Name  string     `json:"name"`
+	Type  string     `json:"type"`
+	Value string     `json:"value"`
+	TTL   flexString `json:"ttl"`
+	MX    flexString `json:"mx"`
+}
+
+// call 调用DNSPod接口
+func (p *dnspodProvider) call(ctx context.Context, action string, params url.Values, out interface{}) error {
+	form := url.Values{}
+	for k, v := range params {
+		form[k] = v
+	}
+	form.Set("login_token", p.token)
+	form.Set("format", "json")
+	form.Set("lang", "cn")
+	form.Set("error_on_empty", "no")
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.endpoint+"/"+action, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("User-Agent", "Domain-MAX/1.0")
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("请求DNSPod失败: %v", err)
+	}
+	body, err := readBody(resp)
+	if err != nil {
+		return fmt.Errorf("读取DNSPod响应失败: %v", err)
+	}
+
+	var result struct {
+		Status dnspodStatus `json:"status"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return &APIError{Provider: "DNSPod", StatusCode: resp.StatusCode, Message: "响应格式错误"}
+	}
+
+	switch result.Status.Code {
+	case "1":
+	case "6":
+		return fmt.Errorf("%w: %s", ErrZoneNotFound, result.Status.Message)
+	case "8":
+		return fmt.Errorf("%w: %s", ErrRecordNotFound, result.Status.Message)
+	default:
+		return &APIError{Provider: "DNSPod", StatusCode: resp.StatusCode, Code: result.Status.Code, Message: result.Status.Message}
+	}
+
+	if out != nil {
+		if err := json.Unmarshal(body, out); err != nil {
+			return fmt.Errorf("解析DNSPod响应失败: %v", err)
+		}
+	}
+	return nil
+}
+
+// ListZones 列出DNSPod账户下的域名
+func (p *dnspodProvider) ListZones(ctx context.Context) ([]Zone, error) {
+	const pageSize = 100
+	var zones []Zone
+
+	for offset := 0; ; offset += pageSize {
+		var result struct {
+			Info struct {
+				DomainTotal flexString `json:"domain_total"`
+			} `json:"info"`
+			Domains []struct {
+				ID   flexString `json:"id"`
+				Name string     `json:"name"`
+			} `json:"domains"`
+		}
+		params := url.Values{
+			"offset": {strconv.Itoa(offset)},
+			"length": {strconv.Itoa(pageSize)},
+		}
+		if err := p.call(ctx, "Domain.List", params, &result); err != nil {
+			return nil, err
+		}
+
+		for _, d := range result.Domains {
+			zones = append(zones, Zone{ID: string(d.ID), Name: d.Name})
+		}
+		if len(result.Domains) < pageSize || len(zones) >= result.Info.DomainTotal.Int() {
+			return zones, nil
+		}
+	}
+}
+
+// ListRecords 列出域名下的解析记录
+func (p *dnspodProvider) ListRecords(ctx context.Context, zone Zone) ([]Record, error) {
+	const pageSize = 3000
+	var records []Record
+
+	for offset := 0; ; offset += pageSize {
+		var result struct {
+			Info struct {
+				RecordTotal flexString `json:"record_total"`
+			} `json:"info"`
+			Records []dnspodRecord `json:"records"`
+		}
+		params := p.domainParams(zone)
+		params.Set("offset", strconv.Itoa(offset))
+		params.Set("length", strconv.Itoa(pageSize))
+		if err := p.call(ctx, "Record.List", params, &result); err != nil {
+			return nil, err
+		}
+
+		for _, r := range result.Records {
+			records = append(records, r.toRecord())
+		}
+		if len(result.Records) < pageSize || len(records) >= result.Info.RecordTotal.Int() {
+			return records, nil
+		}
+	}
+}
+
+// CreateRecord 创建解析记录
+func (p *dnspodProvider) CreateRecord(ctx context.Context, zone Zone, record Record) (string, error) {
+	var result struct {
+		Record struct {
+			ID flexString `json:"id"`
+		} `json:"record"`
+	}
+	if err := p.call(ctx, "Record.Create", p.recordParams(zone, record), &result); err != nil {
+		return "", err
+	}
+	return string(result.Record.ID), nil
+}
+
+// UpdateRecord 修改解析记录
+func (p *dnspodProvider) UpdateRecord(ctx context.Context, zone Zone, old, record Record) (string, error) {
+	params := p.recordParams(zone, record)
+	params.Set("record_id", old.ID)
+
+	var result struct {
+		Record struct {
+			ID flexString `json:"id"`
+		} `json:"record"`
+	}
+	if err := p.call(ctx, "Record.Modify", params, &result); err != nil {
+		return "", err
+	}
+	if result.Record.ID == "" {
+		return old.ID, nil
+	}
+	return string(result.Record.ID), nil
+}
+
+// DeleteRecord 删除解析记录
+func (p *dnspodProvider) DeleteRecord(ctx context.Context, zone Zone, record Record) error {
+	params := p.domainParams(zone)
+	params.Set("record_id", record.ID)
+	return p.call(ctx, "Record.Remove", params, nil)
+}
+
+// Test 通过获取账户信息验证Token
+func (p *dnspodProvider) Test(ctx context.Context) error {
+	return p.call(ctx, "User.Detail", nil, nil)
+}
+
+// domainParams 构造指定域名的请求参数，优先使用域名ID
+func (p *dnspodProvider) domainParams(zone Zone) url.Values {
+	params := url.Values{}
+	if zone.ID != "" {
+		params.Set("domain_id", zone.ID)
+	} else {
+		params.Set("domain", zone.Name)
+	}
+	return params
+}
+
+// recordParams 构造创建和修改记录的请求参数
+func (p *dnspodProvider) recordParams(zone Zone, record Record) url.Values {
+	params := p.domainParams(zone)
+	params.Set("sub_domain", record.Name)
+	params.Set("record_type", strings.ToUpper(record.Type))
+	params.Set("record_line", dnspodDefaultLine)
+	if record.TTL > 0 {
+		params.Set("ttl", strconv.Itoa(record.TTL))
+	}
+
+	switch strings.ToUpper(record.Type) {
+	case "MX":
+		params.Set("value", record.Value)
+		params.Set("mx", strconv.Itoa(record.Priority))
+	case "SRV":
+		// DNSPod的SRV记录值包含优先级、权重和端口
+		params.Set("value", RecordValue(record))
+	default:
+		params.Set("value", record.Value)
+	}
+	return params
+}
+
+// toRecord 转换为驱动记录
+func (r dnspodRecord) toRecord() Record {
+	record := Record{
+		ID:    string(r.ID),
+		Name:  r.Name,
+		Type:  strings.ToUpper(r.Type),
+		Value: r.Value,
+		TTL:   r.TTL.Int(),
+	}
+
+	switch record.Type {
+	case "MX":
+		record.Priority = r.MX.Int()
+	case "SRV":
+		splitSRVValue(&record, r.Value)
+	}
+	record.Value = normalizeValue(record.Type, record.Value)
+	return record
+}
@@ -0,0 +1,222 @@
+package providers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeDNSPod 模拟DNSPod接口，只有example.com一个域名
+type fakeDNSPod struct {
+	mu      sync.Mutex
+	nextID  int
+	records map[string]dnspodRecord
+}
+
+func (f *fakeDNSPod) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost || r.ParseForm() != nil || r.PostForm.Get("format") != "json" {
+		w.WriteHeader(http.StatusBadGateway)
+		w.Write([]byte("<html>Bad Gateway</html>"))
+		return
+	}
+	form := r.PostForm
+	if form.Get("login_token") != "id,token" {
+		dnspodReply(w, "-1", "登录失败", nil)
+		return
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	action := strings.TrimPrefix(r.URL.Path, "/")
+	if action == "User.Detail" {
+		dnspodReply(w, "1", "Action completed successful", nil)
+		return
+	}
+	if form.Get("domain") != "example.com" && form.Get("domain_id") != "100" {
+		dnspodReply(w, "6", "域名ID错误", nil)
+		return
+	}
+
+	id := form.Get("record_id")
+	if _, ok := f.records[id]; !ok && action != "Record.Create" && action != "Record.List" {
+		dnspodReply(w, "8", "记录ID错误", nil)
+		return
+	}
+
+	switch action {
+	case "Record.List":
+		records := []dnspodRecord{}
+		for _, record := range f.records {
+			records = append(records, record)
+		}
+		dnspodReply(w, "1", "", map[string]interface{}{
+			"info":    map[string]string{"record_total": strconv.Itoa(len(records))},
+			"records": records,
+		})
+	case "Record.Create", "Record.Modify":
+		if action == "Record.Create" {
+			f.nextID++
+			id = strconv.Itoa(f.nextID)
+		}
+		f.records[id] = dnspodRecord{
+			ID:    flexString(id),
+			Name:  form.Get("sub_domain"),
+			Type:  form.Get("record_type"),
+			Value: form.Get("value"),
+			TTL:   flexString(form.Get("ttl")),
+			MX:    flexString(form.Get("mx")),
+		}
+		// DNSPod返回的记录ID为数字
+		n, _ := strconv.Atoi(id)
+		dnspodReply(w, "1", "", map[string]interface{}{"record": map[string]int{"id": n}})
+	case "Record.Remove":
+		delete(f.records, id)
+		dnspodReply(w, "1", "", nil)
+	}
+}
+
+func dnspodReply(w http.ResponseWriter, code, message string, data map[string]interface{}) {
+	body := map[string]interface{}{"status": dnspodStatus{Code: code, Message: message}}
+	for k, v := range data {
+		body[k] = v
+	}
+	json.NewEncoder(w).Encode(body)
+}
+
+func newTestDNSPod(t *testing.T, token string) (Provider, *fakeDNSPod) {
+	t.Helper()
+
+	fake := &fakeDNSPod{records: map[string]dnspodRecord{}}
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+
+	config, _ := json.Marshal(dnspodConfig{APIToken: token, Endpoint: server.URL})
+	p, err := newDNSPod(config)
+	if err != nil {
+		t.Fatalf("创建驱动失败: %v", err)
+	}
+	return p, fake
+}
+
+func TestDNSPodRecords(t *testing.T) {
+	ctx := context.Background()
+	p, _ := newTestDNSPod(t, "id,token")
+
+	tests := []struct {
+		name   string
+		zone   Zone
+		record Record
+		update Record
+	}{
+		{
+			name:   "A by domain name",
+			zone:   Zone{Name: "example.com"},
+			record: Record{Name: "www", Type: "A", Value: "1.1.1.1", TTL: 600},
+			update: Record{Name: "www", Type: "A", Value: "2.2.2.2", TTL: 300},
+		},
+		{
+			name:   "MX by domain ID",
+			zone:   Zone{ID: "100", Name: "example.com"},
+			record: Record{Name: "@", Type: "MX", Value: "mail.example.com", Priority: 10, TTL: 600},
+			update: Record{Name: "@", Type: "MX", Value: "mx.example.com", Priority: 20, TTL: 600},
+		},
+		{
+			name:   "SRV",
+			zone:   Zone{Name: "example.com"},
+			record: Record{Name: "_sip._tcp", Type: "SRV", Value: "sip.example.com", Priority: 10, Weight: 5, Port: 5060, TTL: 600},
+			update: Record{Name: "_sip._tcp", Type: "SRV", Value: "sip2.example.com", Priority: 20, Weight: 5, Port: 5061, TTL: 600},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			id, err := p.CreateRecord(ctx, tt.zone, tt.record)
+			if err != nil {
+				t.Fatalf("创建记录失败: %v", err)
+			}
+			tt.record.ID = id
+			assertDNSPodRecord(t, p, tt.zone, tt.record)
+
+			if _, err := p.UpdateRecord(ctx, tt.zone, tt.record, tt.update); err != nil {
+				t.Fatalf("更新记录失败: %v", err)
+			}
+			tt.update.ID = id
+			assertDNSPodRecord(t, p, tt.zone, tt.update)
+
+			if err := p.DeleteRecord(ctx, tt.zone, tt.update); err != nil {
+				t.Fatalf("删除记录失败: %v", err)
+			}
+		})
+	}
+}
+
+func TestDNSPodErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		token string
+		run   func(p Provider) error
+		check func(error) bool
+	}{
+		{
+			name:  "bad token",
+			token: "id,wrong",
+			run:   func(p Provider) error { return p.Test(context.Background()) },
+			check: func(err error) bool {
+				var apiErr *APIError
+				return errors.As(err, &apiErr) && apiErr.Code == "-1"
+			},
+		},
+		{
+			name:  "unknown domain",
+			token: "id,token",
+			run: func(p Provider) error {
+				_, err := p.CreateRecord(context.Background(), Zone{Name: "missing.com"}, Record{Name: "www", Type: "A", Value: "1.1.1.1"})
+				return err
+			},
+			check: func(err error) bool { return errors.Is(err, ErrZoneNotFound) },
+		},
+		{
+			name:  "record not found",
+			token: "id,token",
+			run: func(p Provider) error {
+				return p.DeleteRecord(context.Background(), Zone{Name: "example.com"}, Record{ID: "404"})
+			},
+			check: func(err error) bool { return errors.Is(err, ErrRecordNotFound) },
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, _ := newTestDNSPod(t, tt.token)
+			if err := tt.run(p); !tt.check(err) {
+				t.Fatalf("返回的错误为 %v", err)
+			}
+		})
+	}
+}
+
+// assertDNSPodRecord 检查读取的记录与写入的记录一致
+func assertDNSPodRecord(t *testing.T, p Provider, zone Zone, want Record) {
+	t.Helper()
+
+	records, err := p.ListRecords(context.Background(), zone)
+	if err != nil {
+		t.Fatalf("读取记录失败: %v", err)
+	}
+	for _, got := range records {
+		if got.ID == want.ID {
+			if got != want {
+				t.Fatalf("读取的记录为 %+v，应为 %+v", got, want)
+			}
+			return
+		}
+	}
+	t.Fatalf("没有ID为 %s 的记录", want.ID)
+}
@@ -0,0 +1,53 @@
+package providers
+
+import (
+	"domain-max/pkg/dns/models"
+	"errors"
+
+	"gorm.io/gorm"
+)
+
+// 域名绑定服务商相关错误
+var (
+	ErrDomainProviderNotFound = errors.New("域名绑定的DNS服务商不存在")
+	ErrDomainProviderDisabled = errors.New("域名绑定的DNS服务商已禁用")
+)
+
+// OpenForDomain 创建托管该域名的主DNS服务商驱动，域名未绑定服务商时返回nil
+func OpenForDomain(db *gorm.DB, domain *models.Domain) (Provider, error) {
+	if domain.ProviderID == nil {
+		return nil, nil
+	}
+	return OpenByID(db, *domain.ProviderID)
+}
+
+// OpenByID 按ID创建DNS服务商驱动，服务商不存在或已禁用时返回错误
+func OpenByID(db *gorm.DB, providerID uint) (Provider, error) {
+	var provider models.DNSProvider
+	if err := db.First(&provider, providerID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrDomainProviderNotFound
+		}
+		return nil, err
+	}
+	if !provider.IsActive {
+		return nil, ErrDomainProviderDisabled
+	}
+	return Open(&provider)
+}
+
+// ZoneForDomain 返回域名在主DNS服务商中对应的区域
+func ZoneForDomain(domain *models.Domain) Zone {
+	return Zone{ID: domain.ZoneID, Name: domain.Name}
+}
+
+// ZoneForProvider 返回域名在指定DNS服务商中对应的区域
+func ZoneForProvider(domain *models.Domain, providerID uint) Zone {
+	switch {
+	case domain.ProviderID != nil && *domain.ProviderID == providerID:
+		return Zone{ID: domain.ZoneID, Name: domain.Name}
+	case domain.SecondaryProviderID != nil && *domain.SecondaryProviderID == providerID:
+		return Zone{ID: domain.SecondaryZoneID, Name: domain.Name}
+	}
+	return Zone{Name: domain.Name}
+}
@@ -0,0 +1,315 @@
+package providers
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// godaddyDefaultEndpoint GoDaddy接口地址
+const godaddyDefaultEndpoint = "https://api.godaddy.com"
+
+func init() {
+	Register("godaddy", newGoDaddy)
+	RegisterSchema(Schema{
+		Type:        "godaddy",
+		Name:        "GoDaddy",
+		Description: "GoDaddy DNS",
+		Fields: []Field{
+			{Name: "api_key", Type: FieldString, Label: "API Key", Required: true},
+			{Name: "api_secret", Type: FieldString, Label: "API Secret", Required: true, Secret: true},
+			{Name: "endpoint", Type: FieldString, Label: "接口地址", Format: FormatURL},
+		},
+	})
+}
+
+// godaddyConfig GoDaddy配置
+type godaddyConfig struct {
+	APIKey    string `json:"api_key"`
+	APISecret string `json:"api_secret"`
+	Endpoint  string `json:"endpoint"` // 可选，自定义接口地址（如OTE测试环境）
+}
+
+// godaddyProvider GoDaddy驱动
+//
+// GoDaddy的记录没有独立ID，写接口按"名称+类型"整体替换记录集（RRset），
+// 写入由rrsetEditor读取所在记录集并在本地合并，再通过PUT整体提交。
+type godaddyProvider struct {
+	apiKey    string
+	apiSecret string
+	endpoint  string
+	client    *http.Client
+}
+
+func newGoDaddy(config []byte) (Provider, error) {
+	var cfg godaddyConfig
+	if err := json.Unmarshal(config, &cfg); err != nil {
+		return nil, fmt.Errorf("GoDaddy配置格式错误: %v", err)
+	}
+	if cfg.APIKey == "" || cfg.APISecret == "" {
+		return nil, errors.New("GoDaddy配置缺少api_key或api_secret")
+	}
+	if cfg.Endpoint == "" {
+		cfg.Endpoint = godaddyDefaultEndpoint
+	}
+
+	return &godaddyProvider{
+		apiKey:    cfg.APIKey,
+		apiSecret: cfg.APISecret,
+		endpoint:  strings.TrimSuffix(cfg.Endpoint, "/"),
+		client:    newHTTPClient(),
+	}, nil
+}
+
+// godaddyRecord GoDaddy记录
+type godaddyRecord struct {
+	Type     string `json:"type,omitempty"`
+	Name     string `json:"name,omitempty"`
+	Data     string `json:"data"`
+	TTL      int    `json:"ttl,omitempty"`
+	Priority int    `json:"priority,omitempty"`
+	Weight   int    `json:"weight,omitempty"`
+	Port     int    `json:"port,omitempty"`
+	Service  string `json:"service,omitempty"`
+	Protocol string `json:"protocol,omitempty"`
+}
+
+// do 调用GoDaddy接口
+func (p *godaddyProvider) do(ctx context.Context, method, path string, body, out interface{}) error {
+	reqBody, err := jsonBody(body)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, method, p.endpoint+path, reqBody)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "sso-key "+p.apiKey+":"+p.apiSecret)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("请求GoDaddy失败: %v", err)
+	}
+	data, err := readBody(resp)
+	if err != nil {
+		return fmt.Errorf("读取GoDaddy响应失败: %v", err)
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		var result struct {
+			Code    string `json:"code"`
+			Message string `json:"message"`
+		}
+		json.Unmarshal(data, &result)
+		if resp.StatusCode == http.StatusNotFound {
+			if result.Code == "UNKNOWN_DOMAIN" {
+				return fmt.Errorf("%w: %s", ErrZoneNotFound, result.Message)
+			}
+			return fmt.Errorf("%w: %s", ErrRecordNotFound, result.Message)
+		}
+		return &APIError{Provider: "GoDaddy", StatusCode: resp.StatusCode, Code: result.Code, Message: result.Message}
+	}
+
+	if out != nil && len(data) > 0 {
+		if err := json.Unmarshal(data, out); err != nil {
+			return fmt.Errorf("解析GoDaddy响应失败: %v", err)
+		}
+	}
+	return nil
+}
+
+// ListZones 列出账户下的域名
+func (p *godaddyProvider) ListZones(ctx context.Context) ([]Zone, error) {
+	const pageSize = 1000
+	var zones []Zone
+
+	marker := ""
+	for {
+		query := url.Values{"limit": {strconv.Itoa(pageSize)}}
+		if marker != "" {
+			query.Set("marker", marker)
+		}
+
+		var result []struct {
+			DomainID flexString `json:"domainId"`
+			Domain   string     `json:"domain"`
+		}
+		if err := p.do(ctx, http.MethodGet, "/v1/domains?"+query.Encode(), nil, &result); err != nil {
+			return nil, err
+		}
+
+		for _, d := range result {
+			zones = append(zones, Zone{ID: string(d.DomainID), Name: d.Domain})
+		}
+		if len(result) < pageSize {
+			return zones, nil
+		}
+		marker = result[len(result)-1].Domain
+	}
+}
+
+// ListRecords 列出域名下的全部记录
+func (p *godaddyProvider) ListRecords(ctx context.Context, zone Zone) ([]Record, error) {
+	var result []godaddyRecord
+	if err := p.do(ctx, http.MethodGet, "/v1/domains/"+url.PathEscape(zone.Name)+"/records", nil, &result); err != nil {
+		return nil, err
+	}
+
+	records := make([]Record, 0, len(result))
+	for _, r := range result {
+		records = append(records, r.toRecord(zone.Name))
+	}
+	return records, nil
+}
+
+// CreateRecord 将记录合并到所在的记录集中
+func (p *godaddyProvider) CreateRecord(ctx context.Context, zone Zone, record Record) (string, error) {
+	return p.rrsets().create(ctx, zone, record)
+}
+
+// UpdateRecord 在记录集中替换旧记录
+func (p *godaddyProvider) UpdateRecord(ctx context.Context, zone Zone, old, record Record) (string, error) {
+	return p.rrsets().update(ctx, zone, old, record)
+}
+
+// DeleteRecord 从记录集中移除记录
+func (p *godaddyProvider) DeleteRecord(ctx context.Context, zone Zone, record Record) error {
+	return p.rrsets().delete(ctx, zone, record)
+}
+
+// Test 通过查询域名列表验证API Key
+func (p *godaddyProvider) Test(ctx context.Context) error {
+	return p.do(ctx, http.MethodGet, "/v1/domains?limit=1", nil, nil)
+}
+
+// rrsets 返回按记录集写入记录的编辑器
+func (p *godaddyProvider) rrsets() rrsetEditor[godaddyRecord] {
+	return rrsetEditor[godaddyRecord]{
+		api: p,
+		newItem: func(record Record) (godaddyRecord, error) {
+			return newGoDaddyRecord(record), nil
+		},
+		itemKey: godaddyRecord.key,
+		itemID: func(_ Record, item godaddyRecord) string {
+			return item.id()
+		},
+	}
+}
+
+// rrsetPath 返回记录所在记录集的接口路径
+func (p *godaddyProvider) rrsetPath(zone Zone, record Record) string {
+	item := newGoDaddyRecord(record)
+	return "/v1/domains/" + url.PathEscape(zone.Name) + "/records/" + item.Type + "/" + url.PathEscape(item.Name)
+}
+
+// rrsetKey 返回记录所在记录集的唯一标识
+func (p *godaddyProvider) rrsetKey(zone Zone, record Record) string {
+	item := newGoDaddyRecord(record)
+	return "godaddy|" + p.apiKey + "|" + strings.ToLower(zone.Name) + "|" + item.Type + "|" + item.Name
+}
+
+// readRRSet 读取记录所在的记录集
+func (p *godaddyProvider) readRRSet(ctx context.Context, zone Zone, record Record) ([]godaddyRecord, error) {
+	var current []godaddyRecord
+	if err := p.do(ctx, http.MethodGet, p.rrsetPath(zone, record), nil, &current); err != nil && !errors.Is(err, ErrRecordNotFound) {
+		return nil, err
+	}
+	return current, nil
+}
+
+// writeRRSet 整体写回记录集，记录集为空时删除
+func (p *godaddyProvider) writeRRSet(ctx context.Context, zone Zone, record Record, items []godaddyRecord) error {
+	path := p.rrsetPath(zone, record)
+	if len(items) == 0 {
+		return p.do(ctx, http.MethodDelete, path, nil, nil)
+	}
+
+	// 写回时名称和类型由路径决定
+	body := make([]godaddyRecord, len(items))
+	for i, item := range items {
+		item.Name = ""
+		item.Type = ""
+		body[i] = item
+	}
+	return p.do(ctx, http.MethodPut, path, body, nil)
+}
+
+// newGoDaddyRecord 构造GoDaddy记录，SRV记录的"_服务._协议.名称"拆分为独立字段
+func newGoDaddyRecord(record Record) godaddyRecord {
+	item := godaddyRecord{
+		Type: strings.ToUpper(record.Type),
+		Name: record.Name,
+		Data: record.Value,
+		TTL:  record.TTL,
+	}
+	if item.Name == "" {
+		item.Name = "@"
+	}
+
+	switch item.Type {
+	case "MX":
+		item.Priority = record.Priority
+	case "SRV":
+		item.Priority = record.Priority
+		item.Weight = record.Weight
+		item.Port = record.Port
+
+		labels := strings.SplitN(item.Name, ".", 3)
+		if len(labels) >= 2 && strings.HasPrefix(labels[0], "_") && strings.HasPrefix(labels[1], "_") {
+			item.Service = labels[0]
+			item.Protocol = labels[1]
+			item.Name = "@"
+			if len(labels) == 3 {
+				item.Name = labels[2]
+			}
+		}
+	}
+	return item
+}
+
+// key 记录在记录集中的唯一标识（不含TTL）
+func (r godaddyRecord) key() string {
+	return fmt.Sprintf("%s|%d|%d|%d|%s|%s", strings.TrimSuffix(strings.ToLower(r.Data), "."), r.Priority, r.Weight, r.Port, r.Service, r.Protocol)
+}
+
+// id 生成稳定的记录ID，格式为"类型/名称/内容摘要"
+func (r godaddyRecord) id() string {
+	sum := sha256.Sum256([]byte(r.key()))
+	return strings.ToUpper(r.Type) + "/" + r.Name + "/" + hex.EncodeToString(sum[:8])
+}
+
+// toRecord 转换为驱动记录
+func (r godaddyRecord) toRecord(zoneName string) Record {
+	record := Record{
+		ID:       r.id(),
+		Name:     r.Name,
+		Type:     strings.ToUpper(r.Type),
+		Value:    r.Data,
+		TTL:      r.TTL,
+		Priority: r.Priority,
+		Weight:   r.Weight,
+		Port:     r.Port,
+	}
+
+	if record.Type == "SRV" && r.Service != "" && r.Protocol != "" {
+		record.Name = r.Service + "." + r.Protocol
+		if r.Name != "" && r.Name != "@" {
+			record.Name += "." + r.Name
+		}
+	}
+	// GoDaddy使用"@"表示根域名
+	if record.Value == "@" {
+		record.Value = zoneName
+	}
+	record.Value = normalizeValue(record.Type, record.Value)
+	return record
+}
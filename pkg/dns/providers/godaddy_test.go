@@ -0,0 +1,181 @@
+package providers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeGoDaddy 模拟GoDaddy记录集接口，记录集按"类型/名称"保存
+type fakeGoDaddy struct {
+	mu     sync.Mutex
+	rrsets map[string][]godaddyRecord
+}
+
+func (f *fakeGoDaddy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("Authorization") != "sso-key key:secret" {
+		w.WriteHeader(http.StatusUnauthorized)
+		w.Write([]byte(`{"code":"UNABLE_TO_AUTHENTICATE","message":"Unauthorized"}`))
+		return
+	}
+
+	const prefix = "/v1/domains/example.com/records/"
+	if !strings.HasPrefix(r.URL.Path, prefix) {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"code":"UNKNOWN_DOMAIN","message":"domain not found"}`))
+		return
+	}
+	key := strings.TrimPrefix(r.URL.Path, prefix)
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	switch r.Method {
+	case http.MethodGet:
+		set, ok := f.rrsets[key]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"code":"NOT_FOUND","message":"record not found"}`))
+			return
+		}
+		json.NewEncoder(w).Encode(set)
+	case http.MethodPut:
+		var set []godaddyRecord
+		if err := json.NewDecoder(r.Body).Decode(&set); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		for _, item := range set {
+			if item.Name != "" || item.Type != "" {
+				w.WriteHeader(http.StatusUnprocessableEntity)
+				w.Write([]byte(`{"code":"INVALID_BODY","message":"name and type come from the path"}`))
+				return
+			}
+		}
+		f.rrsets[key] = set
+	case http.MethodDelete:
+		delete(f.rrsets, key)
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// data 返回记录集中的记录值，按字母排序
+func (f *fakeGoDaddy) data(key string) []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var values []string
+	for _, item := range f.rrsets[key] {
+		values = append(values, item.Data)
+	}
+	sort.Strings(values)
+	return values
+}
+
+func newTestGoDaddy(t *testing.T, secret string) (Provider, *fakeGoDaddy) {
+	t.Helper()
+
+	fake := &fakeGoDaddy{rrsets: map[string][]godaddyRecord{}}
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+
+	config, _ := json.Marshal(godaddyConfig{APIKey: "key", APISecret: secret, Endpoint: server.URL})
+	p, err := newGoDaddy(config)
+	if err != nil {
+		t.Fatalf("创建驱动失败: %v", err)
+	}
+	return p, fake
+}
+
+func TestGoDaddyMergesRRSet(t *testing.T) {
+	ctx := context.Background()
+	p, fake := newTestGoDaddy(t, "secret")
+	zone := Zone{Name: "example.com"}
+	a := Record{Name: "www", Type: "A", Value: "1.1.1.1", TTL: 600}
+	b := Record{Name: "www", Type: "A", Value: "2.2.2.2", TTL: 600}
+
+	steps := []struct {
+		name string
+		run  func() error
+		want []string
+	}{
+		{"create first", func() error { _, err := p.CreateRecord(ctx, zone, a); return err }, []string{"1.1.1.1"}},
+		{"create second", func() error { _, err := p.CreateRecord(ctx, zone, b); return err }, []string{"1.1.1.1", "2.2.2.2"}},
+		{"create duplicate", func() error { _, err := p.CreateRecord(ctx, zone, b); return err }, []string{"1.1.1.1", "2.2.2.2"}},
+		{"update", func() error {
+			_, err := p.UpdateRecord(ctx, zone, a, Record{Name: "www", Type: "A", Value: "3.3.3.3", TTL: 600})
+			return err
+		}, []string{"2.2.2.2", "3.3.3.3"}},
+		{"delete", func() error { return p.DeleteRecord(ctx, zone, b) }, []string{"3.3.3.3"}},
+		{"delete last", func() error {
+			return p.DeleteRecord(ctx, zone, Record{Name: "www", Type: "A", Value: "3.3.3.3"})
+		}, nil},
+	}
+
+	for _, step := range steps {
+		if err := step.run(); err != nil {
+			t.Fatalf("%s: %v", step.name, err)
+		}
+		if got := fake.data("A/www"); strings.Join(got, ",") != strings.Join(step.want, ",") {
+			t.Fatalf("%s: 记录集为 %v，应为 %v", step.name, got, step.want)
+		}
+	}
+
+	if err := p.DeleteRecord(ctx, zone, a); !errors.Is(err, ErrRecordNotFound) {
+		t.Fatalf("删除不存在的记录返回 %v", err)
+	}
+}
+
+func TestGoDaddyMovesRecordBetweenRRSets(t *testing.T) {
+	ctx := context.Background()
+	p, fake := newTestGoDaddy(t, "secret")
+	zone := Zone{Name: "example.com"}
+	old := Record{Name: "www", Type: "A", Value: "1.1.1.1", TTL: 600}
+
+	if _, err := p.CreateRecord(ctx, zone, old); err != nil {
+		t.Fatalf("创建记录失败: %v", err)
+	}
+	id, err := p.UpdateRecord(ctx, zone, old, Record{Name: "api", Type: "A", Value: "1.1.1.1", TTL: 600})
+	if err != nil {
+		t.Fatalf("更新记录失败: %v", err)
+	}
+	if !strings.HasPrefix(id, "A/api/") {
+		t.Fatalf("记录ID为 %q", id)
+	}
+	if got := fake.data("A/www"); len(got) != 0 {
+		t.Fatalf("旧记录集未删除: %v", got)
+	}
+	if got := fake.data("A/api"); len(got) != 1 {
+		t.Fatalf("新记录集为 %v", got)
+	}
+}
+
+func TestGoDaddyErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		secret string
+		zone   string
+		check  func(error) bool
+	}{
+		{"unknown domain", "secret", "missing.com", func(err error) bool { return errors.Is(err, ErrZoneNotFound) }},
+		{"bad credentials", "wrong", "example.com", func(err error) bool {
+			var apiErr *APIError
+			return errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusUnauthorized && apiErr.Code == "UNABLE_TO_AUTHENTICATE"
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, _ := newTestGoDaddy(t, tt.secret)
+			_, err := p.CreateRecord(context.Background(), Zone{Name: tt.zone}, Record{Name: "www", Type: "A", Value: "1.1.1.1", TTL: 600})
+			if !tt.check(err) {
+				t.Fatalf("返回的错误为 %v", err)
+			}
+		})
+	}
+}
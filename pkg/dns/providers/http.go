@@ -0,0 +1,78 @@
+package providers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// defaultHTTPTimeout 驱动HTTP客户端的默认超时时间
+const defaultHTTPTimeout = 30 * time.Second
+
+// newHTTPClient 创建驱动使用的HTTP客户端
+func newHTTPClient() *http.Client {
+	return &http.Client{Timeout: defaultHTTPTimeout}
+}
+
+// APIError DNS服务商接口返回的错误
+type APIError struct {
+	Provider   string // 服务商类型
+	StatusCode int    // HTTP状态码
+	Code       string // 服务商错误码
+	Message    string // 服务商错误信息
+}
+
+func (e *APIError) Error() string {
+	if e.Code != "" {
+		return fmt.Sprintf("%s接口错误(HTTP %d, 错误码 %s): %s", e.Provider, e.StatusCode, e.Code, e.Message)
+	}
+	return fmt.Sprintf("%s接口错误(HTTP %d): %s", e.Provider, e.StatusCode, e.Message)
+}
+
+// readBody 读取响应体，限制最大长度避免异常响应占用过多内存
+func readBody(resp *http.Response) ([]byte, error) {
+	defer resp.Body.Close()
+	return io.ReadAll(io.LimitReader(resp.Body, 10<<20))
+}
+
+// jsonBody 将请求体编码为JSON，body为nil时返回nil
+func jsonBody(body interface{}) (io.Reader, error) {
+	if body == nil {
+		return nil, nil
+	}
+	data, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+	return bytes.NewReader(data), nil
+}
+
+// flexString 兼容服务商接口中时而为数字、时而为字符串的字段
+type flexString string
+
+func (s *flexString) UnmarshalJSON(data []byte) error {
+	if len(data) > 0 && data[0] == '"' {
+		var str string
+		if err := json.Unmarshal(data, &str); err != nil {
+			return err
+		}
+		*s = flexString(str)
+		return nil
+	}
+	if string(data) == "null" {
+		*s = ""
+		return nil
+	}
+	*s = flexString(data)
+	return nil
+}
+
+// Int 将字段转换为整数，无法转换时返回0
+func (s flexString) Int() int {
+	n, _ := strconv.Atoi(string(s))
+	return n
+}
@@ -0,0 +1,309 @@
+package providers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	mockOnce sync.Once
+	mockDir  string
+)
+
+// RegisterMock 注册模拟服务商，只应在开发环境和测试中调用，重复调用时忽略
+//
+// dir为数据文件所在目录，配置中的数据文件只能是该目录下的JSON文件名；dir为空时不支持数据文件。
+func RegisterMock(dir string) {
+	mockOnce.Do(func() {
+		mockDir = dir
+		Register("mock", newMock)
+		RegisterSchema(Schema{
+			Type:        "mock",
+			Name:        "模拟服务商",
+			Description: "在内存中保存记录的模拟DNS服务商，用于开发和测试，支持注入延迟和错误",
+			Fields: []Field{
+				{Name: "file", Type: FieldString, Label: "数据文件名"},
+				{Name: "zones", Type: FieldString, Label: "预置区域"},
+				{Name: "latency_ms", Type: FieldInt, Label: "模拟延迟（毫秒）"},
+				{Name: "error_rate", Type: FieldInt, Label: "错误率（%）"},
+			},
+		})
+	})
+}
+
+// ErrMockInjected 模拟服务商按错误率注入的错误
+var ErrMockInjected = errors.New("模拟服务商注入的错误")
+
+// mockConfig 模拟服务商配置
+type mockConfig struct {
+	File      string      `json:"file"`       // 可选，数据目录下保存区域数据的JSON文件名，为空时只保存在内存中
+	Zones     string      `json:"zones"`      // 可选，预置的区域，多个用逗号分隔
+	LatencyMS json.Number `json:"latency_ms"` // 可选，每次请求的延迟
+	ErrorRate json.Number `json:"error_rate"` // 可选，请求失败的概率，取值0-100
+}
+
+// mockProvider 模拟服务商驱动
+//
+// 区域数据按数据文件共享，同一文件（或都未指定文件）的驱动实例读写同一份数据，
+// 因此每次请求重新创建驱动实例时数据不会丢失。写入区域中不存在的记录时自动创建区域。
+type mockProvider struct {
+	store     *mockStore
+	latency   time.Duration
+	errorRate int
+}
+
+// mockStore 模拟服务商的区域数据
+type mockStore struct {
+	mu     sync.Mutex
+	file   string
+	NextID int                 `json:"next_id"`
+	Zones  map[string][]Record `json:"zones"`
+}
+
+// mockStores 按数据文件缓存的区域数据
+var mockStores sync.Map
+
+func newMock(config []byte) (Provider, error) {
+	var cfg mockConfig
+	if err := json.Unmarshal(config, &cfg); err != nil {
+		return nil, fmt.Errorf("模拟服务商配置格式错误: %v", err)
+	}
+
+	p := &mockProvider{}
+	if cfg.LatencyMS != "" {
+		ms, err := cfg.LatencyMS.Int64()
+		if err != nil || ms < 0 {
+			return nil, errors.New("模拟服务商配置的latency_ms无效")
+		}
+		p.latency = time.Duration(ms) * time.Millisecond
+	}
+	if cfg.ErrorRate != "" {
+		rate, err := cfg.ErrorRate.Int64()
+		if err != nil || rate < 0 || rate > 100 {
+			return nil, errors.New("模拟服务商配置的error_rate必须在0-100之间")
+		}
+		p.errorRate = int(rate)
+	}
+
+	store, err := loadMockStore(cfg.File)
+	if err != nil {
+		return nil, err
+	}
+	p.store = store
+
+	for _, name := range strings.Split(cfg.Zones, ",") {
+		if name = mockZoneName(name); name != "" {
+			if err := store.ensureZone(name); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return p, nil
+}
+
+// loadMockStore 返回数据文件对应的区域数据，首次使用时从文件读取
+func loadMockStore(name string) (*mockStore, error) {
+	file, err := mockFile(name)
+	if err != nil {
+		return nil, err
+	}
+	if cached, ok := mockStores.Load(file); ok {
+		return cached.(*mockStore), nil
+	}
+
+	store := &mockStore{file: file, Zones: make(map[string][]Record)}
+	if file != "" {
+		data, err := os.ReadFile(file)
+		if err != nil && !os.IsNotExist(err) {
+			return nil, fmt.Errorf("读取模拟服务商数据文件失败: %v", err)
+		}
+		if len(data) > 0 {
+			if err := json.Unmarshal(data, store); err != nil {
+				return nil, fmt.Errorf("模拟服务商数据文件格式错误: %v", err)
+			}
+			if store.Zones == nil {
+				store.Zones = make(map[string][]Record)
+			}
+		}
+	}
+
+	actual, _ := mockStores.LoadOrStore(file, store)
+	return actual.(*mockStore), nil
+}
+
+// ensureZone 创建不存在的区域
+func (s *mockStore) ensureZone(name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.Zones[name]; ok {
+		return nil
+	}
+	s.Zones[name] = []Record{}
+	return s.save()
+}
+
+// save 将区域数据写入数据文件，调用方需持有锁
+func (s *mockStore) save() error {
+	if s.file == "" {
+		return nil
+	}
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := s.file + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return fmt.Errorf("写入模拟服务商数据文件失败: %v", err)
+	}
+	if err := os.Rename(tmp, s.file); err != nil {
+		return fmt.Errorf("写入模拟服务商数据文件失败: %v", err)
+	}
+	return nil
+}
+
+// mockFile 返回数据文件的路径，文件只能位于RegisterMock指定的数据目录下
+func mockFile(name string) (string, error) {
+	if name == "" {
+		return "", nil
+	}
+	if mockDir == "" {
+		return "", errors.New("模拟服务商未设置数据目录，不支持数据文件")
+	}
+	if filepath.Base(name) != name || !strings.HasSuffix(name, ".json") || name == ".json" {
+		return "", errors.New("模拟服务商数据文件只能是不含路径的JSON文件名，如 zones.json")
+	}
+	if err := os.MkdirAll(mockDir, 0700); err != nil {
+		return "", fmt.Errorf("创建模拟服务商数据目录失败: %v", err)
+	}
+	dir, err := filepath.Abs(mockDir)
+	if err != nil {
+		return "", fmt.Errorf("模拟服务商数据目录无效: %v", err)
+	}
+	return filepath.Join(dir, name), nil
+}
+
+// inject 按配置注入延迟和错误
+func (p *mockProvider) inject(ctx context.Context) error {
+	if p.latency > 0 {
+		timer := time.NewTimer(p.latency)
+		defer timer.Stop()
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+	if p.errorRate > 0 && rand.Intn(100) < p.errorRate {
+		return ErrMockInjected
+	}
+	return nil
+}
+
+func (p *mockProvider) ListZones(ctx context.Context) ([]Zone, error) {
+	if err := p.inject(ctx); err != nil {
+		return nil, err
+	}
+
+	p.store.mu.Lock()
+	defer p.store.mu.Unlock()
+
+	zones := make([]Zone, 0, len(p.store.Zones))
+	for name := range p.store.Zones {
+		zones = append(zones, Zone{ID: name, Name: name})
+	}
+	sort.Slice(zones, func(i, j int) bool { return zones[i].Name < zones[j].Name })
+	return zones, nil
+}
+
+func (p *mockProvider) ListRecords(ctx context.Context, zone Zone) ([]Record, error) {
+	if err := p.inject(ctx); err != nil {
+		return nil, err
+	}
+
+	p.store.mu.Lock()
+	defer p.store.mu.Unlock()
+
+	records, ok := p.store.Zones[mockZoneName(zone.Name)]
+	if !ok {
+		return nil, ErrZoneNotFound
+	}
+	return append([]Record(nil), records...), nil
+}
+
+func (p *mockProvider) CreateRecord(ctx context.Context, zone Zone, record Record) (string, error) {
+	if err := p.inject(ctx); err != nil {
+		return "", err
+	}
+
+	p.store.mu.Lock()
+	defer p.store.mu.Unlock()
+
+	name := mockZoneName(zone.Name)
+	p.store.NextID++
+	record.ID = fmt.Sprintf("mock-%d", p.store.NextID)
+	p.store.Zones[name] = append(p.store.Zones[name], record)
+	if err := p.store.save(); err != nil {
+		return "", err
+	}
+	return record.ID, nil
+}
+
+func (p *mockProvider) UpdateRecord(ctx context.Context, zone Zone, old, record Record) (string, error) {
+	if err := p.inject(ctx); err != nil {
+		return "", err
+	}
+
+	p.store.mu.Lock()
+	defer p.store.mu.Unlock()
+
+	records := p.store.Zones[mockZoneName(zone.Name)]
+	for i := range records {
+		if records[i].ID == old.ID {
+			record.ID = old.ID
+			records[i] = record
+			if err := p.store.save(); err != nil {
+				return "", err
+			}
+			return record.ID, nil
+		}
+	}
+	return "", ErrRecordNotFound
+}
+
+func (p *mockProvider) DeleteRecord(ctx context.Context, zone Zone, record Record) error {
+	if err := p.inject(ctx); err != nil {
+		return err
+	}
+
+	p.store.mu.Lock()
+	defer p.store.mu.Unlock()
+
+	name := mockZoneName(zone.Name)
+	records := p.store.Zones[name]
+	for i := range records {
+		if records[i].ID == record.ID {
+			p.store.Zones[name] = append(records[:i:i], records[i+1:]...)
+			return p.store.save()
+		}
+	}
+	return ErrRecordNotFound
+}
+
+func (p *mockProvider) Test(ctx context.Context) error {
+	return p.inject(ctx)
+}
+
+// mockZoneName 统一区域名称的大小写和结尾的点
+func mockZoneName(name string) string {
+	return strings.TrimSuffix(strings.ToLower(strings.TrimSpace(name)), ".")
+}
@@ -0,0 +1,371 @@
+package providers
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// powerdnsDefaultServerID PowerDNS默认服务器ID
+const powerdnsDefaultServerID = "localhost"
+
+func init() {
+	Register("powerdns", newPowerDNS)
+	RegisterSchema(Schema{
+		Type:        "powerdns",
+		Name:        "PowerDNS",
+		Description: "PowerDNS权威服务器HTTP API",
+		Fields: []Field{
+			{Name: "api_url", Type: FieldString, Label: "API地址", Required: true, Format: FormatURL},
+			{Name: "api_key", Type: FieldString, Label: "API Key", Required: true, Secret: true},
+			{Name: "server_id", Type: FieldString, Label: "服务器ID"},
+		},
+	})
+}
+
+// powerdnsConfig PowerDNS配置
+type powerdnsConfig struct {
+	APIURL   string `json:"api_url"`   // 接口地址，如 http://127.0.0.1:8081
+	APIKey   string `json:"api_key"`   // 对应PowerDNS的api-key配置
+	ServerID string `json:"server_id"` // 服务器ID，默认localhost
+}
+
+// powerdnsProvider PowerDNS权威服务器HTTP API驱动
+//
+// PowerDNS按"名称+类型"整体替换记录集（RRset），记录没有独立ID，
+// 写入由rrsetEditor读取所在记录集并在本地合并，再通过PATCH整体提交。
+type powerdnsProvider struct {
+	apiURL   string
+	apiKey   string
+	serverID string
+	client   *http.Client
+}
+
+func newPowerDNS(config []byte) (Provider, error) {
+	var cfg powerdnsConfig
+	if err := json.Unmarshal(config, &cfg); err != nil {
+		return nil, fmt.Errorf("PowerDNS配置格式错误: %v", err)
+	}
+	if cfg.APIURL == "" || cfg.APIKey == "" {
+		return nil, errors.New("PowerDNS配置缺少api_url或api_key")
+	}
+	if cfg.ServerID == "" {
+		cfg.ServerID = powerdnsDefaultServerID
+	}
+
+	apiURL := strings.TrimSuffix(cfg.APIURL, "/")
+	apiURL = strings.TrimSuffix(apiURL, "/api/v1")
+
+	return &powerdnsProvider{
+		apiURL:   apiURL,
+		apiKey:   cfg.APIKey,
+		serverID: cfg.ServerID,
+		client:   newHTTPClient(),
+	}, nil
+}
+
+// powerdnsRRSet PowerDNS记录集
+type powerdnsRRSet struct {
+	Name       string              `json:"name"`
+	Type       string              `json:"type"`
+	TTL        int                 `json:"ttl,omitempty"`
+	ChangeType string              `json:"changetype,omitempty"`
+	Records    []powerdnsRRSetItem `json:"records"`
+}
+
+// powerdnsRRSetItem 记录集中的单条记录
+type powerdnsRRSetItem struct {
+	Content  string `json:"content"`
+	Disabled bool   `json:"disabled"`
+}
+
+// powerdnsZone PowerDNS区域
+type powerdnsZone struct {
+	ID     string          `json:"id"`
+	Name   string          `json:"name"`
+	RRSets []powerdnsRRSet `json:"rrsets"`
+}
+
+// do 调用PowerDNS接口，path相对于/api/v1/servers/{server_id}
+func (p *powerdnsProvider) do(ctx context.Context, method, path string, body, out interface{}) error {
+	reqBody, err := jsonBody(body)
+	if err != nil {
+		return err
+	}
+	reqURL := p.apiURL + "/api/v1/servers/" + url.PathEscape(p.serverID) + path
+	req, err := http.NewRequestWithContext(ctx, method, reqURL, reqBody)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("X-API-Key", p.apiKey)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("请求PowerDNS失败: %v", err)
+	}
+	data, err := readBody(resp)
+	if err != nil {
+		return fmt.Errorf("读取PowerDNS响应失败: %v", err)
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		var result struct {
+			Error string `json:"error"`
+		}
+		json.Unmarshal(data, &result)
+		if result.Error == "" {
+			result.Error = http.StatusText(resp.StatusCode)
+		}
+		// 区域不存在时返回404，部分版本返回422 "Could not find domain"
+		if resp.StatusCode == http.StatusNotFound || strings.Contains(result.Error, "Could not find domain") {
+			return fmt.Errorf("%w: %s", ErrZoneNotFound, result.Error)
+		}
+		return &APIError{Provider: "PowerDNS", StatusCode: resp.StatusCode, Message: result.Error}
+	}
+
+	if out != nil && len(data) > 0 {
+		if err := json.Unmarshal(data, out); err != nil {
+			return fmt.Errorf("解析PowerDNS响应失败: %v", err)
+		}
+	}
+	return nil
+}
+
+// zonePath 返回区域接口路径，区域ID为空时使用带结尾点的域名
+func (p *powerdnsProvider) zonePath(zone Zone) string {
+	zoneID := zone.ID
+	if zoneID == "" {
+		zoneID = strings.TrimSuffix(zone.Name, ".") + "."
+	}
+	return "/zones/" + url.PathEscape(zoneID)
+}
+
+// ListZones 列出服务器上的全部区域
+func (p *powerdnsProvider) ListZones(ctx context.Context) ([]Zone, error) {
+	var result []powerdnsZone
+	if err := p.do(ctx, http.MethodGet, "/zones", nil, &result); err != nil {
+		return nil, err
+	}
+
+	zones := make([]Zone, 0, len(result))
+	for _, z := range result {
+		zones = append(zones, Zone{ID: z.ID, Name: strings.TrimSuffix(z.Name, ".")})
+	}
+	return zones, nil
+}
+
+// ListRecords 列出区域内的全部记录，忽略由服务器维护的SOA和根域名NS记录
+func (p *powerdnsProvider) ListRecords(ctx context.Context, zone Zone) ([]Record, error) {
+	var result powerdnsZone
+	if err := p.do(ctx, http.MethodGet, p.zonePath(zone), nil, &result); err != nil {
+		return nil, err
+	}
+
+	var records []Record
+	for _, set := range result.RRSets {
+		name := RelativeName(set.Name, zone.Name)
+		if set.Type == "SOA" || (set.Type == "NS" && name == "@") {
+			continue
+		}
+		for _, item := range set.Records {
+			if item.Disabled {
+				continue
+			}
+			records = append(records, powerdnsToRecord(name, set.Type, set.TTL, item.Content))
+		}
+	}
+	return records, nil
+}
+
+// CreateRecord 将记录合并到所在的记录集中
+func (p *powerdnsProvider) CreateRecord(ctx context.Context, zone Zone, record Record) (string, error) {
+	return p.rrsets().create(ctx, zone, record)
+}
+
+// UpdateRecord 在记录集中替换旧记录
+func (p *powerdnsProvider) UpdateRecord(ctx context.Context, zone Zone, old, record Record) (string, error) {
+	return p.rrsets().update(ctx, zone, old, record)
+}
+
+// DeleteRecord 从记录集中移除记录
+func (p *powerdnsProvider) DeleteRecord(ctx context.Context, zone Zone, record Record) error {
+	return p.rrsets().delete(ctx, zone, record)
+}
+
+// Test 查询服务器信息，验证接口地址和API Key
+func (p *powerdnsProvider) Test(ctx context.Context) error {
+	return p.do(ctx, http.MethodGet, "", nil, nil)
+}
+
+// rrsets 返回按记录集写入记录的编辑器，记录内容相同（忽略大小写）即为同一条记录
+func (p *powerdnsProvider) rrsets() rrsetEditor[powerdnsRRSetItem] {
+	return rrsetEditor[powerdnsRRSetItem]{
+		api: p,
+		newItem: func(record Record) (powerdnsRRSetItem, error) {
+			content, err := powerdnsContent(record)
+			return powerdnsRRSetItem{Content: content}, err
+		},
+		itemKey: func(item powerdnsRRSetItem) string {
+			return strings.ToLower(item.Content)
+		},
+		itemID: func(record Record, item powerdnsRRSetItem) string {
+			return powerdnsRecordID(record.Type, record.Name, item.Content)
+		},
+	}
+}
+
+// powerdnsRRSetName 返回记录所在记录集的名称，为带结尾点的小写完整域名
+func powerdnsRRSetName(zone Zone, record Record) string {
+	return strings.ToLower(FQDN(record.Name, zone.Name)) + "."
+}
+
+// rrsetKey 返回记录所在记录集的唯一标识
+func (p *powerdnsProvider) rrsetKey(zone Zone, record Record) string {
+	return "powerdns|" + p.apiURL + "|" + p.serverID + "|" + powerdnsRRSetName(zone, record) + "|" + strings.ToUpper(record.Type)
+}
+
+// readRRSet 读取区域并返回记录所在记录集中的记录，保留记录的禁用状态
+func (p *powerdnsProvider) readRRSet(ctx context.Context, zone Zone, record Record) ([]powerdnsRRSetItem, error) {
+	var current powerdnsZone
+	if err := p.do(ctx, http.MethodGet, p.zonePath(zone), nil, &current); err != nil {
+		return nil, err
+	}
+
+	name, recordType := powerdnsRRSetName(zone, record), strings.ToUpper(record.Type)
+	var items []powerdnsRRSetItem
+	for _, set := range current.RRSets {
+		if strings.EqualFold(set.Name, name) && set.Type == recordType {
+			items = append(items, set.Records...)
+		}
+	}
+	return items, nil
+}
+
+// writeRRSet 通过PATCH整体替换记录集，记录集的TTL取本次写入记录的TTL，记录集为空时删除
+func (p *powerdnsProvider) writeRRSet(ctx context.Context, zone Zone, record Record, items []powerdnsRRSetItem) error {
+	rrset := powerdnsRRSet{
+		Name:       powerdnsRRSetName(zone, record),
+		Type:       strings.ToUpper(record.Type),
+		TTL:        record.TTL,
+		ChangeType: "REPLACE",
+		Records:    items,
+	}
+	if len(items) == 0 {
+		rrset.ChangeType = "DELETE"
+		rrset.TTL = 0
+		rrset.Records = []powerdnsRRSetItem{}
+	} else if rrset.TTL <= 0 {
+		rrset.TTL = 600
+	}
+
+	body := map[string]interface{}{"rrsets": []powerdnsRRSet{rrset}}
+	return p.do(ctx, http.MethodPatch, p.zonePath(zone), body, nil)
+}
+
+// powerdnsContent 将驱动记录转换为PowerDNS的记录内容，主机名需要以点结尾，TXT记录需要加引号
+func powerdnsContent(record Record) (string, error) {
+	switch strings.ToUpper(record.Type) {
+	case "CNAME", "NS", "PTR":
+		return powerdnsFqdn(record.Value), nil
+	case "MX":
+		return fmt.Sprintf("%d %s", record.Priority, powerdnsFqdn(record.Value)), nil
+	case "SRV":
+		return fmt.Sprintf("%d %d %d %s", record.Priority, record.Weight, record.Port, powerdnsFqdn(record.Value)), nil
+	case "TXT":
+		return powerdnsQuote(record.Value), nil
+	case "CAA":
+		flags, tag, value, err := parseCAAValue(record.Value)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("%d %s %s", flags, tag, powerdnsQuote(value)), nil
+	default:
+		return record.Value, nil
+	}
+}
+
+// powerdnsToRecord 将记录集中的一条记录转换为驱动记录
+func powerdnsToRecord(name, recordType string, ttl int, content string) Record {
+	record := Record{
+		ID:    powerdnsRecordID(recordType, name, content),
+		Name:  name,
+		Type:  strings.ToUpper(recordType),
+		Value: content,
+		TTL:   ttl,
+	}
+
+	switch record.Type {
+	case "MX":
+		if parts := strings.Fields(content); len(parts) == 2 {
+			record.Priority, _ = strconv.Atoi(parts[0])
+			record.Value = parts[1]
+		}
+	case "SRV":
+		splitSRVValue(&record, content)
+	case "TXT":
+		record.Value = powerdnsUnquote(content)
+	case "CAA":
+		if flags, tag, value, err := parseCAAValue(content); err == nil {
+			record.Value = fmt.Sprintf("%d %s %s", flags, tag, powerdnsUnquote(value))
+		}
+	}
+	record.Value = normalizeValue(record.Type, record.Value)
+	return record
+}
+
+// powerdnsRecordID 根据记录名称、类型和内容生成稳定的记录ID，格式为"类型/名称/内容摘要"
+func powerdnsRecordID(recordType, name, content string) string {
+	if name == "" {
+		name = "@"
+	}
+	sum := sha256.Sum256([]byte(strings.ToLower(content)))
+	return strings.ToUpper(recordType) + "/" + strings.ToLower(name) + "/" + hex.EncodeToString(sum[:8])
+}
+
+// powerdnsFqdn 为主机名补全结尾的点
+func powerdnsFqdn(host string) string {
+	if strings.HasSuffix(host, ".") {
+		return host
+	}
+	return host + "."
+}
+
+// powerdnsQuote 按区域文件格式为字符串加引号，仅转义引号和反斜杠
+func powerdnsQuote(value string) string {
+	value = strings.ReplaceAll(value, `\`, `\\`)
+	value = strings.ReplaceAll(value, `"`, `\"`)
+	return `"` + value + `"`
+}
+
+// powerdnsUnquote 去掉区域文件格式字符串的引号，多段字符串直接拼接
+func powerdnsUnquote(value string) string {
+	if !strings.HasPrefix(value, `"`) {
+		return value
+	}
+
+	var b strings.Builder
+	inQuote, escaped := false, false
+	for _, r := range value {
+		switch {
+		case escaped:
+			b.WriteRune(r)
+			escaped = false
+		case r == '\\' && inQuote:
+			escaped = true
+		case r == '"':
+			inQuote = !inQuote
+		case inQuote:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,201 @@
+package providers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakePowerDNS 模拟PowerDNS区域接口，只有example.com一个区域
+type fakePowerDNS struct {
+	mu     sync.Mutex
+	rrsets map[string]powerdnsRRSet // 按"名称|类型"保存
+}
+
+func (f *fakePowerDNS) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("X-API-Key") != "secret" {
+		w.WriteHeader(http.StatusUnauthorized)
+		w.Write([]byte(`{"error":"Unauthorized"}`))
+		return
+	}
+	if r.URL.Path != "/api/v1/servers/localhost/zones/example.com." {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"error":"Not Found"}`))
+		return
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	switch r.Method {
+	case http.MethodGet:
+		zone := powerdnsZone{ID: "example.com.", Name: "example.com."}
+		for _, set := range f.rrsets {
+			zone.RRSets = append(zone.RRSets, set)
+		}
+		json.NewEncoder(w).Encode(zone)
+	case http.MethodPatch:
+		var body struct {
+			RRSets []powerdnsRRSet `json:"rrsets"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		for _, set := range body.RRSets {
+			key := set.Name + "|" + set.Type
+			switch set.ChangeType {
+			case "REPLACE":
+				if set.TTL <= 0 || len(set.Records) == 0 {
+					w.WriteHeader(http.StatusUnprocessableEntity)
+					w.Write([]byte(`{"error":"invalid rrset"}`))
+					return
+				}
+				set.ChangeType = ""
+				f.rrsets[key] = set
+			case "DELETE":
+				delete(f.rrsets, key)
+			}
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// contents 返回记录集中的记录内容，按字母排序
+func (f *fakePowerDNS) contents(key string) []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var values []string
+	for _, item := range f.rrsets[key].Records {
+		values = append(values, item.Content)
+	}
+	sort.Strings(values)
+	return values
+}
+
+func newTestPowerDNS(t *testing.T, apiKey string) (Provider, *fakePowerDNS) {
+	t.Helper()
+
+	fake := &fakePowerDNS{rrsets: map[string]powerdnsRRSet{
+		"example.com.|SOA": {Name: "example.com.", Type: "SOA", TTL: 3600, Records: []powerdnsRRSetItem{{Content: "ns1.example.com. admin.example.com. 1 10800 3600 604800 3600"}}},
+		"example.com.|NS":  {Name: "example.com.", Type: "NS", TTL: 3600, Records: []powerdnsRRSetItem{{Content: "ns1.example.com."}}},
+	}}
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+
+	config, _ := json.Marshal(powerdnsConfig{APIURL: server.URL + "/api/v1", APIKey: apiKey})
+	p, err := newPowerDNS(config)
+	if err != nil {
+		t.Fatalf("创建驱动失败: %v", err)
+	}
+	return p, fake
+}
+
+func TestPowerDNSMergesRRSet(t *testing.T) {
+	ctx := context.Background()
+	p, fake := newTestPowerDNS(t, "secret")
+	zone := Zone{Name: "example.com"}
+	a := Record{Name: "www", Type: "A", Value: "1.1.1.1", TTL: 600}
+	b := Record{Name: "WWW", Type: "a", Value: "2.2.2.2", TTL: 600}
+
+	steps := []struct {
+		name string
+		run  func() error
+		want []string
+	}{
+		{"create first", func() error { _, err := p.CreateRecord(ctx, zone, a); return err }, []string{"1.1.1.1"}},
+		{"create second", func() error { _, err := p.CreateRecord(ctx, zone, b); return err }, []string{"1.1.1.1", "2.2.2.2"}},
+		{"create duplicate", func() error { _, err := p.CreateRecord(ctx, zone, a); return err }, []string{"1.1.1.1", "2.2.2.2"}},
+		{"update", func() error {
+			_, err := p.UpdateRecord(ctx, zone, a, Record{Name: "www", Type: "A", Value: "3.3.3.3", TTL: 600})
+			return err
+		}, []string{"2.2.2.2", "3.3.3.3"}},
+		{"delete", func() error { return p.DeleteRecord(ctx, zone, b) }, []string{"3.3.3.3"}},
+		{"delete last", func() error {
+			return p.DeleteRecord(ctx, zone, Record{Name: "www", Type: "A", Value: "3.3.3.3"})
+		}, nil},
+	}
+
+	for _, step := range steps {
+		if err := step.run(); err != nil {
+			t.Fatalf("%s: %v", step.name, err)
+		}
+		if got := fake.contents("www.example.com.|A"); strings.Join(got, ",") != strings.Join(step.want, ",") {
+			t.Fatalf("%s: 记录集为 %v，应为 %v", step.name, got, step.want)
+		}
+	}
+
+	if err := p.DeleteRecord(ctx, zone, a); !errors.Is(err, ErrRecordNotFound) {
+		t.Fatalf("删除不存在的记录返回 %v", err)
+	}
+}
+
+func TestPowerDNSRecordContent(t *testing.T) {
+	ctx := context.Background()
+	p, fake := newTestPowerDNS(t, "secret")
+	zone := Zone{Name: "example.com"}
+
+	tests := []struct {
+		record  Record
+		key     string
+		content string
+	}{
+		{Record{Name: "@", Type: "MX", Value: "mail.example.com", Priority: 10, TTL: 600}, "example.com.|MX", "10 mail.example.com."},
+		{Record{Name: "txt", Type: "TXT", Value: `say "hi" \o/`, TTL: 600}, "txt.example.com.|TXT", `"say \"hi\" \\o/"`},
+		{Record{Name: "@", Type: "CAA", Value: `0 issue "letsencrypt.org"`, TTL: 600}, "example.com.|CAA", `0 issue "letsencrypt.org"`},
+	}
+
+	for _, tt := range tests {
+		if _, err := p.CreateRecord(ctx, zone, tt.record); err != nil {
+			t.Fatalf("创建 %s 记录失败: %v", tt.record.Type, err)
+		}
+		if got := fake.contents(tt.key); len(got) != 1 || got[0] != tt.content {
+			t.Fatalf("%s 记录内容为 %v，应为 %q", tt.record.Type, got, tt.content)
+		}
+	}
+
+	// 读取时忽略SOA和根域名NS记录，并还原记录值
+	records, err := p.ListRecords(ctx, zone)
+	if err != nil {
+		t.Fatalf("读取记录失败: %v", err)
+	}
+	if len(records) != len(tests) {
+		t.Fatalf("读取的记录为 %+v", records)
+	}
+	for _, r := range records {
+		if r.Type == "TXT" && r.Value != `say "hi" \o/` {
+			t.Fatalf("TXT记录值为 %q", r.Value)
+		}
+	}
+}
+
+func TestPowerDNSErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		apiKey string
+		zone   string
+		check  func(error) bool
+	}{
+		{"unknown zone", "secret", "missing.com", func(err error) bool { return errors.Is(err, ErrZoneNotFound) }},
+		{"bad api key", "wrong", "example.com", func(err error) bool {
+			var apiErr *APIError
+			return errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusUnauthorized
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, _ := newTestPowerDNS(t, tt.apiKey)
+			_, err := p.CreateRecord(context.Background(), Zone{Name: tt.zone}, Record{Name: "www", Type: "A", Value: "1.1.1.1", TTL: 600})
+			if !tt.check(err) {
+				t.Fatalf("返回的错误为 %v", err)
+			}
+		})
+	}
+}
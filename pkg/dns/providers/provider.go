@@ -0,0 +1,66 @@
+package providers
+
+import (
+	"context"
+	"errors"
+)
+
+// 通用错误
+var (
+	ErrUnsupportedType = errors.New("不支持的DNS服务商类型")
+	ErrZoneNotFound    = errors.New("DNS服务商中不存在该域名")
+	ErrRecordNotFound  = errors.New("DNS服务商中不存在该记录")
+)
+
+// Zone DNS服务商中的托管域名（区域）
+type Zone struct {
+	ID   string `json:"id"`   // 服务商侧的区域ID，部分服务商为空
+	Name string `json:"name"` // 域名，如 example.com
+}
+
+// Record DNS服务商中的解析记录
+type Record struct {
+	ID       string `json:"id"`       // 服务商记录ID
+	Name     string `json:"name"`     // 子域名，"@"表示根域名
+	Type     string `json:"type"`     // 记录类型
+	Value    string `json:"value"`    // 记录内容，MX/SRV记录为目标主机
+	TTL      int    `json:"ttl"`      // 生存时间
+	Priority int    `json:"priority"` // MX和SRV记录优先级
+	Weight   int    `json:"weight"`   // SRV记录权重
+	Port     int    `json:"port"`     // SRV记录端口
+	Proxied  bool   `json:"proxied"`  // 是否开启CDN代理（仅Cloudflare支持）
+}
+
+// Provider DNS服务商驱动接口
+//
+// 所有方法都应是无状态的，驱动实例可以在多个goroutine中并发使用。
+type Provider interface {
+	// ListZones 列出账户下托管的全部域名
+	ListZones(ctx context.Context) ([]Zone, error)
+
+	// ListRecords 列出域名下的全部解析记录
+	ListRecords(ctx context.Context, zone Zone) ([]Record, error)
+
+	// CreateRecord 创建解析记录，返回服务商记录ID
+	CreateRecord(ctx context.Context, zone Zone, record Record) (string, error)
+
+	// UpdateRecord 将旧记录更新为新记录，返回更新后的服务商记录ID
+	UpdateRecord(ctx context.Context, zone Zone, old, record Record) (string, error)
+
+	// DeleteRecord 删除解析记录
+	DeleteRecord(ctx context.Context, zone Zone, record Record) error
+
+	// Test 使用只读请求验证凭据是否有效
+	Test(ctx context.Context) error
+}
+
+// ProxySupporter 支持CDN代理的驱动实现该接口，ListRecords返回的记录带有代理状态
+type ProxySupporter interface {
+	SupportsProxy() bool
+}
+
+// SupportsProxy 驱动是否支持CDN代理，不支持的驱动读取的记录Proxied始终为false
+func SupportsProxy(p Provider) bool {
+	s, ok := p.(ProxySupporter)
+	return ok && s.SupportsProxy()
+}
@@ -0,0 +1,105 @@
+package providers
+
+import (
+	"domain-max/pkg/dns/models"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// FromDNSRecord 将本地DNS记录转换为驱动记录
+//
+// 本地MX记录值格式为"优先级 主机"，SRV记录值格式为"优先级 权重 端口 目标"，
+// 转换时拆分为结构化字段，Value只保留目标主机。
+func FromDNSRecord(r *models.DNSRecord) Record {
+	record := Record{
+		ID:       r.ExternalID,
+		Name:     r.Subdomain,
+		Type:     strings.ToUpper(r.Type),
+		Value:    r.Value,
+		TTL:      r.TTL,
+		Priority: r.Priority,
+		Weight:   r.Weight,
+		Port:     r.Port,
+		Proxied:  r.Proxied,
+	}
+
+	parts := strings.Fields(r.Value)
+	switch record.Type {
+	case "MX":
+		if len(parts) == 2 {
+			record.Priority, _ = strconv.Atoi(parts[0])
+			record.Value = parts[1]
+		}
+	case "SRV":
+		splitSRVValue(&record, r.Value)
+	}
+
+	return record
+}
+
+// splitSRVValue 将"优先级 权重 端口 目标"格式的SRV记录值拆分到记录字段中
+func splitSRVValue(record *Record, value string) {
+	parts := strings.Fields(value)
+	if len(parts) != 4 {
+		return
+	}
+	record.Priority, _ = strconv.Atoi(parts[0])
+	record.Weight, _ = strconv.Atoi(parts[1])
+	record.Port, _ = strconv.Atoi(parts[2])
+	record.Value = parts[3]
+}
+
+// ApplyToDNSRecord 将驱动记录写回本地DNS记录
+func ApplyToDNSRecord(record Record, r *models.DNSRecord) {
+	r.ExternalID = record.ID
+	r.Subdomain = record.Name
+	r.Type = strings.ToUpper(record.Type)
+	r.Value = RecordValue(record)
+	r.TTL = record.TTL
+	r.Priority = record.Priority
+	r.Weight = record.Weight
+	r.Port = record.Port
+	r.Proxied = record.Proxied
+}
+
+// RecordValue 返回驱动记录对应的本地记录值
+func RecordValue(record Record) string {
+	switch strings.ToUpper(record.Type) {
+	case "MX":
+		return fmt.Sprintf("%d %s", record.Priority, record.Value)
+	case "SRV":
+		return fmt.Sprintf("%d %d %d %s", record.Priority, record.Weight, record.Port, record.Value)
+	default:
+		return record.Value
+	}
+}
+
+// normalizeValue 规范化服务商返回的记录值，去掉主机名结尾的点
+func normalizeValue(recordType, value string) string {
+	switch strings.ToUpper(recordType) {
+	case "CNAME", "MX", "NS", "PTR", "SRV":
+		return strings.TrimSuffix(value, ".")
+	default:
+		return value
+	}
+}
+
+// FQDN 将子域名转换为完整域名（不含结尾的点）
+func FQDN(name, zone string) string {
+	zone = strings.TrimSuffix(zone, ".")
+	if name == "" || name == "@" {
+		return zone
+	}
+	return name + "." + zone
+}
+
+// RelativeName 将完整域名转换为相对于区域的子域名，根域名返回"@"
+func RelativeName(fqdn, zone string) string {
+	fqdn = strings.TrimSuffix(strings.ToLower(fqdn), ".")
+	zone = strings.TrimSuffix(strings.ToLower(zone), ".")
+	if fqdn == zone {
+		return "@"
+	}
+	return strings.TrimSuffix(fqdn, "."+zone)
+}
@@ -0,0 +1,105 @@
+package providers
+
+import (
+	"domain-max/pkg/dns/models"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// Factory 根据JSON格式的配置创建驱动实例
+type Factory func(config []byte) (Provider, error)
+
+var (
+	registryMu sync.RWMutex
+	registry   = make(map[string]Factory)
+	defaults   = make(map[string]map[string]string)
+)
+
+// Register 注册DNS服务商驱动，类型与 DNSProvider.Type 对应
+func Register(providerType string, factory Factory) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	if factory == nil {
+		panic("providers: 驱动工厂不能为空")
+	}
+	if _, exists := registry[providerType]; exists {
+		panic("providers: 重复注册驱动 " + providerType)
+	}
+	registry[providerType] = factory
+}
+
+// SetDefaults 设置服务商配置的默认值，配置中缺少或为空的字段将使用默认值
+func SetDefaults(providerType string, values map[string]string) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	filtered := make(map[string]string, len(values))
+	for k, v := range values {
+		if v != "" {
+			filtered[k] = v
+		}
+	}
+	defaults[providerType] = filtered
+}
+
+// Types 返回已注册的服务商类型
+func Types() []string {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	types := make([]string, 0, len(registry))
+	for t := range registry {
+		types = append(types, t)
+	}
+	sort.Strings(types)
+	return types
+}
+
+// New 根据服务商类型和配置创建驱动实例
+func New(providerType string, config []byte) (Provider, error) {
+	registryMu.RLock()
+	factory, ok := registry[providerType]
+	values := defaults[providerType]
+	registryMu.RUnlock()
+
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedType, providerType)
+	}
+
+	if len(values) > 0 {
+		merged, err := applyDefaults(config, values)
+		if err != nil {
+			return nil, err
+		}
+		config = merged
+	}
+	return factory(config)
+}
+
+// applyDefaults 将默认值合并到JSON配置中
+func applyDefaults(config []byte, values map[string]string) ([]byte, error) {
+	fields := make(map[string]interface{})
+	if len(config) > 0 {
+		if err := json.Unmarshal(config, &fields); err != nil {
+			return nil, fmt.Errorf("服务商配置格式错误: %v", err)
+		}
+	}
+	for k, v := range values {
+		if current, ok := fields[k]; !ok || current == nil || current == "" {
+			fields[k] = v
+		}
+	}
+	return json.Marshal(fields)
+}
+
+// Open 根据数据库中的服务商配置创建驱动实例，加密保存的敏感字段在此解密
+func Open(provider *models.DNSProvider) (Provider, error) {
+	config, err := DecryptConfig(provider.Type, provider.Config)
+	if err != nil {
+		return nil, err
+	}
+	return New(provider.Type, []byte(config))
+}
@@ -0,0 +1,426 @@
+package providers
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/miekg/dns"
+)
+
+// rfc2136DefaultPort DNS服务默认端口
+const rfc2136DefaultPort = "53"
+
+// rfc2136TsigFudge TSIG签名允许的时间偏差（秒）
+const rfc2136TsigFudge = 300
+
+func init() {
+	Register("rfc2136", newRFC2136)
+	RegisterSchema(Schema{
+		Type:        "rfc2136",
+		Name:        "RFC 2136",
+		Description: "支持动态更新的自建DNS服务器（BIND、Knot等）",
+		Fields: []Field{
+			{Name: "server", Type: FieldString, Label: "服务器地址", Required: true, Format: FormatHostPort},
+			{Name: "zone", Type: FieldString, Label: "区域", Format: FormatHostname},
+			{Name: "tsig_key_name", Type: FieldString, Label: "TSIG密钥名称", Format: FormatHostname},
+			{Name: "tsig_algorithm", Type: FieldString, Label: "TSIG算法"},
+			{Name: "tsig_secret", Type: FieldString, Label: "TSIG密钥", Secret: true, Format: FormatBase64},
+		},
+	})
+}
+
+// rfc2136Config RFC 2136动态更新配置
+type rfc2136Config struct {
+	Server        string `json:"server"`         // DNS服务器地址，如 ns1.example.com:53
+	Zone          string `json:"zone"`           // 更新的区域，为空时使用域名本身
+	TSIGKeyName   string `json:"tsig_key_name"`  // TSIG密钥名称
+	TSIGAlgorithm string `json:"tsig_algorithm"` // TSIG算法，默认hmac-sha256
+	TSIGSecret    string `json:"tsig_secret"`    // Base64编码的TSIG密钥
+}
+
+// rfc2136Provider 基于RFC 2136动态更新的驱动，适用于自建的BIND、Knot等服务器
+//
+// 动态更新协议没有记录ID，记录ID由记录类型和内容摘要生成。
+type rfc2136Provider struct {
+	server    string
+	zone      string
+	keyName   string
+	algorithm string
+	secret    string
+	timeout   time.Duration
+}
+
+// rfc2136Algorithms 支持的TSIG算法
+var rfc2136Algorithms = map[string]string{
+	"hmac-md5":    dns.HmacMD5,
+	"hmac-sha1":   dns.HmacSHA1,
+	"hmac-sha224": dns.HmacSHA224,
+	"hmac-sha256": dns.HmacSHA256,
+	"hmac-sha384": dns.HmacSHA384,
+	"hmac-sha512": dns.HmacSHA512,
+}
+
+func newRFC2136(config []byte) (Provider, error) {
+	var cfg rfc2136Config
+	if err := json.Unmarshal(config, &cfg); err != nil {
+		return nil, fmt.Errorf("RFC 2136配置格式错误: %v", err)
+	}
+	if cfg.Server == "" {
+		return nil, errors.New("RFC 2136配置缺少server")
+	}
+	if _, _, err := net.SplitHostPort(cfg.Server); err != nil {
+		cfg.Server = net.JoinHostPort(cfg.Server, rfc2136DefaultPort)
+	}
+
+	p := &rfc2136Provider{
+		server:  cfg.Server,
+		zone:    cfg.Zone,
+		timeout: defaultHTTPTimeout,
+	}
+
+	if cfg.TSIGKeyName != "" {
+		if cfg.TSIGSecret == "" {
+			return nil, errors.New("RFC 2136配置缺少tsig_secret")
+		}
+		if _, err := base64.StdEncoding.DecodeString(cfg.TSIGSecret); err != nil {
+			return nil, errors.New("tsig_secret必须是Base64编码")
+		}
+		if cfg.TSIGAlgorithm == "" {
+			cfg.TSIGAlgorithm = "hmac-sha256"
+		}
+		algorithm, ok := rfc2136Algorithms[strings.ToLower(strings.TrimSuffix(cfg.TSIGAlgorithm, "."))]
+		if !ok {
+			return nil, fmt.Errorf("不支持的TSIG算法: %s", cfg.TSIGAlgorithm)
+		}
+		p.keyName = dns.Fqdn(cfg.TSIGKeyName)
+		p.algorithm = algorithm
+		p.secret = cfg.TSIGSecret
+	}
+
+	return p, nil
+}
+
+// client 创建DNS客户端，配置了TSIG时附带密钥
+func (p *rfc2136Provider) client(network string) *dns.Client {
+	c := &dns.Client{Net: network, Timeout: p.timeout}
+	if p.keyName != "" {
+		c.TsigSecret = map[string]string{p.keyName: p.secret}
+	}
+	return c
+}
+
+// sign 为消息附加TSIG签名
+func (p *rfc2136Provider) sign(m *dns.Msg) {
+	if p.keyName != "" {
+		m.SetTsig(p.keyName, p.algorithm, rfc2136TsigFudge, time.Now().Unix())
+	}
+}
+
+// exchange 发送消息并检查响应码
+func (p *rfc2136Provider) exchange(ctx context.Context, m *dns.Msg) (*dns.Msg, error) {
+	p.sign(m)
+
+	resp, _, err := p.client("tcp").ExchangeContext(ctx, m, p.server)
+	if err != nil {
+		return nil, fmt.Errorf("请求DNS服务器失败: %v", err)
+	}
+	if resp.Rcode != dns.RcodeSuccess {
+		rcode := dns.RcodeToString[resp.Rcode]
+		if resp.Rcode == dns.RcodeNotAuth || resp.Rcode == dns.RcodeNameError {
+			return nil, fmt.Errorf("%w: %s", ErrZoneNotFound, rcode)
+		}
+		return nil, &APIError{Provider: "RFC 2136", Code: rcode, Message: "DNS服务器拒绝请求"}
+	}
+	return resp, nil
+}
+
+// updateZone 返回动态更新使用的区域
+func (p *rfc2136Provider) updateZone(zone Zone) string {
+	if p.zone != "" {
+		return dns.Fqdn(p.zone)
+	}
+	return dns.Fqdn(zone.Name)
+}
+
+// ListZones 返回配置中的区域
+func (p *rfc2136Provider) ListZones(ctx context.Context) ([]Zone, error) {
+	if p.zone == "" {
+		return nil, nil
+	}
+	return []Zone{{Name: strings.TrimSuffix(p.zone, ".")}}, nil
+}
+
+// ListRecords 通过区域传送（AXFR）列出记录
+func (p *rfc2136Provider) ListRecords(ctx context.Context, zone Zone) ([]Record, error) {
+	m := new(dns.Msg)
+	m.SetAxfr(p.updateZone(zone))
+	p.sign(m)
+
+	transfer := &dns.Transfer{
+		DialTimeout:  p.timeout,
+		ReadTimeout:  p.timeout,
+		WriteTimeout: p.timeout,
+	}
+	if p.keyName != "" {
+		transfer.TsigSecret = map[string]string{p.keyName: p.secret}
+	}
+
+	envelopes, err := transfer.In(m, p.server)
+	if err != nil {
+		return nil, fmt.Errorf("区域传送失败: %v", err)
+	}
+
+	apex := dns.Fqdn(zone.Name)
+	var records []Record
+	for envelope := range envelopes {
+		if envelope.Error != nil {
+			return nil, fmt.Errorf("区域传送失败: %v", envelope.Error)
+		}
+		for _, rr := range envelope.RR {
+			// 只返回属于该域名的记录，忽略由服务器维护的SOA和根域名NS记录
+			name := strings.ToLower(rr.Header().Name)
+			if name != strings.ToLower(apex) && !dns.IsSubDomain(apex, name) {
+				continue
+			}
+			if _, ok := rr.(*dns.SOA); ok {
+				continue
+			}
+			if _, ok := rr.(*dns.NS); ok && strings.EqualFold(rr.Header().Name, apex) {
+				continue
+			}
+			if record, ok := rfc2136ToRecord(rr, zone.Name); ok {
+				records = append(records, record)
+			}
+		}
+	}
+	return records, nil
+}
+
+// CreateRecord 发送添加记录的动态更新
+func (p *rfc2136Provider) CreateRecord(ctx context.Context, zone Zone, record Record) (string, error) {
+	rr, err := rfc2136NewRR(zone.Name, record)
+	if err != nil {
+		return "", err
+	}
+
+	m := new(dns.Msg)
+	m.SetUpdate(p.updateZone(zone))
+	m.Insert([]dns.RR{rr})
+	if _, err := p.exchange(ctx, m); err != nil {
+		return "", err
+	}
+	return rfc2136RecordID(rr), nil
+}
+
+// UpdateRecord 在同一个动态更新消息中删除旧记录并添加新记录
+func (p *rfc2136Provider) UpdateRecord(ctx context.Context, zone Zone, old, record Record) (string, error) {
+	oldRR, err := rfc2136NewRR(zone.Name, old)
+	if err != nil {
+		return "", err
+	}
+	rr, err := rfc2136NewRR(zone.Name, record)
+	if err != nil {
+		return "", err
+	}
+
+	m := new(dns.Msg)
+	m.SetUpdate(p.updateZone(zone))
+	m.Remove([]dns.RR{oldRR})
+	m.Insert([]dns.RR{rr})
+	if _, err := p.exchange(ctx, m); err != nil {
+		return "", err
+	}
+	return rfc2136RecordID(rr), nil
+}
+
+// DeleteRecord 发送删除记录的动态更新
+func (p *rfc2136Provider) DeleteRecord(ctx context.Context, zone Zone, record Record) error {
+	rr, err := rfc2136NewRR(zone.Name, record)
+	if err != nil {
+		return err
+	}
+
+	m := new(dns.Msg)
+	m.SetUpdate(p.updateZone(zone))
+	m.Remove([]dns.RR{rr})
+	_, err = p.exchange(ctx, m)
+	return err
+}
+
+// Test 查询区域的SOA记录，验证服务器可达且TSIG密钥有效
+func (p *rfc2136Provider) Test(ctx context.Context) error {
+	if p.zone == "" {
+		return errors.New("RFC 2136配置缺少zone，无法测试")
+	}
+
+	m := new(dns.Msg)
+	m.SetQuestion(dns.Fqdn(p.zone), dns.TypeSOA)
+	resp, err := p.exchange(ctx, m)
+	if err != nil {
+		return err
+	}
+	if !resp.Authoritative {
+		return fmt.Errorf("DNS服务器不是区域 %s 的权威服务器", p.zone)
+	}
+	return nil
+}
+
+// rfc2136NewRR 将驱动记录转换为资源记录
+//
+// TXT和CAA记录直接构造，内容按区域文件格式转义，其余类型按区域文件格式解析。
+func rfc2136NewRR(zoneName string, record Record) (dns.RR, error) {
+	recordType := strings.ToUpper(record.Type)
+	name := dns.Fqdn(FQDN(record.Name, zoneName))
+	var rdata string
+
+	switch recordType {
+	case "CNAME", "NS", "PTR":
+		rdata = dns.Fqdn(record.Value)
+	case "MX":
+		rdata = fmt.Sprintf("%d %s", record.Priority, dns.Fqdn(record.Value))
+	case "SRV":
+		rdata = fmt.Sprintf("%d %d %d %s", record.Priority, record.Weight, record.Port, dns.Fqdn(record.Value))
+	case "TXT":
+		hdr := dns.RR_Header{Name: name, Rrtype: dns.TypeTXT, Class: dns.ClassINET, Ttl: uint32(record.TTL)}
+		return &dns.TXT{Hdr: hdr, Txt: rfc2136TXTStrings(record.Value)}, nil
+	case "CAA":
+		flags, tag, value, err := parseCAAValue(record.Value)
+		if err != nil {
+			return nil, err
+		}
+		if flags < 0 || flags > 255 {
+			return nil, errors.New("CAA记录flags必须在0到255之间")
+		}
+		hdr := dns.RR_Header{Name: name, Rrtype: dns.TypeCAA, Class: dns.ClassINET, Ttl: uint32(record.TTL)}
+		return &dns.CAA{Hdr: hdr, Flag: uint8(flags), Tag: tag, Value: rfc2136Escape(value)}, nil
+	default:
+		rdata = record.Value
+	}
+
+	rr, err := dns.NewRR(fmt.Sprintf("%s %d IN %s %s", name, record.TTL, recordType, rdata))
+	if err != nil {
+		return nil, fmt.Errorf("记录格式错误: %v", err)
+	}
+	return rr, nil
+}
+
+// rfc2136TXTStrings 将TXT记录值按255字节拆分为多个字符串并转义
+func rfc2136TXTStrings(value string) []string {
+	const maxLen = 255
+	strs := []string{}
+	for len(value) > maxLen {
+		strs = append(strs, rfc2136Escape(value[:maxLen]))
+		value = value[maxLen:]
+	}
+	return append(strs, rfc2136Escape(value))
+}
+
+// rfc2136Escape 按区域文件格式转义字符串：引号和反斜杠前加反斜杠，不可打印的字节写为\DDD
+//
+// 与miekg/dns解析报文时的转义规则一致，保证写入和读取的记录生成相同的记录ID。
+func rfc2136Escape(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == '"' || c == '\\':
+			b.WriteByte('\\')
+			b.WriteByte(c)
+		case c < ' ' || c > '~':
+			fmt.Fprintf(&b, "\\%03d", c)
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
+
+// rfc2136Unescape 还原按区域文件格式转义的字符串
+func rfc2136Unescape(s string) string {
+	if !strings.Contains(s, `\`) {
+		return s
+	}
+
+	b := make([]byte, 0, len(s))
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' || i+1 == len(s) {
+			b = append(b, s[i])
+			continue
+		}
+		i++
+		if i+2 < len(s) && isDigit(s[i]) && isDigit(s[i+1]) && isDigit(s[i+2]) {
+			if n, err := strconv.Atoi(s[i : i+3]); err == nil && n <= 255 {
+				b = append(b, byte(n))
+				i += 2
+				continue
+			}
+		}
+		b = append(b, s[i])
+	}
+	return string(b)
+}
+
+// isDigit 判断字节是否为十进制数字
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+// rfc2136ToRecord 将资源记录转换为驱动记录，不支持的类型返回false
+func rfc2136ToRecord(rr dns.RR, zoneName string) (Record, bool) {
+	hdr := rr.Header()
+	record := Record{
+		ID:   rfc2136RecordID(rr),
+		Name: RelativeName(hdr.Name, zoneName),
+		Type: dns.TypeToString[hdr.Rrtype],
+		TTL:  int(hdr.Ttl),
+	}
+
+	switch v := rr.(type) {
+	case *dns.A:
+		record.Value = v.A.String()
+	case *dns.AAAA:
+		record.Value = v.AAAA.String()
+	case *dns.CNAME:
+		record.Value = v.Target
+	case *dns.NS:
+		record.Value = v.Ns
+	case *dns.PTR:
+		record.Value = v.Ptr
+	case *dns.MX:
+		record.Value = v.Mx
+		record.Priority = int(v.Preference)
+	case *dns.SRV:
+		record.Value = v.Target
+		record.Priority = int(v.Priority)
+		record.Weight = int(v.Weight)
+		record.Port = int(v.Port)
+	case *dns.TXT:
+		record.Value = rfc2136Unescape(strings.Join(v.Txt, ""))
+	case *dns.CAA:
+		record.Value = fmt.Sprintf("%d %s %s", v.Flag, v.Tag, v.Value)
+	default:
+		return Record{}, false
+	}
+
+	record.Value = normalizeValue(record.Type, record.Value)
+	return record, true
+}
+
+// rfc2136RecordID 根据记录名称、类型和内容生成稳定的记录ID（不含TTL），格式为"类型/摘要"
+func rfc2136RecordID(rr dns.RR) string {
+	rr = dns.Copy(rr)
+	rr.Header().Ttl = 0
+	rr.Header().Name = strings.ToLower(rr.Header().Name)
+
+	sum := sha256.Sum256([]byte(rr.String()))
+	return dns.TypeToString[rr.Header().Rrtype] + "/" + hex.EncodeToString(sum[:12])
+}
@@ -0,0 +1,236 @@
+package providers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/miekg/dns"
+)
+
+const (
+	testTSIGKey    = "update-key."
+	testTSIGSecret = "c2VjcmV0LWtleS1mb3ItcmZjMjEzNi10ZXN0cw=="
+)
+
+// fakeDNSServer 支持TSIG签名的动态更新和区域传送的DNS服务器，只有example.com一个区域
+type fakeDNSServer struct {
+	mu  sync.Mutex
+	rrs []dns.RR
+}
+
+func (f *fakeDNSServer) ServeDNS(w dns.ResponseWriter, r *dns.Msg) {
+	m := new(dns.Msg)
+	m.SetReply(r)
+	if r.IsTsig() == nil || w.TsigStatus() != nil {
+		m.Rcode = dns.RcodeRefused
+		w.WriteMsg(m)
+		return
+	}
+	if len(r.Question) != 1 || r.Question[0].Name != "example.com." {
+		m.Rcode = dns.RcodeRefused
+		m.SetTsig(testTSIGKey, dns.HmacSHA256, 300, time.Now().Unix())
+		w.WriteMsg(m)
+		return
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	switch {
+	case r.Opcode == dns.OpcodeUpdate:
+		for _, rr := range r.Ns {
+			switch rr.Header().Class {
+			case dns.ClassINET:
+				f.remove(rr)
+				f.rrs = append(f.rrs, rr)
+			case dns.ClassNONE:
+				f.remove(rr)
+			}
+		}
+	case r.Question[0].Qtype == dns.TypeAXFR:
+		soa, _ := dns.NewRR("example.com. 3600 IN SOA ns1.example.com. admin.example.com. 1 10800 3600 604800 3600")
+		ns, _ := dns.NewRR("example.com. 3600 IN NS ns1.example.com.")
+		ch := make(chan *dns.Envelope, 1)
+		ch <- &dns.Envelope{RR: append(append([]dns.RR{soa, ns}, f.rrs...), soa)}
+		close(ch)
+		new(dns.Transfer).Out(w, r, ch)
+		return
+	}
+
+	m.SetTsig(testTSIGKey, dns.HmacSHA256, 300, time.Now().Unix())
+	w.WriteMsg(m)
+}
+
+// remove 删除与rr内容相同的记录（忽略TTL和类）
+func (f *fakeDNSServer) remove(rr dns.RR) {
+	target := dns.Copy(rr)
+	target.Header().Class = dns.ClassINET
+	target.Header().Ttl = 0
+
+	kept := f.rrs[:0]
+	for _, existing := range f.rrs {
+		candidate := dns.Copy(existing)
+		candidate.Header().Ttl = 0
+		if !dns.IsDuplicate(candidate, target) {
+			kept = append(kept, existing)
+		}
+	}
+	f.rrs = kept
+}
+
+func newTestRFC2136(t *testing.T) Provider {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("监听失败: %v", err)
+	}
+	started := make(chan struct{})
+	server := &dns.Server{
+		Listener:          listener,
+		Handler:           &fakeDNSServer{},
+		TsigSecret:        map[string]string{testTSIGKey: testTSIGSecret},
+		NotifyStartedFunc: func() { close(started) },
+		// 默认只接受查询，动态更新返回NOTIMP
+		MsgAcceptFunc: func(dns.Header) dns.MsgAcceptAction { return dns.MsgAccept },
+	}
+	go server.ActivateAndServe()
+	<-started
+	t.Cleanup(func() { server.Shutdown() })
+
+	config, _ := json.Marshal(rfc2136Config{
+		Server:      listener.Addr().String(),
+		Zone:        "example.com",
+		TSIGKeyName: strings.TrimSuffix(testTSIGKey, "."),
+		TSIGSecret:  testTSIGSecret,
+	})
+	p, err := newRFC2136(config)
+	if err != nil {
+		t.Fatalf("创建驱动失败: %v", err)
+	}
+	return p
+}
+
+func TestRFC2136RoundTrip(t *testing.T) {
+	ctx := context.Background()
+	p := newTestRFC2136(t)
+	zone := Zone{Name: "example.com"}
+
+	tests := []struct {
+		name   string
+		record Record
+		update Record
+	}{
+		{
+			name:   "A",
+			record: Record{Name: "www", Type: "A", Value: "1.1.1.1", TTL: 600},
+			update: Record{Name: "www", Type: "A", Value: "2.2.2.2", TTL: 300},
+		},
+		{
+			name:   "MX",
+			record: Record{Name: "@", Type: "MX", Value: "mail.example.com", Priority: 10, TTL: 600},
+			update: Record{Name: "@", Type: "MX", Value: "mx.example.com", Priority: 20, TTL: 600},
+		},
+		{
+			name:   "TXT with escapes",
+			record: Record{Name: "txt", Type: "TXT", Value: "say \"hi\"\t\\o/ 你好\x7f", TTL: 600},
+			update: Record{Name: "txt", Type: "TXT", Value: "v=spf1 include:example.net ~all", TTL: 600},
+		},
+		{
+			name:   "long TXT",
+			record: Record{Name: "dkim", Type: "TXT", Value: strings.Repeat("a", 300), TTL: 600},
+			update: Record{Name: "dkim", Type: "TXT", Value: strings.Repeat("b", 600), TTL: 600},
+		},
+		{
+			name:   "CAA",
+			record: Record{Name: "@", Type: "CAA", Value: "0 issue letsencrypt.org", TTL: 600},
+			update: Record{Name: "@", Type: "CAA", Value: "128 iodef mailto:ops@example.com", TTL: 600},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			id, err := p.CreateRecord(ctx, zone, tt.record)
+			if err != nil {
+				t.Fatalf("创建记录失败: %v", err)
+			}
+			assertRFC2136Record(t, p, zone, id, tt.record)
+
+			id, err = p.UpdateRecord(ctx, zone, tt.record, tt.update)
+			if err != nil {
+				t.Fatalf("更新记录失败: %v", err)
+			}
+			assertRFC2136Record(t, p, zone, id, tt.update)
+
+			if err := p.DeleteRecord(ctx, zone, tt.update); err != nil {
+				t.Fatalf("删除记录失败: %v", err)
+			}
+			if r := findRecord(t, p, zone, id); r != nil {
+				t.Fatalf("记录未删除: %+v", r)
+			}
+		})
+	}
+}
+
+func TestRFC2136Errors(t *testing.T) {
+	tests := []struct {
+		name   string
+		zone   string
+		secret string
+		check  func(error) bool
+	}{
+		{"refused", "other.com", testTSIGSecret, func(err error) bool {
+			var apiErr *APIError
+			return errors.As(err, &apiErr) && apiErr.Code == "REFUSED"
+		}},
+		{"bad TSIG secret", "example.com", "d3Jvbmcta2V5", func(err error) bool { return err != nil }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := newTestRFC2136(t).(*rfc2136Provider)
+			p.zone = tt.zone
+			p.secret = tt.secret
+
+			_, err := p.CreateRecord(context.Background(), Zone{Name: tt.zone}, Record{Name: "www", Type: "A", Value: "1.1.1.1", TTL: 600})
+			if !tt.check(err) {
+				t.Fatalf("返回的错误为 %v", err)
+			}
+		})
+	}
+}
+
+// assertRFC2136Record 检查通过区域传送读取的记录与写入的记录一致
+func assertRFC2136Record(t *testing.T, p Provider, zone Zone, id string, want Record) {
+	t.Helper()
+
+	got := findRecord(t, p, zone, id)
+	if got == nil {
+		t.Fatalf("没有ID为 %s 的记录", id)
+	}
+	if got.Name != want.Name || got.Value != want.Value || got.TTL != want.TTL || got.Priority != want.Priority {
+		t.Fatalf("读取的记录为 %+v，应为 %+v", *got, want)
+	}
+}
+
+// findRecord 通过区域传送查找指定ID的记录
+func findRecord(t *testing.T, p Provider, zone Zone, id string) *Record {
+	t.Helper()
+
+	records, err := p.ListRecords(context.Background(), zone)
+	if err != nil {
+		t.Fatalf("读取记录失败: %v", err)
+	}
+	for i := range records {
+		if records[i].ID == id {
+			return &records[i]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,138 @@
+package providers
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// rrsetLocks 记录集级别的锁，避免并发的读-改-写互相覆盖
+var rrsetLocks sync.Map
+
+// rrsetAPI 按"名称+类型"整体替换记录集（RRset）的服务商接口，T为记录集中的单条记录
+type rrsetAPI[T any] interface {
+	// rrsetKey 返回记录所在记录集的唯一标识，需包含服务商账户，用于加锁和判断两条记录是否属于同一记录集
+	rrsetKey(zone Zone, record Record) string
+	// readRRSet 读取记录所在的记录集，记录集不存在时返回空
+	readRRSet(ctx context.Context, zone Zone, record Record) ([]T, error)
+	// writeRRSet 整体写回记录所在的记录集，items为空时删除整个记录集
+	writeRRSet(ctx context.Context, zone Zone, record Record, items []T) error
+}
+
+// rrsetEditor 在记录集中写入单条记录
+//
+// 记录没有独立ID的服务商只能整体替换记录集，因此每次写入都先加锁读取所在记录集，
+// 在本地合并后再整体提交。驱动通过rrsetAPI提供读写接口，合并和加锁由本类型完成。
+type rrsetEditor[T any] struct {
+	api     rrsetAPI[T]
+	newItem func(record Record) (T, error) // 构造记录集中的记录
+	itemKey func(item T) string            // 记录在记录集中的唯一标识（不含TTL）
+	itemID  func(record Record, item T) string
+}
+
+// create 将记录合并到所在的记录集中，记录已存在时不重复写入
+func (e rrsetEditor[T]) create(ctx context.Context, zone Zone, record Record) (string, error) {
+	item, err := e.newItem(record)
+	if err != nil {
+		return "", err
+	}
+
+	key := e.itemKey(item)
+	err = e.modify(ctx, zone, record, func(set []T) []T {
+		for _, existing := range set {
+			if e.itemKey(existing) == key {
+				return set
+			}
+		}
+		return append(set, item)
+	})
+	if err != nil {
+		return "", err
+	}
+	return e.itemID(record, item), nil
+}
+
+// update 在记录集中替换旧记录，名称或类型变化时从旧记录集移除并合并到新记录集
+func (e rrsetEditor[T]) update(ctx context.Context, zone Zone, old, record Record) (string, error) {
+	if e.api.rrsetKey(zone, old) != e.api.rrsetKey(zone, record) {
+		id, err := e.create(ctx, zone, record)
+		if err != nil {
+			return "", err
+		}
+		if err := e.delete(ctx, zone, old); err != nil && !errors.Is(err, ErrRecordNotFound) {
+			return "", err
+		}
+		return id, nil
+	}
+
+	oldItem, err := e.newItem(old)
+	if err != nil {
+		return "", err
+	}
+	item, err := e.newItem(record)
+	if err != nil {
+		return "", err
+	}
+
+	oldKey, key := e.itemKey(oldItem), e.itemKey(item)
+	err = e.modify(ctx, zone, record, func(set []T) []T {
+		merged := make([]T, 0, len(set)+1)
+		for _, existing := range set {
+			if k := e.itemKey(existing); k == oldKey || k == key {
+				continue
+			}
+			merged = append(merged, existing)
+		}
+		return append(merged, item)
+	})
+	if err != nil {
+		return "", err
+	}
+	return e.itemID(record, item), nil
+}
+
+// delete 从记录集中移除记录，记录集为空时删除整个记录集
+func (e rrsetEditor[T]) delete(ctx context.Context, zone Zone, record Record) error {
+	item, err := e.newItem(record)
+	if err != nil {
+		return err
+	}
+
+	key := e.itemKey(item)
+	found := false
+	err = e.modify(ctx, zone, record, func(set []T) []T {
+		remaining := make([]T, 0, len(set))
+		for _, existing := range set {
+			if e.itemKey(existing) == key {
+				found = true
+				continue
+			}
+			remaining = append(remaining, existing)
+		}
+		return remaining
+	})
+	if err != nil {
+		return err
+	}
+	if !found {
+		return ErrRecordNotFound
+	}
+	return nil
+}
+
+// modify 锁定记录所在的记录集，读取后经fn处理再整体写回，处理前后都为空时不写回
+func (e rrsetEditor[T]) modify(ctx context.Context, zone Zone, record Record, fn func([]T) []T) error {
+	lock, _ := rrsetLocks.LoadOrStore(e.api.rrsetKey(zone, record), &sync.Mutex{})
+	lock.(*sync.Mutex).Lock()
+	defer lock.(*sync.Mutex).Unlock()
+
+	current, err := e.api.readRRSet(ctx, zone, record)
+	if err != nil {
+		return err
+	}
+	updated := fn(current)
+	if len(updated) == 0 && len(current) == 0 {
+		return nil
+	}
+	return e.api.writeRRSet(ctx, zone, record, updated)
+}
@@ -0,0 +1,219 @@
+package providers
+
+import (
+	"domain-max/pkg/utils"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// 配置字段的值类型
+const (
+	FieldString = "string"
+	FieldInt    = "int"
+	FieldBool   = "bool"
+)
+
+// 配置字段的格式
+const (
+	FormatURL      = "url"      // http或https地址
+	FormatHostname = "hostname" // 域名
+	FormatHostPort = "hostport" // 主机名或IP，可带端口
+	FormatBase64   = "base64"   // Base64编码
+)
+
+// Field 服务商配置字段
+type Field struct {
+	Name     string `json:"name"`             // 配置JSON中的字段名
+	Type     string `json:"type"`             // 值类型：string、int、bool
+	Label    string `json:"label"`            // 显示名称
+	Required bool   `json:"required"`         // 是否必填
+	Secret   bool   `json:"secret"`           // 是否为敏感字段，加密保存并在响应中隐藏
+	Format   string `json:"format,omitempty"` // 取值格式，为空时不校验
+}
+
+// Schema 服务商类型及其配置字段
+type Schema struct {
+	Type        string  `json:"type"`
+	Name        string  `json:"name"`
+	Description string  `json:"description"`
+	Fields      []Field `json:"config_fields"`
+}
+
+// ConfigError 服务商配置校验错误，Fields为字段名到错误信息的映射
+type ConfigError struct {
+	Fields map[string]string
+}
+
+func (e *ConfigError) Error() string {
+	names := make([]string, 0, len(e.Fields))
+	for name := range e.Fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	msgs := make([]string, 0, len(names))
+	for _, name := range names {
+		msgs = append(msgs, e.Fields[name])
+	}
+	return "服务商配置校验失败: " + strings.Join(msgs, "; ")
+}
+
+var schemas = make(map[string]Schema)
+
+// hostnamePattern 域名格式
+var hostnamePattern = regexp.MustCompile(`^([a-zA-Z0-9_]([a-zA-Z0-9_-]{0,61}[a-zA-Z0-9])?\.)*[a-zA-Z0-9]([a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?\.?$`)
+
+// RegisterSchema 注册服务商类型的配置字段
+func RegisterSchema(schema Schema) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	if _, exists := schemas[schema.Type]; exists {
+		panic("providers: 重复注册配置字段 " + schema.Type)
+	}
+	schemas[schema.Type] = schema
+}
+
+// Schemas 返回已注册服务商类型的配置字段，按类型排序
+func Schemas() []Schema {
+	list := make([]Schema, 0)
+	for _, providerType := range Types() {
+		if schema, ok := GetSchema(providerType); ok {
+			list = append(list, schema)
+		}
+	}
+	return list
+}
+
+// GetSchema 返回服务商类型的配置字段
+func GetSchema(providerType string) (Schema, bool) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	schema, ok := schemas[providerType]
+	return schema, ok
+}
+
+// SecretFields 返回服务商配置中的敏感字段
+func SecretFields(providerType string) []string {
+	schema, _ := GetSchema(providerType)
+
+	var names []string
+	for _, field := range schema.Fields {
+		if field.Secret {
+			names = append(names, field.Name)
+		}
+	}
+	return names
+}
+
+// ValidateConfig 按配置字段校验服务商配置，字段错误以*ConfigError返回
+//
+// 已通过SetDefaults设置默认值的必填字段允许留空。
+func ValidateConfig(providerType, config string) error {
+	registryMu.RLock()
+	_, registered := registry[providerType]
+	schema, hasSchema := schemas[providerType]
+	values := defaults[providerType]
+	registryMu.RUnlock()
+
+	if !registered {
+		return fmt.Errorf("%w: %s", ErrUnsupportedType, providerType)
+	}
+
+	fields := make(map[string]interface{})
+	if err := json.Unmarshal([]byte(config), &fields); err != nil {
+		return &ConfigError{Fields: map[string]string{"config": "配置格式必须是JSON对象"}}
+	}
+	if !hasSchema {
+		return nil
+	}
+
+	errs := make(map[string]string)
+	for _, field := range schema.Fields {
+		value, ok := fields[field.Name]
+		if !ok || value == nil || value == "" {
+			if field.Required && values[field.Name] == "" {
+				errs[field.Name] = field.Label + "不能为空"
+			}
+			continue
+		}
+		if msg := validateField(field, value); msg != "" {
+			errs[field.Name] = msg
+		}
+	}
+
+	if len(errs) > 0 {
+		return &ConfigError{Fields: errs}
+	}
+	return nil
+}
+
+// validateField 校验单个字段的类型和格式，返回错误信息
+func validateField(field Field, value interface{}) string {
+	switch field.Type {
+	case FieldInt:
+		switch v := value.(type) {
+		case float64:
+			if v != float64(int64(v)) {
+				return field.Label + "必须是整数"
+			}
+		case string:
+			if _, err := strconv.Atoi(v); err != nil {
+				return field.Label + "必须是整数"
+			}
+		default:
+			return field.Label + "必须是整数"
+		}
+		return ""
+	case FieldBool:
+		if _, ok := value.(bool); !ok {
+			return field.Label + "必须是布尔值"
+		}
+		return ""
+	}
+
+	s, ok := value.(string)
+	if !ok {
+		return field.Label + "必须是字符串"
+	}
+	// 已加密或掩码的敏感字段不再校验格式
+	if field.Secret && (s == MaskedValue || utils.IsEncryptedSecret(s)) {
+		return ""
+	}
+
+	switch field.Format {
+	case FormatURL:
+		u, err := url.Parse(s)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return field.Label + "必须是有效的http或https地址"
+		}
+	case FormatHostname:
+		if !hostnamePattern.MatchString(s) {
+			return field.Label + "必须是有效的域名"
+		}
+	case FormatHostPort:
+		host := s
+		if h, port, err := net.SplitHostPort(s); err == nil {
+			if n, err := strconv.Atoi(port); err != nil || n < 1 || n > 65535 {
+				return field.Label + "的端口无效"
+			}
+			host = h
+		}
+		if net.ParseIP(host) == nil && !hostnamePattern.MatchString(host) {
+			return field.Label + "必须是有效的主机名或IP地址"
+		}
+	case FormatBase64:
+		if _, err := base64.StdEncoding.DecodeString(s); err != nil {
+			return field.Label + "必须是Base64编码"
+		}
+	}
+	return ""
+}
@@ -0,0 +1,98 @@
+package providers
+
+import (
+	"errors"
+	"testing"
+)
+
+// schemaTestType 包含各种字段类型和格式的测试服务商类型
+const schemaTestType = "schema_test"
+
+func init() {
+	Register(schemaTestType, newMock)
+	RegisterSchema(Schema{
+		Type: schemaTestType,
+		Name: "配置校验测试",
+		Fields: []Field{
+			{Name: "server", Type: FieldString, Label: "服务器地址", Required: true, Format: FormatHostPort},
+			{Name: "endpoint", Type: FieldString, Label: "接口地址", Format: FormatURL},
+			{Name: "zone", Type: FieldString, Label: "区域", Format: FormatHostname},
+			{Name: "secret", Type: FieldString, Label: "密钥", Secret: true, Format: FormatBase64},
+			{Name: "port", Type: FieldInt, Label: "端口"},
+			{Name: "tls", Type: FieldBool, Label: "TLS"},
+		},
+	})
+}
+
+func TestValidateConfig(t *testing.T) {
+	tests := []struct {
+		name     string
+		config   string
+		defaults map[string]string
+		field    string // ConfigError中应出现的唯一字段，为空时配置有效
+	}{
+		{name: "valid", config: `{"server":"ns1.example.com:53"}`},
+		{name: "missing required", config: `{"zone":"example.com"}`, field: "server"},
+		{name: "empty required", config: `{"server":""}`, field: "server"},
+		{name: "default applied", config: `{}`, defaults: map[string]string{"server": "ns1.example.com"}},
+		{name: "not JSON object", config: `[]`, field: "config"},
+
+		{name: "int number", config: `{"server":"ns1.example.com","port":53}`},
+		{name: "int string", config: `{"server":"ns1.example.com","port":"53"}`},
+		{name: "int fraction", config: `{"server":"ns1.example.com","port":5.3}`, field: "port"},
+		{name: "int invalid", config: `{"server":"ns1.example.com","port":"abc"}`, field: "port"},
+
+		{name: "bool valid", config: `{"server":"ns1.example.com","tls":true}`},
+		{name: "bool invalid", config: `{"server":"ns1.example.com","tls":"yes"}`, field: "tls"},
+
+		{name: "url valid", config: `{"server":"ns1.example.com","endpoint":"https://api.example.com/v1"}`},
+		{name: "url scheme", config: `{"server":"ns1.example.com","endpoint":"ftp://api.example.com"}`, field: "endpoint"},
+		{name: "url host", config: `{"server":"ns1.example.com","endpoint":"https://"}`, field: "endpoint"},
+
+		{name: "hostname valid", config: `{"server":"ns1.example.com","zone":"example.com."}`},
+		{name: "hostname invalid", config: `{"server":"ns1.example.com","zone":"exa mple.com"}`, field: "zone"},
+		{name: "string type", config: `{"server":"ns1.example.com","zone":123}`, field: "zone"},
+
+		{name: "hostport IP", config: `{"server":"192.0.2.1"}`},
+		{name: "hostport IPv6 with port", config: `{"server":"[2001:db8::1]:5353"}`},
+		{name: "hostport bad port", config: `{"server":"ns1.example.com:70000"}`, field: "server"},
+		{name: "hostport bad host", config: `{"server":"ns1_.example.com:53"}`, field: "server"},
+
+		{name: "base64 valid", config: `{"server":"ns1.example.com","secret":"c2VjcmV0"}`},
+		{name: "base64 invalid", config: `{"server":"ns1.example.com","secret":"not base64!"}`, field: "secret"},
+		{name: "masked secret", config: `{"server":"ns1.example.com","secret":"******"}`},
+		{name: "encrypted secret", config: `{"server":"ns1.example.com","secret":"enc:v1:not base64!"}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.defaults != nil {
+				SetDefaults(schemaTestType, tt.defaults)
+				t.Cleanup(func() { SetDefaults(schemaTestType, nil) })
+			}
+
+			err := ValidateConfig(schemaTestType, tt.config)
+			if tt.field == "" {
+				if err != nil {
+					t.Fatalf("校验失败: %v", err)
+				}
+				return
+			}
+			var configErr *ConfigError
+			if !errors.As(err, &configErr) {
+				t.Fatalf("返回的错误为 %v，应为ConfigError", err)
+			}
+			if _, ok := configErr.Fields[tt.field]; !ok || len(configErr.Fields) != 1 {
+				t.Fatalf("错误字段为 %v，应为 %s", configErr.Fields, tt.field)
+			}
+		})
+	}
+}
+
+func TestValidateConfigUnknownType(t *testing.T) {
+	err := ValidateConfig("unknown", `{}`)
+	var configErr *ConfigError
+	if !errors.Is(err, ErrUnsupportedType) || errors.As(err, &configErr) {
+		t.Fatalf("返回的错误为 %v", err)
+	}
+}
@@ -0,0 +1,155 @@
+package providers
+
+import (
+	"domain-max/pkg/utils"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// MaskedValue API响应中敏感配置的掩码，提交该值表示保持原值不变
+const MaskedValue = "******"
+
+// ErrEncryptionKeyNotSet 未设置加密密钥
+var ErrEncryptionKeyNotSet = errors.New("未设置DNS服务商配置的加密密钥")
+
+var (
+	keyMu         sync.RWMutex
+	encryptionKey []byte
+)
+
+// SetEncryptionKey 设置加密敏感配置使用的主密钥（64位十六进制）
+func SetEncryptionKey(hexKey string) error {
+	key, err := utils.ParseEncryptionKey(hexKey)
+	if err != nil {
+		return err
+	}
+
+	keyMu.Lock()
+	defer keyMu.Unlock()
+	encryptionKey = key
+	return nil
+}
+
+// currentKey 返回当前的主密钥
+func currentKey() []byte {
+	keyMu.RLock()
+	defer keyMu.RUnlock()
+	return encryptionKey
+}
+
+// EncryptConfig 加密配置中尚未加密的敏感字段
+func EncryptConfig(providerType, config string) (string, error) {
+	key := currentKey()
+	if key == nil {
+		return "", ErrEncryptionKeyNotSet
+	}
+	return transformSecrets(providerType, config, func(value string) (string, error) {
+		if utils.IsEncryptedSecret(value) {
+			return value, nil
+		}
+		return utils.EncryptSecret(value, key)
+	})
+}
+
+// DecryptConfig 解密配置中的敏感字段，仅供驱动层使用，兼容尚未加密的旧配置
+func DecryptConfig(providerType, config string) (string, error) {
+	return transformSecrets(providerType, config, func(value string) (string, error) {
+		if !utils.IsEncryptedSecret(value) {
+			return value, nil
+		}
+		key := currentKey()
+		if key == nil {
+			return "", ErrEncryptionKeyNotSet
+		}
+		return utils.DecryptSecret(value, key)
+	})
+}
+
+// RotateConfig 使用新的主密钥重新加密敏感字段，尚未加密的字段直接用新密钥加密
+func RotateConfig(providerType, config string, oldKey, newKey []byte) (string, error) {
+	return transformSecrets(providerType, config, func(value string) (string, error) {
+		if utils.IsEncryptedSecret(value) {
+			return utils.RewrapSecret(value, oldKey, newKey)
+		}
+		return utils.EncryptSecret(value, newKey)
+	})
+}
+
+// MaskConfig 将敏感字段替换为掩码，用于API响应
+func MaskConfig(providerType, config string) string {
+	masked, err := transformSecrets(providerType, config, func(string) (string, error) {
+		return MaskedValue, nil
+	})
+	if err != nil {
+		// 无法解析的配置不返回原文
+		return ""
+	}
+	return masked
+}
+
+// MergeMaskedConfig 将提交配置中值为掩码的敏感字段替换为已保存的值
+func MergeMaskedConfig(providerType, config, stored string) (string, error) {
+	fields := make(map[string]interface{})
+	if err := json.Unmarshal([]byte(config), &fields); err != nil {
+		return "", fmt.Errorf("服务商配置格式错误: %v", err)
+	}
+	storedFields := make(map[string]interface{})
+	if stored != "" {
+		json.Unmarshal([]byte(stored), &storedFields)
+	}
+
+	changed := false
+	for _, name := range SecretFields(providerType) {
+		if fields[name] != MaskedValue {
+			continue
+		}
+		if storedValue, ok := storedFields[name]; ok {
+			fields[name] = storedValue
+		} else {
+			delete(fields, name)
+		}
+		changed = true
+	}
+	if !changed {
+		return config, nil
+	}
+
+	data, err := json.Marshal(fields)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// transformSecrets 对配置中非空的字符串敏感字段逐个执行transform
+func transformSecrets(providerType, config string, transform func(string) (string, error)) (string, error) {
+	names := SecretFields(providerType)
+	if len(names) == 0 || config == "" {
+		return config, nil
+	}
+
+	fields := make(map[string]interface{})
+	if err := json.Unmarshal([]byte(config), &fields); err != nil {
+		return "", fmt.Errorf("服务商配置格式错误: %v", err)
+	}
+
+	for _, name := range names {
+		value, ok := fields[name].(string)
+		if !ok || value == "" {
+			continue
+		}
+		transformed, err := transform(value)
+		if err != nil {
+			return "", fmt.Errorf("处理配置字段%s失败: %v", name, err)
+		}
+		fields[name] = transformed
+	}
+
+	data, err := json.Marshal(fields)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
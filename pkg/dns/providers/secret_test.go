@@ -0,0 +1,195 @@
+package providers
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"domain-max/pkg/utils"
+)
+
+const (
+	testEncryptionKey  = "0101010101010101010101010101010101010101010101010101010101010101"
+	otherEncryptionKey = "0202020202020202020202020202020202020202020202020202020202020202"
+)
+
+// setTestKey 设置主密钥，测试结束后恢复原密钥
+func setTestKey(t *testing.T, hexKey string) []byte {
+	t.Helper()
+
+	previous := currentKey()
+	t.Cleanup(func() {
+		keyMu.Lock()
+		encryptionKey = previous
+		keyMu.Unlock()
+	})
+	if err := SetEncryptionKey(hexKey); err != nil {
+		t.Fatalf("设置加密密钥失败: %v", err)
+	}
+	return currentKey()
+}
+
+// configField 读取配置JSON中的字段
+func configField(t *testing.T, config, name string) interface{} {
+	t.Helper()
+
+	fields := make(map[string]interface{})
+	if err := json.Unmarshal([]byte(config), &fields); err != nil {
+		t.Fatalf("解析配置失败: %v", err)
+	}
+	return fields[name]
+}
+
+func TestEncryptConfig(t *testing.T) {
+	setTestKey(t, testEncryptionKey)
+	config := `{"api_token":"secret-token","zone_id":"zone1"}`
+
+	encrypted, err := EncryptConfig("cloudflare", config)
+	if err != nil {
+		t.Fatalf("加密配置失败: %v", err)
+	}
+	token, _ := configField(t, encrypted, "api_token").(string)
+	if !utils.IsEncryptedSecret(token) || strings.Contains(encrypted, "secret-token") {
+		t.Fatalf("加密后的配置为 %s", encrypted)
+	}
+	if zoneID := configField(t, encrypted, "zone_id"); zoneID != "zone1" {
+		t.Fatalf("非敏感字段被修改为 %v", zoneID)
+	}
+
+	// 已加密的字段不会重复加密
+	again, err := EncryptConfig("cloudflare", encrypted)
+	if err != nil {
+		t.Fatalf("重复加密配置失败: %v", err)
+	}
+	if configField(t, again, "api_token") != token {
+		t.Fatalf("已加密的字段被重复加密: %s", again)
+	}
+
+	decrypted, err := DecryptConfig("cloudflare", again)
+	if err != nil {
+		t.Fatalf("解密配置失败: %v", err)
+	}
+	if got := configField(t, decrypted, "api_token"); got != "secret-token" {
+		t.Fatalf("解密结果为 %v", got)
+	}
+
+	// 兼容尚未加密的旧配置
+	if got, err := DecryptConfig("cloudflare", config); err != nil || configField(t, got, "api_token") != "secret-token" {
+		t.Fatalf("解密明文配置结果为 %s: %v", got, err)
+	}
+}
+
+func TestDecryptConfigWrongKey(t *testing.T) {
+	setTestKey(t, testEncryptionKey)
+	encrypted, err := EncryptConfig("cloudflare", `{"api_token":"secret-token"}`)
+	if err != nil {
+		t.Fatalf("加密配置失败: %v", err)
+	}
+
+	setTestKey(t, otherEncryptionKey)
+	if got, err := DecryptConfig("cloudflare", encrypted); err == nil {
+		t.Fatalf("使用错误的密钥解密成功: %s", got)
+	}
+}
+
+func TestEncryptConfigWithoutKey(t *testing.T) {
+	setTestKey(t, testEncryptionKey)
+	keyMu.Lock()
+	encryptionKey = nil
+	keyMu.Unlock()
+
+	if _, err := EncryptConfig("cloudflare", `{"api_token":"secret-token"}`); err != ErrEncryptionKeyNotSet {
+		t.Fatalf("未设置密钥时加密返回 %v", err)
+	}
+}
+
+func TestRotateConfig(t *testing.T) {
+	oldKey := setTestKey(t, testEncryptionKey)
+	encrypted, err := EncryptConfig("cloudflare", `{"api_token":"secret-token"}`)
+	if err != nil {
+		t.Fatalf("加密配置失败: %v", err)
+	}
+	newKey, _ := utils.ParseEncryptionKey(otherEncryptionKey)
+
+	tests := []struct {
+		name   string
+		config string
+	}{
+		{"encrypted", encrypted},
+		{"plaintext", `{"api_token":"secret-token"}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rotated, err := RotateConfig("cloudflare", tt.config, oldKey, newKey)
+			if err != nil {
+				t.Fatalf("轮换密钥失败: %v", err)
+			}
+			token, _ := configField(t, rotated, "api_token").(string)
+			if got, err := utils.DecryptSecret(token, newKey); err != nil || got != "secret-token" {
+				t.Fatalf("使用新密钥解密结果为 %q: %v", got, err)
+			}
+		})
+	}
+
+	if _, err := RotateConfig("cloudflare", encrypted, newKey, newKey); err == nil {
+		t.Fatal("使用错误的原密钥轮换成功")
+	}
+}
+
+func TestMaskConfig(t *testing.T) {
+	setTestKey(t, testEncryptionKey)
+	encrypted, _ := EncryptConfig("cloudflare", `{"api_token":"secret-token","zone_id":"zone1"}`)
+
+	tests := []struct {
+		name   string
+		config string
+		want   string
+	}{
+		{"plaintext", `{"api_token":"secret-token","zone_id":"zone1"}`, `{"api_token":"******","zone_id":"zone1"}`},
+		{"encrypted", encrypted, `{"api_token":"******","zone_id":"zone1"}`},
+		{"empty secret", `{"api_token":"","zone_id":"zone1"}`, `{"api_token":"","zone_id":"zone1"}`},
+		{"invalid JSON", `{"api_token":"secret-token"`, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := MaskConfig("cloudflare", tt.config); got != tt.want {
+				t.Fatalf("掩码后的配置为 %s，应为 %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMergeMaskedConfig(t *testing.T) {
+	setTestKey(t, testEncryptionKey)
+	stored, _ := EncryptConfig("cloudflare", `{"api_token":"secret-token","zone_id":"zone1"}`)
+	storedToken := configField(t, stored, "api_token")
+
+	tests := []struct {
+		name   string
+		config string
+		stored string
+		want   interface{} // 合并后api_token的值，nil表示字段被删除
+	}{
+		{"masked keeps stored ciphertext", `{"api_token":"******","zone_id":"zone2"}`, stored, storedToken},
+		{"new value replaces stored", `{"api_token":"new-token"}`, stored, "new-token"},
+		{"masked without stored value", `{"api_token":"******"}`, "", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			merged, err := MergeMaskedConfig("cloudflare", tt.config, tt.stored)
+			if err != nil {
+				t.Fatalf("合并配置失败: %v", err)
+			}
+			if got := configField(t, merged, "api_token"); got != tt.want {
+				t.Fatalf("合并后的api_token为 %v，应为 %v", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := MergeMaskedConfig("cloudflare", "not json", stored); err == nil {
+		t.Fatal("合并无效的配置成功")
+	}
+}
@@ -0,0 +1,36 @@
+package providers
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/miekg/dns"
+)
+
+// WriteZoneFile 将记录按BIND区域文件格式写入w
+//
+// 记录名称使用完整域名。无法转换为资源记录的记录写为注释，不中断导出。
+func WriteZoneFile(w io.Writer, zoneName string, records []Record) error {
+	zoneName = strings.TrimSuffix(zoneName, ".")
+	if _, err := fmt.Fprintf(w, "; %s 区域文件，导出于 %s\n$ORIGIN %s\n\n",
+		zoneName, time.Now().Format(time.RFC3339), dns.Fqdn(zoneName)); err != nil {
+		return err
+	}
+
+	for _, record := range records {
+		rr, err := rfc2136NewRR(zoneName, record)
+		if err != nil {
+			if _, err := fmt.Fprintf(w, "; 无法导出 %s %s %s: %v\n",
+				FQDN(record.Name, zoneName), record.Type, RecordValue(record), err); err != nil {
+				return err
+			}
+			continue
+		}
+		if _, err := fmt.Fprintln(w, rr.String()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
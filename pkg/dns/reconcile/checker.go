@@ -0,0 +1,248 @@
+package reconcile
+
+import (
+	"context"
+	"domain-max/pkg/dns/models"
+	"domain-max/pkg/dns/providers"
+	"domain-max/pkg/utils"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// checkTimeout 单个域名检测的超时时间
+const checkTimeout = 2 * time.Minute
+
+// ErrNoProvider 域名未绑定DNS服务商
+var ErrNoProvider = errors.New("该域名未绑定DNS服务商")
+
+// Checker 漂移检测器，比较本地DNS记录与服务商区域中的记录
+type Checker struct {
+	db *gorm.DB
+}
+
+// NewChecker 创建漂移检测器
+func NewChecker(db *gorm.DB) *Checker {
+	return &Checker{db: db}
+}
+
+// Run 按固定间隔检测全部绑定了服务商的域名，直到ctx取消
+func (c *Checker) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		c.CheckAll(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// CheckAll 检测全部已启用且绑定了服务商的域名
+func (c *Checker) CheckAll(ctx context.Context) {
+	var domains []models.Domain
+	if err := c.db.Where("provider_id IS NOT NULL AND is_active = ?", true).Find(&domains).Error; err != nil {
+		log.Printf("漂移检测：查询域名失败: %v", err)
+		return
+	}
+
+	for i := range domains {
+		if ctx.Err() != nil {
+			return
+		}
+		reports, err := c.CheckDomain(ctx, &domains[i])
+		if err != nil {
+			log.Printf("漂移检测：域名 %s 检测失败: %v", domains[i].Name, err)
+		}
+		for _, report := range reports {
+			if report.Error == "" && !report.InSync() {
+				log.Printf("漂移检测：域名 %s 在服务商 %d 中缺失 %d 条，多出 %d 条，不一致 %d 条",
+					domains[i].Name, report.ProviderID, len(report.Missing), len(report.Extra), len(report.Changed))
+			}
+		}
+	}
+}
+
+// CheckDomain 依次检测域名绑定的主备DNS服务商并保存结果，服务商请求失败时同样保存失败原因
+//
+// 返回已保存的检测结果，任一服务商检测失败时同时返回第一个错误。
+func (c *Checker) CheckDomain(ctx context.Context, domain *models.Domain) ([]*Report, error) {
+	providerIDs := domain.ProviderIDs()
+	if len(providerIDs) == 0 {
+		return nil, ErrNoProvider
+	}
+
+	var reports []*Report
+	var checkErr error
+	for _, providerID := range providerIDs {
+		report := &Report{
+			DomainID:   domain.ID,
+			DomainName: domain.Name,
+			ProviderID: providerID,
+			CheckedAt:  time.Now(),
+			Missing:    []Item{},
+			Extra:      []Item{},
+			Changed:    []Change{},
+			Unsynced:   []Item{},
+		}
+
+		if err := c.compare(ctx, domain, providerID, report); err != nil {
+			report.Error = err.Error()
+			if checkErr == nil {
+				checkErr = fmt.Errorf("服务商 %d: %w", providerID, err)
+			}
+		}
+		if err := c.save(report); err != nil {
+			return nil, err
+		}
+		reports = append(reports, report)
+	}
+
+	// 已解除绑定的服务商的检测结果不再保留
+	if err := c.db.Where("domain_id = ? AND provider_id NOT IN ?", domain.ID, providerIDs).
+		Delete(&models.DriftReport{}).Error; err != nil {
+		return nil, err
+	}
+	return reports, checkErr
+}
+
+// compare 读取本地记录和指定服务商中的记录并填充报告
+func (c *Checker) compare(ctx context.Context, domain *models.Domain, providerID uint, report *Report) error {
+	provider, err := providers.OpenByID(c.db, providerID)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, checkTimeout)
+	defer cancel()
+
+	upstream, err := provider.ListRecords(ctx, providers.ZoneForProvider(domain, providerID))
+	if err != nil {
+		return err
+	}
+
+	var local []models.DNSRecord
+	if err := c.db.Where("domain_id = ?", domain.ID).Find(&local).Error; err != nil {
+		return err
+	}
+	unsynced, err := c.providerState(domain, providerID, local)
+	if err != nil {
+		return err
+	}
+
+	report.Missing, report.Extra, report.Changed = Compare(local, upstream, providers.SupportsProxy(provider))
+	separateUnsynced(report, unsynced)
+	return nil
+}
+
+// providerState 将本地记录的服务商记录ID替换为该服务商中的记录ID，并返回尚未同步到该服务商的记录
+//
+// 早期同步的记录没有各服务商的同步状态，只在记录中保存了主服务商的记录ID和汇总状态。
+func (c *Checker) providerState(domain *models.Domain, providerID uint, local []models.DNSRecord) (map[uint]bool, error) {
+	var states []models.RecordSync
+	records := c.db.Model(&models.DNSRecord{}).Select("id").Where("domain_id = ?", domain.ID)
+	if err := c.db.Where("provider_id = ? AND record_id IN (?)", providerID, records).Find(&states).Error; err != nil {
+		return nil, err
+	}
+	byRecord := make(map[uint]*models.RecordSync, len(states))
+	for i := range states {
+		byRecord[states[i].RecordID] = &states[i]
+	}
+
+	primary := domain.ProviderID != nil && *domain.ProviderID == providerID
+	unsynced := make(map[uint]bool)
+	for i := range local {
+		record := &local[i]
+		state, ok := byRecord[record.ID]
+		switch {
+		case ok:
+			record.ExternalID = state.ExternalID
+			if state.Status != models.RecordStatusSynced {
+				unsynced[record.ID] = true
+			}
+		case primary:
+			if record.Status != models.RecordStatusSynced {
+				unsynced[record.ID] = true
+			}
+		default:
+			record.ExternalID = ""
+			unsynced[record.ID] = true
+		}
+	}
+	return unsynced, nil
+}
+
+// separateUnsynced 将尚未同步成功的本地记录从缺失和不一致中移到Unsynced
+func separateUnsynced(report *Report, unsynced map[uint]bool) {
+	if len(unsynced) == 0 {
+		return
+	}
+
+	missing := report.Missing[:0]
+	for _, item := range report.Missing {
+		if unsynced[item.RecordID] {
+			report.Unsynced = append(report.Unsynced, item)
+			continue
+		}
+		missing = append(missing, item)
+	}
+	report.Missing = missing
+
+	changed := report.Changed[:0]
+	for _, change := range report.Changed {
+		if unsynced[change.Local.RecordID] {
+			report.Unsynced = append(report.Unsynced, change.Local)
+			continue
+		}
+		changed = append(changed, change)
+	}
+	report.Changed = changed
+}
+
+// save 保存检测结果，每个域名在每个服务商中只保留最近一次
+func (c *Checker) save(report *Report) error {
+	details, err := json.Marshal(report)
+	if err != nil {
+		return err
+	}
+
+	var row models.DriftReport
+	if err := c.db.Where("domain_id = ? AND provider_id = ?", report.DomainID, report.ProviderID).First(&row).Error; err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+	row.DomainID = report.DomainID
+	row.ProviderID = report.ProviderID
+	row.Missing = len(report.Missing)
+	row.Extra = len(report.Extra)
+	row.Changed = len(report.Changed)
+	row.Details = string(details)
+	row.Error = utils.TruncateRunes(report.Error, 1000)
+	row.CheckedAt = report.CheckedAt
+	return c.db.Omit("Domain").Save(&row).Error
+}
+
+// LoadReports 读取域名在各服务商中最近一次的检测结果，按服务商ID排序
+func LoadReports(db *gorm.DB, domainID uint) ([]Report, error) {
+	var rows []models.DriftReport
+	if err := db.Where("domain_id = ?", domainID).Order("provider_id").Find(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	reports := make([]Report, 0, len(rows))
+	for _, row := range rows {
+		var report Report
+		if err := json.Unmarshal([]byte(row.Details), &report); err != nil {
+			return nil, err
+		}
+		reports = append(reports, report)
+	}
+	return reports, nil
+}
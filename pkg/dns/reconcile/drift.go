@@ -0,0 +1,248 @@
+package reconcile
+
+import (
+	"domain-max/pkg/dns/models"
+	"domain-max/pkg/dns/providers"
+	"strings"
+	"time"
+)
+
+// Item 漂移明细中的一条记录，Value为本地格式（MX、SRV记录包含优先级等字段）
+type Item struct {
+	RecordID   uint   `json:"record_id,omitempty"`   // 本地记录ID
+	ExternalID string `json:"external_id,omitempty"` // 服务商记录ID
+	Name       string `json:"name"`
+	Type       string `json:"type"`
+	Value      string `json:"value"`
+	TTL        int    `json:"ttl"`
+	Proxied    bool   `json:"proxied,omitempty"`
+}
+
+// Change 两侧内容不一致的记录
+type Change struct {
+	Local    Item     `json:"local"`
+	Upstream Item     `json:"upstream"`
+	Fields   []string `json:"fields"` // 不一致的字段
+}
+
+// Report 域名的漂移检测报告
+type Report struct {
+	DomainID   uint      `json:"domain_id"`
+	DomainName string    `json:"domain_name"`
+	ProviderID uint      `json:"provider_id"`
+	CheckedAt  time.Time `json:"checked_at"`
+	Missing    []Item    `json:"missing"`  // 本地存在但服务商中缺失
+	Extra      []Item    `json:"extra"`    // 服务商中存在但本地没有
+	Changed    []Change  `json:"changed"`  // 两侧内容不一致
+	Unsynced   []Item    `json:"unsynced"` // 本地记录等待同步或同步失败，与服务商不一致属于预期，不计入漂移
+	Error      string    `json:"error,omitempty"`
+}
+
+// InSync 两侧记录是否一致
+func (r *Report) InSync() bool {
+	return r.Error == "" && len(r.Missing) == 0 && len(r.Extra) == 0 && len(r.Changed) == 0
+}
+
+// localEntry 待匹配的本地记录
+type localEntry struct {
+	row    *models.DNSRecord
+	record providers.Record
+}
+
+// Compare 比较本地记录和服务商记录
+//
+// 依次按服务商记录ID、"名称+类型+内容"、"名称+类型"匹配。后两轮用于记录ID由内容
+// 生成的服务商（如GoDaddy、PowerDNS），这类记录在服务商控制台修改后ID会变化。
+// 服务商自动维护的SOA和根域名NS记录不参与比较，proxied为false时不比较代理状态。
+func Compare(local []models.DNSRecord, upstream []providers.Record, proxied bool) (missing, extra []Item, changed []Change) {
+	remote, matched, missing, changed := match(local, upstream, proxied)
+
+	extra = []Item{}
+	for i, r := range remote {
+		if !matched[i] {
+			extra = append(extra, upstreamItem(r))
+		}
+	}
+	return missing, extra, changed
+}
+
+// Untracked 返回服务商中存在但本地没有对应记录的记录，匹配规则与Compare相同
+//
+// tracked为本地已有对应记录的服务商记录数量，不含服务商自动维护的SOA和根域名NS记录。
+func Untracked(local []models.DNSRecord, upstream []providers.Record) (untracked []providers.Record, tracked int) {
+	remote, matched, _, _ := match(local, upstream, false)
+
+	for i, r := range remote {
+		if matched[i] {
+			tracked++
+			continue
+		}
+		untracked = append(untracked, r)
+	}
+	return untracked, tracked
+}
+
+// match 匹配本地记录和服务商记录，返回参与比较的服务商记录及其匹配状态
+//
+// 服务商不支持CDN代理（proxied为false）时忽略两侧的代理状态。
+func match(local []models.DNSRecord, upstream []providers.Record, proxied bool) (remote []providers.Record, matched []bool, missing []Item, changed []Change) {
+	missing, changed = []Item{}, []Change{}
+
+	for _, r := range upstream {
+		r.Name = normalizeName(r.Name)
+		r.Type = strings.ToUpper(r.Type)
+		r.Proxied = r.Proxied && proxied
+		if r.Type == "SOA" || (r.Type == "NS" && r.Name == "@") {
+			continue
+		}
+		remote = append(remote, r)
+	}
+	matched = make([]bool, len(remote))
+
+	byID := make(map[string]int, len(remote))
+	for i, r := range remote {
+		if r.ID != "" {
+			byID[r.ID] = i
+		}
+	}
+
+	// 第一轮：按服务商记录ID匹配
+	var pending []localEntry
+	for i := range local {
+		record := providers.FromDNSRecord(&local[i])
+		record.Name = normalizeName(record.Name)
+		record.Proxied = record.Proxied && proxied
+
+		if idx, ok := byID[record.ID]; ok && record.ID != "" && !matched[idx] {
+			matched[idx] = true
+			if fields := diffFields(record, remote[idx]); len(fields) > 0 {
+				changed = append(changed, newChange(&local[i], record, remote[idx], fields))
+			}
+			continue
+		}
+		pending = append(pending, localEntry{row: &local[i], record: record})
+	}
+
+	// 第二轮：名称、类型和内容都相同
+	var unmatched []localEntry
+	for _, entry := range pending {
+		idx := findRemote(remote, matched, func(r providers.Record) bool {
+			return sameKey(entry.record, r) && sameValue(entry.record, r)
+		})
+		if idx < 0 {
+			unmatched = append(unmatched, entry)
+			continue
+		}
+		matched[idx] = true
+		if fields := diffFields(entry.record, remote[idx]); len(fields) > 0 {
+			changed = append(changed, newChange(entry.row, entry.record, remote[idx], fields))
+		}
+	}
+
+	// 第三轮：名称和类型相同但内容不同，剩余的本地记录视为缺失
+	for _, entry := range unmatched {
+		idx := findRemote(remote, matched, func(r providers.Record) bool {
+			return sameKey(entry.record, r)
+		})
+		if idx < 0 {
+			missing = append(missing, localItem(entry.row, entry.record))
+			continue
+		}
+		matched[idx] = true
+		changed = append(changed, newChange(entry.row, entry.record, remote[idx], diffFields(entry.record, remote[idx])))
+	}
+	return remote, matched, missing, changed
+}
+
+// findRemote 返回第一条未匹配且满足条件的服务商记录下标，不存在时返回-1
+func findRemote(remote []providers.Record, matched []bool, accept func(providers.Record) bool) int {
+	for i, r := range remote {
+		if !matched[i] && accept(r) {
+			return i
+		}
+	}
+	return -1
+}
+
+// diffFields 返回两侧不一致的字段
+func diffFields(local, remote providers.Record) []string {
+	var fields []string
+	if !sameKey(local, remote) {
+		fields = append(fields, "name")
+	}
+	if !sameValue(local, remote) {
+		fields = append(fields, "value")
+	}
+	// 开启代理或自动TTL时服务商返回的TTL不反映实际配置
+	if !remote.Proxied && remote.TTL > 1 && local.TTL != remote.TTL {
+		fields = append(fields, "ttl")
+	}
+	if local.Proxied != remote.Proxied {
+		fields = append(fields, "proxied")
+	}
+	return fields
+}
+
+// sameKey 名称和类型是否相同
+func sameKey(a, b providers.Record) bool {
+	return strings.EqualFold(a.Name, b.Name) && strings.EqualFold(a.Type, b.Type)
+}
+
+// sameValue 记录内容是否相同，主机名忽略大小写和结尾的点
+func sameValue(a, b providers.Record) bool {
+	switch strings.ToUpper(a.Type) {
+	case "MX":
+		if a.Priority != b.Priority {
+			return false
+		}
+	case "SRV":
+		if a.Priority != b.Priority || a.Weight != b.Weight || a.Port != b.Port {
+			return false
+		}
+	case "TXT":
+		return a.Value == b.Value
+	}
+	return strings.EqualFold(strings.TrimSuffix(a.Value, "."), strings.TrimSuffix(b.Value, "."))
+}
+
+// normalizeName 统一根域名的表示
+func normalizeName(name string) string {
+	if name == "" {
+		return "@"
+	}
+	return strings.ToLower(name)
+}
+
+// newChange 构造不一致记录
+func newChange(row *models.DNSRecord, local, remote providers.Record, fields []string) Change {
+	return Change{
+		Local:    localItem(row, local),
+		Upstream: upstreamItem(remote),
+		Fields:   fields,
+	}
+}
+
+// localItem 将本地记录转换为明细
+func localItem(row *models.DNSRecord, record providers.Record) Item {
+	return Item{
+		RecordID:   row.ID,
+		ExternalID: row.ExternalID,
+		Name:       record.Name,
+		Type:       record.Type,
+		Value:      row.Value,
+		TTL:        row.TTL,
+		Proxied:    row.Proxied,
+	}
+}
+
+// upstreamItem 将服务商记录转换为明细
+func upstreamItem(record providers.Record) Item {
+	return Item{
+		ExternalID: record.ID,
+		Name:       record.Name,
+		Type:       record.Type,
+		Value:      providers.RecordValue(record),
+		TTL:        record.TTL,
+		Proxied:    record.Proxied,
+	}
+}
@@ -0,0 +1,130 @@
+package reconcile
+
+import (
+	"testing"
+
+	"domain-max/pkg/dns/models"
+	"domain-max/pkg/dns/providers"
+)
+
+func TestUntracked(t *testing.T) {
+	local := []models.DNSRecord{
+		{ID: 1, Subdomain: "www", Type: "A", Value: "1.1.1.1", TTL: 600, ExternalID: "rec-1"},
+		{ID: 2, Subdomain: "@", Type: "MX", Value: "10 mail.example.com", TTL: 600},
+	}
+
+	tests := []struct {
+		name      string
+		upstream  []providers.Record
+		untracked []string
+		tracked   int
+	}{
+		{
+			name: "server records are neither imported nor skipped",
+			upstream: []providers.Record{
+				{Name: "@", Type: "SOA", Value: "ns1.example.com. admin.example.com. 1 10800 3600 604800 3600"},
+				{Name: "@", Type: "NS", Value: "ns1.example.com"},
+				{Name: "@", Type: "NS", Value: "ns2.example.com"},
+			},
+		},
+		{
+			name: "matched by id and content",
+			upstream: []providers.Record{
+				{ID: "rec-1", Name: "www", Type: "A", Value: "1.1.1.1", TTL: 600},
+				{ID: "mx", Name: "@", Type: "MX", Value: "mail.example.com.", Priority: 10, TTL: 600},
+				{ID: "soa", Name: "@", Type: "SOA", Value: "ns1.example.com. admin.example.com. 1 10800 3600 604800 3600"},
+			},
+			tracked: 2,
+		},
+		{
+			name: "untracked",
+			upstream: []providers.Record{
+				{ID: "rec-1", Name: "www", Type: "A", Value: "1.1.1.1", TTL: 600},
+				{ID: "api", Name: "api", Type: "A", Value: "2.2.2.2", TTL: 600},
+				{ID: "sub-ns", Name: "sub", Type: "NS", Value: "ns.other.com", TTL: 600},
+			},
+			untracked: []string{"api", "sub-ns"},
+			tracked:   1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			untracked, tracked := Untracked(local, tt.upstream)
+			if tracked != tt.tracked {
+				t.Fatalf("已有对应记录的数量为 %d，应为 %d", tracked, tt.tracked)
+			}
+			if len(untracked) != len(tt.untracked) {
+				t.Fatalf("未导入的记录为 %+v", untracked)
+			}
+			for i, id := range tt.untracked {
+				if untracked[i].ID != id {
+					t.Fatalf("未导入的记录为 %+v", untracked)
+				}
+			}
+		})
+	}
+}
+
+func TestCompareProxied(t *testing.T) {
+	local := []models.DNSRecord{
+		{ID: 1, Subdomain: "www", Type: "A", Value: "1.1.1.1", TTL: 600, Proxied: true, ExternalID: "rec-1"},
+	}
+	upstream := []providers.Record{
+		{ID: "rec-1", Name: "www", Type: "A", Value: "1.1.1.1", TTL: 600},
+	}
+
+	tests := []struct {
+		name    string
+		proxied bool
+		changed bool
+	}{
+		{name: "provider supports proxy", proxied: true, changed: true},
+		{name: "provider without proxy", proxied: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			missing, extra, changed := Compare(local, upstream, tt.proxied)
+			if len(missing) != 0 || len(extra) != 0 {
+				t.Fatalf("缺失 %+v，多出 %+v", missing, extra)
+			}
+			if (len(changed) > 0) != tt.changed {
+				t.Fatalf("不一致的记录为 %+v", changed)
+			}
+			if tt.changed && (len(changed[0].Fields) != 1 || changed[0].Fields[0] != "proxied") {
+				t.Fatalf("不一致的字段为 %v", changed[0].Fields)
+			}
+		})
+	}
+}
+
+func TestSeparateUnsynced(t *testing.T) {
+	local := []models.DNSRecord{
+		{ID: 1, Subdomain: "synced", Type: "A", Value: "1.1.1.1", TTL: 600},
+		{ID: 2, Subdomain: "pending", Type: "A", Value: "2.2.2.2", TTL: 600},
+		{ID: 3, Subdomain: "failed", Type: "A", Value: "3.3.3.3", TTL: 600},
+		{ID: 4, Subdomain: "updating", Type: "A", Value: "4.4.4.4", TTL: 600},
+	}
+	upstream := []providers.Record{
+		{Name: "updating", Type: "A", Value: "9.9.9.9", TTL: 600},
+	}
+
+	report := &Report{Unsynced: []Item{}}
+	report.Missing, report.Extra, report.Changed = Compare(local, upstream, false)
+	separateUnsynced(report, map[uint]bool{2: true, 3: true, 4: true})
+
+	if len(report.Missing) != 1 || report.Missing[0].RecordID != 1 {
+		t.Fatalf("缺失的记录为 %+v", report.Missing)
+	}
+	if len(report.Changed) != 0 {
+		t.Fatalf("不一致的记录为 %+v", report.Changed)
+	}
+	var ids []uint
+	for _, item := range report.Unsynced {
+		ids = append(ids, item.RecordID)
+	}
+	if len(ids) != 3 || ids[0] != 2 || ids[1] != 3 || ids[2] != 4 {
+		t.Fatalf("未同步的记录为 %v", ids)
+	}
+}
@@ -0,0 +1,170 @@
+package syncqueue
+
+import (
+	"domain-max/pkg/dns/models"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ErrNoFailedJob 记录没有失败的同步任务
+var ErrNoFailedJob = errors.New("该记录没有失败的同步任务")
+
+// defaultMaxAttempts 同步任务的最大执行次数
+const defaultMaxAttempts = 8
+
+// wake 通知后台任务有新的同步任务
+var wake = make(chan struct{}, 1)
+
+// Notify 唤醒后台任务立即处理同步任务，应在写入任务的事务提交后调用
+func Notify() {
+	select {
+	case wake <- struct{}{}:
+	default:
+	}
+}
+
+// Enqueue 为域名绑定的每个DNS服务商写入记录变更对应的同步任务，应与记录变更在同一事务中调用
+//
+// old为更新前的记录，仅在更新时使用。域名未绑定服务商时不写入任务，记录直接视为已同步。
+func Enqueue(tx *gorm.DB, domain *models.Domain, action string, record, old *models.DNSRecord) error {
+	providerIDs := domain.ProviderIDs()
+	if len(providerIDs) == 0 {
+		if action == models.SyncActionDelete {
+			return nil
+		}
+		now := time.Now()
+		record.Status = models.RecordStatusSynced
+		record.SyncError = ""
+		record.SyncedAt = &now
+		return tx.Model(record).UpdateColumns(map[string]interface{}{
+			"status":     record.Status,
+			"sync_error": "",
+			"synced_at":  now,
+		}).Error
+	}
+
+	for _, providerID := range providerIDs {
+		if err := EnqueueFor(tx, providerID, action, record, old); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// EnqueueFor 为指定DNS服务商写入记录变更对应的同步任务
+//
+// 该服务商已有等待执行的创建或更新任务时不再重复写入更新任务，执行时总是同步记录的最新内容。
+func EnqueueFor(tx *gorm.DB, providerID uint, action string, record, old *models.DNSRecord) error {
+	if action == models.SyncActionUpdate {
+		var count int64
+		if err := tx.Model(&models.SyncJob{}).
+			Where("record_id = ? AND provider_id = ? AND status = ? AND action IN ?",
+				record.ID, providerID, models.SyncJobPending, []string{models.SyncActionCreate, models.SyncActionUpdate}).
+			Count(&count).Error; err != nil {
+			return err
+		}
+		if count > 0 {
+			return markPending(tx, record, providerID)
+		}
+	}
+
+	snapshot := record
+	if action == models.SyncActionUpdate {
+		snapshot = old
+	}
+	data, err := json.Marshal(snapshot)
+	if err != nil {
+		return err
+	}
+
+	job := models.SyncJob{
+		RecordID:    record.ID,
+		DomainID:    record.DomainID,
+		ProviderID:  providerID,
+		Action:      action,
+		Snapshot:    string(data),
+		Status:      models.SyncJobPending,
+		MaxAttempts: defaultMaxAttempts,
+		NextRunAt:   time.Now(),
+	}
+	if err := tx.Create(&job).Error; err != nil {
+		return err
+	}
+
+	if action == models.SyncActionDelete {
+		return nil
+	}
+	return markPending(tx, record, providerID)
+}
+
+// Unbind 域名解除与DNS服务商的绑定时，取消未完成的任务并移除记录在该服务商中的同步状态
+//
+// 调用方需确保域名下没有已同步到该服务商的记录。
+func Unbind(tx *gorm.DB, domain *models.Domain, providerID uint) error {
+	if err := tx.Model(&models.SyncJob{}).
+		Where("domain_id = ? AND provider_id = ? AND status IN ?", domain.ID, providerID, []string{models.SyncJobPending, models.SyncJobFailed}).
+		Updates(map[string]interface{}{"status": models.SyncJobDone, "last_error": "域名已解除与该DNS服务商的绑定"}).Error; err != nil {
+		return err
+	}
+
+	var recordIDs []uint
+	if err := tx.Model(&models.DNSRecord{}).Where("domain_id = ?", domain.ID).Pluck("id", &recordIDs).Error; err != nil {
+		return err
+	}
+	if len(recordIDs) == 0 {
+		return nil
+	}
+	if err := tx.Where("provider_id = ? AND record_id IN ?", providerID, recordIDs).Delete(&models.RecordSync{}).Error; err != nil {
+		return err
+	}
+	for _, id := range recordIDs {
+		if err := refreshStatus(tx, id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Retry 重新执行记录中失败的同步任务
+func Retry(db *gorm.DB, record *models.DNSRecord) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.SyncJob{}).
+			Where("record_id = ? AND status = ?", record.ID, models.SyncJobFailed).
+			Updates(map[string]interface{}{
+				"status":      models.SyncJobPending,
+				"attempts":    0,
+				"next_run_at": time.Now(),
+				"locked_at":   nil,
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrNoFailedJob
+		}
+
+		if err := tx.Model(&models.RecordSync{}).
+			Where("record_id = ? AND status = ?", record.ID, models.RecordStatusFailed).
+			Update("status", models.RecordStatusPending).Error; err != nil {
+			return err
+		}
+		record.Status = models.RecordStatusPending
+		return tx.Model(record).UpdateColumn("status", record.Status).Error
+	})
+}
+
+// markPending 将记录及其在指定服务商中的同步状态标记为等待同步
+func markPending(tx *gorm.DB, record *models.DNSRecord, providerID uint) error {
+	var state models.RecordSync
+	if err := tx.Where(models.RecordSync{RecordID: record.ID, ProviderID: providerID}).
+		Assign(models.RecordSync{Status: models.RecordStatusPending}).
+		FirstOrCreate(&state).Error; err != nil {
+		return err
+	}
+
+	record.Status = models.RecordStatusPending
+	return tx.Model(record).UpdateColumn("status", record.Status).Error
+}
@@ -0,0 +1,335 @@
+package syncqueue
+
+import (
+	"context"
+	"domain-max/pkg/dns/models"
+	"domain-max/pkg/dns/providers"
+	"domain-max/pkg/jobqueue"
+	"domain-max/pkg/utils"
+	"encoding/json"
+	"errors"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	// pollInterval 轮询同步任务的间隔
+	pollInterval = 5 * time.Second
+	// batchSize 每次读取的同步任务数量
+	batchSize = 20
+	// jobTimeout 单个同步任务的超时时间
+	jobTimeout = 30 * time.Second
+)
+
+// Worker 同步任务执行器，将DNS记录的变更同步到DNS服务商
+//
+// 同一记录在同一服务商中的任务按写入顺序执行，前一个任务未完成时后面的任务等待；
+// 不同服务商的任务互不影响，其中一个服务商不可用时其余服务商照常同步。
+// 任务通过条件更新认领，多个实例同时运行时不会重复执行。
+type Worker struct {
+	db *gorm.DB
+}
+
+// NewWorker 创建同步任务执行器
+func NewWorker(db *gorm.DB) *Worker {
+	return &Worker{db: db}
+}
+
+// Run 持续处理到期的同步任务，直到ctx取消
+func (w *Worker) Run(ctx context.Context) {
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	for {
+		w.RunPending(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-wake:
+		}
+	}
+}
+
+// RunPending 执行全部到期的同步任务
+func (w *Worker) RunPending(ctx context.Context) {
+	w.recoverStale()
+
+	var lastID uint
+	for ctx.Err() == nil {
+		var jobs []models.SyncJob
+		if err := jobqueue.FindDue(w.db, &jobs, lastID, batchSize); err != nil {
+			log.Printf("记录同步：查询同步任务失败: %v", err)
+			return
+		}
+
+		for i := range jobs {
+			if ctx.Err() != nil {
+				return
+			}
+			lastID = jobs[i].ID
+			ok, err := w.claim(&jobs[i])
+			if err != nil {
+				log.Printf("记录同步：认领任务 %d 失败: %v", jobs[i].ID, err)
+				continue
+			}
+			if !ok {
+				continue
+			}
+			w.process(ctx, &jobs[i])
+		}
+
+		if len(jobs) < batchSize {
+			return
+		}
+	}
+}
+
+// recoverStale 将中断的任务重新放回队列
+func (w *Worker) recoverStale() {
+	if err := jobqueue.RecoverStale(w.db, &models.SyncJob{}); err != nil {
+		log.Printf("记录同步：回收中断的任务失败: %v", err)
+	}
+}
+
+// claim 认领任务，同一记录在同一服务商中存在更早的未完成任务或已被其他实例认领时返回false
+func (w *Worker) claim(job *models.SyncJob) (bool, error) {
+	var earlier int64
+	if err := w.db.Model(&models.SyncJob{}).
+		Where("record_id = ? AND provider_id = ? AND id < ? AND status IN ?",
+			job.RecordID, job.ProviderID, job.ID, []string{models.SyncJobPending, models.SyncJobRunning}).
+		Count(&earlier).Error; err != nil {
+		return false, err
+	}
+	if earlier > 0 {
+		return false, nil
+	}
+
+	now, ok, err := jobqueue.Claim(w.db, &models.SyncJob{}, job.ID)
+	if err != nil || !ok {
+		return false, err
+	}
+	job.Status = models.SyncJobRunning
+	job.Attempts++
+	job.LockedAt = &now
+	return true, nil
+}
+
+// process 执行任务并保存结果
+func (w *Worker) process(ctx context.Context, job *models.SyncJob) {
+	var record models.DNSRecord
+	if err := w.db.Unscoped().First(&record, job.RecordID).Error; err != nil {
+		w.fail(job, nil, nil, err)
+		return
+	}
+	var domain models.Domain
+	if err := w.db.Unscoped().First(&domain, job.DomainID).Error; err != nil {
+		w.fail(job, &record, nil, err)
+		return
+	}
+
+	// 早期的任务未记录服务商，按域名的主服务商处理
+	providerID := job.ProviderID
+	if providerID == 0 {
+		if domain.ProviderID == nil {
+			w.succeed(job, &record, nil)
+			return
+		}
+		providerID = *domain.ProviderID
+	}
+
+	var state models.RecordSync
+	if err := w.db.Where(models.RecordSync{RecordID: record.ID, ProviderID: providerID}).FirstOrInit(&state).Error; err != nil {
+		w.fail(job, &record, nil, err)
+		return
+	}
+	// 主服务商的记录ID同时保存在记录中
+	if state.ID == 0 && domain.ProviderID != nil && *domain.ProviderID == providerID {
+		state.ExternalID = record.ExternalID
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, jobTimeout)
+	defer cancel()
+
+	externalID, err := w.execute(ctx, job, &domain, providerID, &record, state.ExternalID)
+	if err != nil {
+		w.fail(job, &record, &state, err)
+		return
+	}
+	state.ExternalID = externalID
+	w.succeed(job, &record, &state)
+}
+
+// execute 将任务同步到DNS服务商，externalID为记录在该服务商中的ID，返回同步后的ID
+func (w *Worker) execute(ctx context.Context, job *models.SyncJob, domain *models.Domain, providerID uint, record *models.DNSRecord, externalID string) (string, error) {
+	// 域名已解除与该服务商的绑定，只需删除服务商中已有的记录
+	bound := false
+	for _, id := range domain.ProviderIDs() {
+		bound = bound || id == providerID
+	}
+	if !bound && job.Action != models.SyncActionDelete {
+		return externalID, nil
+	}
+
+	provider, err := providers.OpenByID(w.db, providerID)
+	if err != nil {
+		return "", err
+	}
+	zone := providers.ZoneForProvider(domain, providerID)
+
+	current := *record
+	current.ExternalID = externalID
+
+	switch job.Action {
+	case models.SyncActionCreate:
+		// 记录已删除或已由其他任务创建
+		if record.DeletedAt.Valid || externalID != "" {
+			return externalID, nil
+		}
+		return provider.CreateRecord(ctx, zone, providers.FromDNSRecord(&current))
+
+	case models.SyncActionUpdate:
+		// 记录已删除，由删除任务处理
+		if record.DeletedAt.Valid {
+			return externalID, nil
+		}
+		if externalID == "" {
+			return provider.CreateRecord(ctx, zone, providers.FromDNSRecord(&current))
+		}
+		var old models.DNSRecord
+		if err := json.Unmarshal([]byte(job.Snapshot), &old); err != nil {
+			return "", err
+		}
+		// 服务商中的记录ID以当前保存的为准，快照写入时可能尚未创建
+		old.ExternalID = externalID
+		return provider.UpdateRecord(ctx, zone, providers.FromDNSRecord(&old), providers.FromDNSRecord(&current))
+
+	case models.SyncActionDelete:
+		if externalID == "" {
+			return "", nil
+		}
+		err := provider.DeleteRecord(ctx, zone, providers.FromDNSRecord(&current))
+		if err != nil && !errors.Is(err, providers.ErrRecordNotFound) {
+			return "", err
+		}
+		return externalID, nil
+	}
+	return "", errors.New("未知的同步操作: " + job.Action)
+}
+
+// succeed 保存成功的任务，state为空时只更新任务状态
+func (w *Worker) succeed(job *models.SyncJob, record *models.DNSRecord, state *models.RecordSync) {
+	err := w.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(job).Updates(map[string]interface{}{
+			"status":     models.SyncJobDone,
+			"last_error": "",
+			"locked_at":  nil,
+		}).Error; err != nil {
+			return err
+		}
+		if state == nil {
+			return refreshStatus(tx, record.ID)
+		}
+
+		// 该服务商还有未完成的任务时保持等待同步
+		var remaining int64
+		if err := tx.Model(&models.SyncJob{}).
+			Where("record_id = ? AND provider_id = ? AND status IN ?",
+				record.ID, job.ProviderID, []string{models.SyncJobPending, models.SyncJobRunning}).
+			Count(&remaining).Error; err != nil {
+			return err
+		}
+		if remaining == 0 {
+			now := time.Now()
+			state.Status = models.RecordStatusSynced
+			state.SyncError = ""
+			state.SyncedAt = &now
+		}
+		if err := tx.Save(state).Error; err != nil {
+			return err
+		}
+
+		var domain models.Domain
+		if err := tx.Unscoped().First(&domain, record.DomainID).Error; err != nil {
+			return err
+		}
+		if domain.ProviderID != nil && *domain.ProviderID == state.ProviderID {
+			if err := tx.Unscoped().Model(record).UpdateColumn("external_id", state.ExternalID).Error; err != nil {
+				return err
+			}
+		}
+		return refreshStatus(tx, record.ID)
+	})
+	if err != nil {
+		log.Printf("记录同步：保存任务 %d 的结果失败: %v", job.ID, err)
+	}
+}
+
+// fail 保存失败的任务，未达到最大执行次数时按指数退避重试
+func (w *Worker) fail(job *models.SyncJob, record *models.DNSRecord, state *models.RecordSync, cause error) {
+	msg := utils.TruncateRunes(cause.Error(), 1000)
+	updates, exhausted := jobqueue.Failure(job.Attempts, job.MaxAttempts, msg)
+
+	err := w.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(job).Updates(updates).Error; err != nil {
+			return err
+		}
+		if record == nil {
+			return nil
+		}
+		if state != nil {
+			state.SyncError = msg
+			if exhausted {
+				state.Status = models.RecordStatusFailed
+			}
+			if err := tx.Save(state).Error; err != nil {
+				return err
+			}
+		}
+		if err := tx.Unscoped().Model(record).UpdateColumn("sync_error", msg).Error; err != nil {
+			return err
+		}
+		if state == nil && exhausted {
+			return tx.Unscoped().Model(record).UpdateColumn("status", models.RecordStatusFailed).Error
+		}
+		return refreshStatus(tx, record.ID)
+	})
+	if err != nil {
+		log.Printf("记录同步：保存任务 %d 的结果失败: %v", job.ID, err)
+	}
+	if exhausted {
+		log.Printf("记录同步：任务 %d 已达到最大执行次数: %s", job.ID, msg)
+	}
+}
+
+// refreshStatus 按各服务商的同步状态汇总记录的同步状态
+//
+// 任一服务商同步失败时记录为失败，否则任一服务商等待同步时记录为等待同步。
+func refreshStatus(tx *gorm.DB, recordID uint) error {
+	var states []models.RecordSync
+	if err := tx.Where("record_id = ?", recordID).Find(&states).Error; err != nil {
+		return err
+	}
+
+	status := models.RecordStatusSynced
+	for _, state := range states {
+		switch state.Status {
+		case models.RecordStatusFailed:
+			status = models.RecordStatusFailed
+		case models.RecordStatusPending:
+			if status != models.RecordStatusFailed {
+				status = models.RecordStatusPending
+			}
+		}
+	}
+
+	updates := map[string]interface{}{"status": status}
+	if status == models.RecordStatusSynced {
+		updates["sync_error"] = ""
+		updates["synced_at"] = time.Now()
+	}
+	return tx.Unscoped().Model(&models.DNSRecord{}).Where("id = ?", recordID).UpdateColumns(updates).Error
+}
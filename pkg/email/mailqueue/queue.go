@@ -0,0 +1,100 @@
+package mailqueue
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"domain-max/pkg/email"
+	"domain-max/pkg/email/models"
+	"domain-max/pkg/utils"
+
+	"gorm.io/gorm"
+)
+
+// defaultMaxAttempts 邮件任务的最大执行次数
+const defaultMaxAttempts = 6
+
+// FinishedRetention 已发送和已失败的邮件及其投递记录的保留时间
+const FinishedRetention = 30 * 24 * time.Hour
+
+// wake 通知后台任务有新的邮件
+var wake = make(chan struct{}, 1)
+
+// Notify 唤醒后台任务立即发送邮件，应在写入邮件的事务提交后调用
+func Notify() {
+	select {
+	case wake <- struct{}{}:
+	default:
+	}
+}
+
+// Enqueue 写入待发送的邮件，应与触发发送的数据变更在同一事务中调用
+func Enqueue(tx *gorm.DB, msg *email.Message) (*models.MailJob, error) {
+	return enqueue(tx, msg, "")
+}
+
+// EnqueueTemplate 使用指定语言渲染模板并写入待发送的邮件
+func EnqueueTemplate(tx *gorm.DB, to, name, locale string, data interface{}) (*models.MailJob, error) {
+	msg, err := email.Render(name, locale, data)
+	if err != nil {
+		return nil, err
+	}
+	msg.To = to
+	return enqueue(tx, msg, name)
+}
+
+// enqueue 写入邮件任务，template为渲染使用的模板名称
+func enqueue(tx *gorm.DB, msg *email.Message, template string) (*models.MailJob, error) {
+	job := models.MailJob{
+		Recipient:   msg.To,
+		Subject:     utils.TruncateRunes(msg.Subject, 255),
+		TextBody:    msg.Text,
+		HTMLBody:    msg.HTML,
+		Template:    template,
+		Status:      models.MailJobPending,
+		MaxAttempts: defaultMaxAttempts,
+		NextRunAt:   time.Now(),
+	}
+	if err := tx.Create(&job).Error; err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+// PurgeFinished 删除完成时间早于before的已发送和已失败的邮件及其投递记录
+//
+// 同时清除其余已完成邮件中残留的正文，正文中可能包含仍然有效的验证或重置链接。
+func PurgeFinished(db *gorm.DB, before time.Time) error {
+	finished := []string{models.MailJobSent, models.MailJobFailed}
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.MailJob{}).
+			Where("status IN ? AND (text_body <> ? OR html_body <> ?)", finished, "", "").
+			UpdateColumns(map[string]interface{}{"text_body": "", "html_body": ""}).Error; err != nil {
+			return err
+		}
+
+		expired := tx.Model(&models.MailJob{}).Select("id").Where("status IN ? AND updated_at < ?", finished, before)
+		if err := tx.Where("job_id IN (?)", expired).Delete(&models.MailDelivery{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("status IN ? AND updated_at < ?", finished, before).Delete(&models.MailJob{}).Error
+	})
+}
+
+// RunCleanup 定期删除超过保留期的已完成邮件，直到ctx取消
+func RunCleanup(ctx context.Context, db *gorm.DB, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := PurgeFinished(db, time.Now().Add(-FinishedRetention)); err != nil {
+				log.Printf("邮件队列：清理已完成的邮件失败: %v", err)
+			}
+		}
+	}
+}
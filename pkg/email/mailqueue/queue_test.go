@@ -0,0 +1,144 @@
+package mailqueue
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"domain-max/pkg/config"
+	"domain-max/pkg/database"
+	"domain-max/pkg/email"
+	"domain-max/pkg/email/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// newTestDB 创建已执行全部迁移的SQLite内存数据库
+func newTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+
+	db, err := database.Connect(&config.Config{DBType: "sqlite", DBPath: ":memory:"})
+	if err != nil {
+		t.Fatalf("连接数据库失败: %v", err)
+	}
+	db.Logger = logger.Default.LogMode(logger.Silent)
+	if err := database.Migrate(db); err != nil {
+		t.Fatalf("数据库迁移失败: %v", err)
+	}
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+	return db
+}
+
+// enqueueTest 写入一封测试邮件
+func enqueueTest(t *testing.T, db *gorm.DB, to string) *models.MailJob {
+	t.Helper()
+
+	job, err := Enqueue(db, &email.Message{
+		To:      to,
+		Subject: "验证邮箱",
+		Text:    "https://example.com/verify-email?token=secret",
+		HTML:    `<a href="https://example.com/verify-email?token=secret">验证</a>`,
+	})
+	if err != nil {
+		t.Fatalf("写入邮件失败: %v", err)
+	}
+	return job
+}
+
+func TestWorkerClearsBodyWhenExhausted(t *testing.T) {
+	tests := []struct {
+		name        string
+		maxAttempts int
+		status      string
+		keepBody    bool
+	}{
+		{name: "retry", maxAttempts: 2, status: models.MailJobPending, keepBody: true},
+		{name: "exhausted", maxAttempts: 1, status: models.MailJobFailed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := newTestDB(t)
+			job := enqueueTest(t, db, "user@example.com")
+			db.Model(job).Update("max_attempts", tt.maxAttempts)
+
+			// 没有启用的SMTP配置，发送失败
+			w := NewWorker(db, 1)
+			jobs := make(chan *models.MailJob, 1)
+			w.dispatch(context.Background(), jobs)
+			w.process(<-jobs)
+
+			var got models.MailJob
+			if err := db.First(&got, job.ID).Error; err != nil {
+				t.Fatalf("查询邮件失败: %v", err)
+			}
+			if got.Status != tt.status || got.Attempts != 1 || got.LastError == "" {
+				t.Fatalf("邮件任务为 %+v", got)
+			}
+			if hasBody := got.TextBody != "" && got.HTMLBody != ""; hasBody != tt.keepBody {
+				t.Fatalf("邮件正文保留为 %v，应为 %v", hasBody, tt.keepBody)
+			}
+		})
+	}
+}
+
+func TestPurgeFinished(t *testing.T) {
+	db := newTestDB(t)
+	now := time.Now()
+	old := now.Add(-2 * FinishedRetention)
+
+	tests := []struct {
+		name      string
+		status    string
+		updatedAt time.Time
+		kept      bool
+	}{
+		{name: "old sent", status: models.MailJobSent, updatedAt: old},
+		{name: "old failed", status: models.MailJobFailed, updatedAt: old},
+		{name: "recent sent", status: models.MailJobSent, updatedAt: now, kept: true},
+		{name: "old pending", status: models.MailJobPending, updatedAt: old, kept: true},
+	}
+
+	ids := make([]uint, len(tests))
+	for i, tt := range tests {
+		job := enqueueTest(t, db, tt.name+"@example.com")
+		ids[i] = job.ID
+		if err := db.Model(job).UpdateColumns(map[string]interface{}{"status": tt.status, "updated_at": tt.updatedAt}).Error; err != nil {
+			t.Fatalf("更新邮件失败: %v", err)
+		}
+		if err := db.Create(&models.MailDelivery{JobID: job.ID, Attempt: 1, Status: models.DeliverySent}).Error; err != nil {
+			t.Fatalf("写入投递记录失败: %v", err)
+		}
+	}
+
+	if err := PurgeFinished(db, now.Add(-FinishedRetention)); err != nil {
+		t.Fatalf("清理邮件失败: %v", err)
+	}
+
+	for i, tt := range tests {
+		var job models.MailJob
+		err := db.First(&job, ids[i]).Error
+		if kept := err == nil; kept != tt.kept {
+			t.Errorf("%s: 邮件保留为 %v，应为 %v", tt.name, kept, tt.kept)
+			continue
+		}
+
+		var deliveries int64
+		db.Model(&models.MailDelivery{}).Where("job_id = ?", ids[i]).Count(&deliveries)
+		if (deliveries > 0) != tt.kept {
+			t.Errorf("%s: 剩余 %d 条投递记录", tt.name, deliveries)
+		}
+		if !tt.kept {
+			continue
+		}
+		// 已完成的邮件不保留正文，等待发送的邮件不受影响
+		if hasBody := job.TextBody != ""; hasBody != (tt.status == models.MailJobPending) {
+			t.Errorf("%s: 邮件正文保留为 %v", tt.name, hasBody)
+		}
+	}
+}
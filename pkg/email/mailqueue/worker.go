@@ -0,0 +1,221 @@
+package mailqueue
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"domain-max/pkg/email"
+	"domain-max/pkg/email/models"
+	"domain-max/pkg/jobqueue"
+	"domain-max/pkg/utils"
+
+	"gorm.io/gorm"
+)
+
+const (
+	// pollInterval 轮询邮件任务的间隔
+	pollInterval = 5 * time.Second
+	// batchSize 每次读取的邮件任务数量
+	batchSize = 20
+)
+
+// Worker 邮件发送器，将队列中的邮件通过SMTP发送
+//
+// 每次执行按优先级依次尝试启用的SMTP配置，直到其中一个发送成功；全部失败时按指数退避重试。
+// 每次尝试都写入投递记录。任务通过条件更新认领，多个实例同时运行时不会重复发送。
+type Worker struct {
+	db      *gorm.DB
+	workers int
+}
+
+// NewWorker 创建邮件发送器，workers为并发发送的数量
+func NewWorker(db *gorm.DB, workers int) *Worker {
+	if workers < 1 {
+		workers = 1
+	}
+	return &Worker{db: db, workers: workers}
+}
+
+// Run 持续发送到期的邮件，直到ctx取消
+func (w *Worker) Run(ctx context.Context) {
+	jobs := make(chan *models.MailJob)
+	var wg sync.WaitGroup
+	for i := 0; i < w.workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for job := range jobs {
+				w.process(job)
+			}
+		}()
+	}
+	defer func() {
+		close(jobs)
+		wg.Wait()
+	}()
+
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	for {
+		w.dispatch(ctx, jobs)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-wake:
+		}
+	}
+}
+
+// dispatch 认领全部到期的邮件任务并交给发送协程
+func (w *Worker) dispatch(ctx context.Context, jobs chan<- *models.MailJob) {
+	w.recoverStale()
+
+	var lastID uint
+	for ctx.Err() == nil {
+		var pending []models.MailJob
+		if err := jobqueue.FindDue(w.db, &pending, lastID, batchSize); err != nil {
+			log.Printf("邮件队列：查询邮件任务失败: %v", err)
+			return
+		}
+
+		for i := range pending {
+			job := &pending[i]
+			lastID = job.ID
+			ok, err := w.claim(job)
+			if err != nil {
+				log.Printf("邮件队列：认领任务 %d 失败: %v", job.ID, err)
+				continue
+			}
+			if !ok {
+				continue
+			}
+
+			select {
+			case jobs <- job:
+			case <-ctx.Done():
+				w.release(job)
+				return
+			}
+		}
+
+		if len(pending) < batchSize {
+			return
+		}
+	}
+}
+
+// recoverStale 将中断的任务重新放回队列
+func (w *Worker) recoverStale() {
+	if err := jobqueue.RecoverStale(w.db, &models.MailJob{}); err != nil {
+		log.Printf("邮件队列：回收中断的任务失败: %v", err)
+	}
+}
+
+// claim 认领任务，已被其他实例认领时返回false
+func (w *Worker) claim(job *models.MailJob) (bool, error) {
+	now, ok, err := jobqueue.Claim(w.db, &models.MailJob{}, job.ID)
+	if err != nil || !ok {
+		return false, err
+	}
+	job.Status = models.MailJobRunning
+	job.Attempts++
+	job.LockedAt = &now
+	return true, nil
+}
+
+// release 将已认领但未发送的任务放回队列，不计入执行次数
+func (w *Worker) release(job *models.MailJob) {
+	if err := jobqueue.Release(w.db, &models.MailJob{}, job.ID); err != nil {
+		log.Printf("邮件队列：释放任务 %d 失败: %v", job.ID, err)
+	}
+}
+
+// process 按优先级依次尝试启用的SMTP配置发送邮件并保存结果
+func (w *Worker) process(job *models.MailJob) {
+	configs, err := email.ActiveConfigs(w.db)
+	if err != nil {
+		w.fail(job, err.Error())
+		return
+	}
+
+	msg := &email.Message{
+		To:      job.Recipient,
+		Subject: job.Subject,
+		Text:    job.TextBody,
+		HTML:    job.HTMLBody,
+	}
+
+	var errs []string
+	for i := range configs {
+		config := &configs[i]
+		start := time.Now()
+		err := email.Send(config, msg)
+		w.logDelivery(job, config, time.Since(start), err)
+		if err == nil {
+			w.succeed(job, config)
+			return
+		}
+		errs = append(errs, fmt.Sprintf("%s: %v", config.Name, err))
+	}
+	w.fail(job, strings.Join(errs, "; "))
+}
+
+// logDelivery 写入一次投递尝试的记录
+func (w *Worker) logDelivery(job *models.MailJob, config *models.SMTPConfig, duration time.Duration, cause error) {
+	delivery := models.MailDelivery{
+		JobID:          job.ID,
+		Attempt:        job.Attempts,
+		SMTPConfigID:   config.ID,
+		SMTPConfigName: config.Name,
+		Recipient:      job.Recipient,
+		Status:         models.DeliverySent,
+		DurationMs:     duration.Milliseconds(),
+	}
+	if cause != nil {
+		delivery.Status = models.DeliveryFailed
+		delivery.Error = utils.TruncateRunes(cause.Error(), 1000)
+	}
+	if err := w.db.Create(&delivery).Error; err != nil {
+		log.Printf("邮件队列：保存任务 %d 的投递记录失败: %v", job.ID, err)
+	}
+}
+
+// succeed 保存发送成功的任务，邮件正文中可能包含验证或重置链接，发送后即清除
+func (w *Worker) succeed(job *models.MailJob, config *models.SMTPConfig) {
+	err := w.db.Model(job).Updates(map[string]interface{}{
+		"status":         models.MailJobSent,
+		"last_error":     "",
+		"smtp_config_id": config.ID,
+		"sent_at":        time.Now(),
+		"locked_at":      nil,
+		"text_body":      "",
+		"html_body":      "",
+	}).Error
+	if err != nil {
+		log.Printf("邮件队列：保存任务 %d 的结果失败: %v", job.ID, err)
+	}
+}
+
+// fail 保存发送失败的任务，未达到最大执行次数时按指数退避重试，不再重试的任务清除邮件正文
+func (w *Worker) fail(job *models.MailJob, cause string) {
+	msg := utils.TruncateRunes(cause, 1000)
+	updates, exhausted := jobqueue.Failure(job.Attempts, job.MaxAttempts, msg)
+	if exhausted {
+		updates["text_body"] = ""
+		updates["html_body"] = ""
+	}
+
+	if err := w.db.Model(job).Updates(updates).Error; err != nil {
+		log.Printf("邮件队列：保存任务 %d 的结果失败: %v", job.ID, err)
+	}
+	if exhausted {
+		log.Printf("邮件队列：发送给 %s 的邮件 %d 已达到最大执行次数: %s", job.Recipient, job.ID, msg)
+	}
+}
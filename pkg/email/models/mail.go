@@ -0,0 +1,52 @@
+package models
+
+import "time"
+
+// 邮件任务的状态
+const (
+	MailJobPending = "pending" // 等待发送或等待重试
+	MailJobRunning = "running" // 发送中
+	MailJobSent    = "sent"    // 发送成功
+	MailJobFailed  = "failed"  // 达到最大重试次数
+)
+
+// 单次投递的结果
+const (
+	DeliverySent   = "sent"
+	DeliveryFailed = "failed"
+)
+
+// MailJob 待发送的邮件，写入时已渲染好内容，由后台任务发送
+type MailJob struct {
+	ID           uint       `json:"id" gorm:"primaryKey"`
+	Recipient    string     `json:"recipient" gorm:"not null;size:255;index"`
+	Subject      string     `json:"subject" gorm:"not null;size:255"`
+	TextBody     string     `json:"-" gorm:"type:text"`
+	HTMLBody     string     `json:"-" gorm:"type:text"`
+	Template     string     `json:"template" gorm:"size:50"`              // 渲染使用的模板，直接写入的邮件为空
+	Status       string     `json:"status" gorm:"not null;size:20;index"` // 任务状态
+	Attempts     int        `json:"attempts" gorm:"default:0"`            // 已执行次数
+	MaxAttempts  int        `json:"max_attempts" gorm:"default:6"`        // 最大执行次数
+	LastError    string     `json:"last_error" gorm:"size:1000"`          // 最近一次失败的原因
+	SMTPConfigID *uint      `json:"smtp_config_id"`                       // 发送成功使用的SMTP配置
+	NextRunAt    time.Time  `json:"next_run_at" gorm:"index"`             // 下次执行时间
+	LockedAt     *time.Time `json:"locked_at"`                            // 开始执行的时间，用于回收中断的任务
+	SentAt       *time.Time `json:"sent_at"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+}
+
+// MailDelivery 邮件通过某个SMTP配置的一次投递尝试
+type MailDelivery struct {
+	ID             uint      `json:"id" gorm:"primaryKey"`
+	JobID          uint      `json:"job_id" gorm:"not null;index"`
+	Attempt        int       `json:"attempt"` // 所属任务的第几次执行，同一次执行中切换SMTP配置时相同
+	SMTPConfigID   uint      `json:"smtp_config_id" gorm:"index"`
+	SMTPConfigName string    `json:"smtp_config_name" gorm:"size:100"`
+	Recipient      string    `json:"recipient" gorm:"size:255"`
+	Status         string    `json:"status" gorm:"not null;size:20;index"`
+	Error          string    `json:"error" gorm:"size:1000"` // SMTP服务器返回的错误
+	DurationMs     int64     `json:"duration_ms"`
+	CreatedAt      time.Time `json:"created_at" gorm:"index"`
+	Job            *MailJob  `json:"job,omitempty" gorm:"foreignKey:JobID"`
+}
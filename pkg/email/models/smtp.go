@@ -19,6 +19,7 @@ type SMTPConfig struct {
 	IsActive    bool           `json:"is_active" gorm:"default:false;index"`             // 是否启用
 	IsDefault   bool           `json:"is_default" gorm:"default:false"`                  // 是否为默认配置
 	UseTLS      bool           `json:"use_tls" gorm:"default:true"`                      // 是否使用TLS
+	SortOrder   int            `json:"sort_order" gorm:"default:0"`                      // 排序字段，发送失败时按该顺序切换到下一个配置
 	Description string         `json:"description" gorm:"size:500"`                      // 配置描述
 	LastTestAt  *time.Time     `json:"last_test_at"`                                     // 最后测试时间
 	TestResult  string         `json:"test_result" gorm:"size:1000"`                     // 测试结果
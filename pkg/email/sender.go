@@ -0,0 +1,195 @@
+package email
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"time"
+
+	"domain-max/pkg/email/models"
+)
+
+// ImplicitTLSPort 使用隐式TLS（SMTPS）的端口，UseTLS为true时该端口直接建立TLS连接，其他端口使用STARTTLS
+const ImplicitTLSPort = 465
+
+// sendTimeout 连接并发送一封邮件的超时时间
+const sendTimeout = 30 * time.Second
+
+// Message 一封邮件，Text和HTML至少填写一个，同时填写时作为multipart/alternative发送
+type Message struct {
+	To      string
+	Subject string
+	Text    string
+	HTML    string
+}
+
+// Send 使用SMTP配置发送邮件
+//
+// UseTLS为true时，465端口使用隐式TLS，其他端口要求服务器支持STARTTLS；
+// 为false时使用明文连接，可用于本地的SMTP测试服务器。服务器不支持AUTH时跳过认证。
+func Send(config *models.SMTPConfig, msg *Message) error {
+	if msg.To == "" {
+		return errors.New("收件人不能为空")
+	}
+	data, err := buildMessage(config, msg)
+	if err != nil {
+		return err
+	}
+
+	client, err := dial(config)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	if config.Username != "" {
+		if ok, _ := client.Extension("AUTH"); ok {
+			if err := client.Auth(smtp.PlainAuth("", config.Username, config.Password, config.Host)); err != nil {
+				return fmt.Errorf("SMTP认证失败: %v", err)
+			}
+		}
+	}
+
+	if err := client.Mail(config.FromEmail); err != nil {
+		return fmt.Errorf("设置发件人失败: %v", err)
+	}
+	if err := client.Rcpt(msg.To); err != nil {
+		return fmt.Errorf("设置收件人失败: %v", err)
+	}
+	w, err := client.Data()
+	if err != nil {
+		return fmt.Errorf("发送邮件内容失败: %v", err)
+	}
+	if _, err := w.Write(data); err != nil {
+		return fmt.Errorf("发送邮件内容失败: %v", err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("发送邮件内容失败: %v", err)
+	}
+	return client.Quit()
+}
+
+// dial 连接SMTP服务器并按配置建立TLS
+func dial(config *models.SMTPConfig) (*smtp.Client, error) {
+	addr := net.JoinHostPort(config.Host, strconv.Itoa(config.Port))
+	tlsConfig := &tls.Config{ServerName: config.Host}
+	implicitTLS := config.UseTLS && config.Port == ImplicitTLSPort
+
+	dialer := &net.Dialer{Timeout: sendTimeout}
+	var (
+		conn net.Conn
+		err  error
+	)
+	if implicitTLS {
+		conn, err = tls.DialWithDialer(dialer, "tcp", addr, tlsConfig)
+	} else {
+		conn, err = dialer.Dial("tcp", addr)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("连接SMTP服务器失败: %v", err)
+	}
+	conn.SetDeadline(time.Now().Add(sendTimeout))
+
+	client, err := smtp.NewClient(conn, config.Host)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("连接SMTP服务器失败: %v", err)
+	}
+
+	if config.UseTLS && !implicitTLS {
+		if ok, _ := client.Extension("STARTTLS"); !ok {
+			client.Close()
+			return nil, errors.New("SMTP服务器不支持STARTTLS")
+		}
+		if err := client.StartTLS(tlsConfig); err != nil {
+			client.Close()
+			return nil, fmt.Errorf("启用TLS失败: %v", err)
+		}
+	}
+	return client, nil
+}
+
+// buildMessage 生成UTF-8编码的邮件，正文使用quoted-printable编码
+func buildMessage(config *models.SMTPConfig, msg *Message) ([]byte, error) {
+	if msg.Text == "" && msg.HTML == "" {
+		return nil, errors.New("邮件内容不能为空")
+	}
+	from := mail.Address{Name: config.FromName, Address: config.FromEmail}
+
+	var b bytes.Buffer
+	b.WriteString("From: " + from.String() + "\r\n")
+	b.WriteString("To: " + msg.To + "\r\n")
+	b.WriteString("Subject: " + mime.BEncoding.Encode("UTF-8", msg.Subject) + "\r\n")
+	b.WriteString("Date: " + time.Now().Format(time.RFC1123Z) + "\r\n")
+	b.WriteString("Message-ID: " + messageID(config.FromEmail) + "\r\n")
+	b.WriteString("MIME-Version: 1.0\r\n")
+
+	if msg.Text == "" || msg.HTML == "" {
+		contentType, body := "text/plain", msg.Text
+		if msg.HTML != "" {
+			contentType, body = "text/html", msg.HTML
+		}
+		b.WriteString("Content-Type: " + contentType + "; charset=UTF-8\r\n")
+		b.WriteString("Content-Transfer-Encoding: quoted-printable\r\n\r\n")
+		if err := writeQuotedPrintable(&b, body); err != nil {
+			return nil, err
+		}
+		return b.Bytes(), nil
+	}
+
+	mw := multipart.NewWriter(&b)
+	b.WriteString("Content-Type: multipart/alternative; boundary=" + mw.Boundary() + "\r\n\r\n")
+	for _, part := range []struct{ contentType, body string }{
+		{"text/plain", msg.Text},
+		{"text/html", msg.HTML},
+	} {
+		w, err := mw.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part.contentType + "; charset=UTF-8"},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return nil, err
+		}
+		if err := writeQuotedPrintable(w, part.body); err != nil {
+			return nil, err
+		}
+	}
+	if err := mw.Close(); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
+
+// writeQuotedPrintable 以CRLF换行写入quoted-printable编码的正文
+func writeQuotedPrintable(w io.Writer, body string) error {
+	qp := quotedprintable.NewWriter(w)
+	body = strings.ReplaceAll(strings.ReplaceAll(body, "\r\n", "\n"), "\n", "\r\n")
+	if _, err := qp.Write([]byte(body)); err != nil {
+		return err
+	}
+	return qp.Close()
+}
+
+// messageID 生成使用发件人域名的Message-ID
+func messageID(from string) string {
+	domain := "localhost"
+	if i := strings.LastIndex(from, "@"); i >= 0 {
+		domain = from[i+1:]
+	}
+	buf := make([]byte, 12)
+	rand.Read(buf)
+	return fmt.Sprintf("<%d.%s@%s>", time.Now().UnixNano(), hex.EncodeToString(buf), domain)
+}
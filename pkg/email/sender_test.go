@@ -0,0 +1,280 @@
+package email
+
+import (
+	"bytes"
+	"encoding/base64"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/textproto"
+	"strings"
+	"sync"
+	"testing"
+
+	"domain-max/pkg/email/models"
+)
+
+// received SMTP服务器收到的一封邮件
+type received struct {
+	from string
+	to   string
+	data []byte
+}
+
+// fakeSMTP 只支持AUTH PLAIN的SMTP服务器，拒绝发往rejected的邮件
+type fakeSMTP struct {
+	addr     *net.TCPAddr
+	rejected string
+
+	mu       sync.Mutex
+	messages []received
+}
+
+func newFakeSMTP(t *testing.T) *fakeSMTP {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("监听失败: %v", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	f := &fakeSMTP{addr: listener.Addr().(*net.TCPAddr), rejected: "blocked@example.com"}
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go f.serve(conn)
+		}
+	}()
+	return f
+}
+
+func (f *fakeSMTP) serve(conn net.Conn) {
+	defer conn.Close()
+	tp := textproto.NewConn(conn)
+	tp.PrintfLine("220 localhost ESMTP")
+
+	var msg received
+	for {
+		line, err := tp.ReadLine()
+		if err != nil {
+			return
+		}
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		switch strings.ToUpper(fields[0]) {
+		case "EHLO":
+			tp.PrintfLine("250-localhost")
+			tp.PrintfLine("250 AUTH PLAIN")
+		case "AUTH":
+			want := base64.StdEncoding.EncodeToString([]byte("\x00user\x00password"))
+			if len(fields) == 3 && fields[2] == want {
+				tp.PrintfLine("235 Authentication successful")
+			} else {
+				tp.PrintfLine("535 Authentication failed")
+			}
+		case "MAIL":
+			msg = received{from: smtpAddress(line)}
+			tp.PrintfLine("250 OK")
+		case "RCPT":
+			if to := smtpAddress(line); to == f.rejected {
+				tp.PrintfLine("550 Mailbox unavailable")
+			} else {
+				msg.to = to
+				tp.PrintfLine("250 OK")
+			}
+		case "DATA":
+			tp.PrintfLine("354 End data with <CR><LF>.<CR><LF>")
+			msg.data, err = tp.ReadDotBytes()
+			if err != nil {
+				return
+			}
+			f.mu.Lock()
+			f.messages = append(f.messages, msg)
+			f.mu.Unlock()
+			tp.PrintfLine("250 OK")
+		case "QUIT":
+			tp.PrintfLine("221 Bye")
+			return
+		default:
+			tp.PrintfLine("502 Command not implemented")
+		}
+	}
+}
+
+// smtpAddress 取出MAIL和RCPT命令中尖括号内的地址
+func smtpAddress(line string) string {
+	start, end := strings.Index(line, "<"), strings.LastIndex(line, ">")
+	if start < 0 || end < start {
+		return ""
+	}
+	return line[start+1 : end]
+}
+
+// last 返回最后收到的邮件
+func (f *fakeSMTP) last(t *testing.T) received {
+	t.Helper()
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if len(f.messages) == 0 {
+		t.Fatal("服务器没有收到邮件")
+	}
+	return f.messages[len(f.messages)-1]
+}
+
+// config 返回连接该服务器的SMTP配置，使用明文连接
+func (f *fakeSMTP) config(password string) *models.SMTPConfig {
+	return &models.SMTPConfig{
+		Host:      f.addr.IP.String(),
+		Port:      f.addr.Port,
+		Username:  "user",
+		Password:  password,
+		FromEmail: "noreply@example.com",
+		FromName:  "Domain MAX",
+	}
+}
+
+func TestSend(t *testing.T) {
+	server := newFakeSMTP(t)
+
+	tests := []struct {
+		name string
+		msg  Message
+		want map[string]string // 各内容类型的正文
+	}{
+		{
+			name: "text",
+			msg:  Message{To: "user@example.com", Subject: "验证您的邮箱", Text: "您好，\n请点击链接完成验证。"},
+			want: map[string]string{"text/plain": "您好，\n请点击链接完成验证。"},
+		},
+		{
+			name: "html",
+			msg:  Message{To: "user@example.com", Subject: "Reset", HTML: "<p>reset</p>"},
+			want: map[string]string{"text/html": "<p>reset</p>"},
+		},
+		{
+			name: "text and html",
+			msg:  Message{To: "user@example.com", Subject: "重置密码", Text: "reset", HTML: "<p>reset</p>"},
+			want: map[string]string{"text/plain": "reset", "text/html": "<p>reset</p>"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := Send(server.config("password"), &tt.msg); err != nil {
+				t.Fatalf("发送失败: %v", err)
+			}
+
+			got := server.last(t)
+			if got.from != "noreply@example.com" || got.to != tt.msg.To {
+				t.Fatalf("发件人为 %s，收件人为 %s", got.from, got.to)
+			}
+			parsed, err := mail.ReadMessage(bytes.NewReader(got.data))
+			if err != nil {
+				t.Fatalf("解析邮件失败: %v", err)
+			}
+			if subject, _ := new(mime.WordDecoder).DecodeHeader(parsed.Header.Get("Subject")); subject != tt.msg.Subject {
+				t.Fatalf("主题为 %q", subject)
+			}
+			if from := parsed.Header.Get("From"); from != `"Domain MAX" <noreply@example.com>` {
+				t.Fatalf("发件人为 %s", from)
+			}
+			if bodies := readBodies(t, parsed); !sameBodies(bodies, tt.want) {
+				t.Fatalf("正文为 %q，应为 %q", bodies, tt.want)
+			}
+		})
+	}
+}
+
+func TestSendErrors(t *testing.T) {
+	server := newFakeSMTP(t)
+
+	tests := []struct {
+		name   string
+		config func() *models.SMTPConfig
+		to     string
+		want   string
+	}{
+		{"wrong password", func() *models.SMTPConfig { return server.config("wrong") }, "user@example.com", "SMTP认证失败"},
+		{"rejected recipient", func() *models.SMTPConfig { return server.config("password") }, server.rejected, "设置收件人失败"},
+		{"STARTTLS unsupported", func() *models.SMTPConfig {
+			config := server.config("password")
+			config.UseTLS = true
+			return config
+		}, "user@example.com", "不支持STARTTLS"},
+		{"connection refused", func() *models.SMTPConfig {
+			listener, _ := net.Listen("tcp", "127.0.0.1:0")
+			port := listener.Addr().(*net.TCPAddr).Port
+			listener.Close()
+			config := server.config("password")
+			config.Port = port
+			return config
+		}, "user@example.com", "连接SMTP服务器失败"},
+		{"empty recipient", func() *models.SMTPConfig { return server.config("password") }, "", "收件人不能为空"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Send(tt.config(), &Message{To: tt.to, Subject: "test", Text: "test"})
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("返回的错误为 %v，应包含 %s", err, tt.want)
+			}
+		})
+	}
+}
+
+// readBodies 按内容类型读取解码后的正文，ReadDotBytes已将CRLF转换为LF
+func readBodies(t *testing.T, msg *mail.Message) map[string]string {
+	t.Helper()
+
+	mediaType, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	if err != nil {
+		t.Fatalf("解析Content-Type失败: %v", err)
+	}
+	bodies := map[string]string{}
+	if mediaType != "multipart/alternative" {
+		body, err := io.ReadAll(quotedprintable.NewReader(msg.Body))
+		if err != nil {
+			t.Fatalf("读取正文失败: %v", err)
+		}
+		// DATA结束前补充的换行不属于正文
+		bodies[mediaType] = strings.TrimSuffix(string(body), "\n")
+		return bodies
+	}
+
+	// multipart会自动解码quoted-printable
+	mr := multipart.NewReader(msg.Body, params["boundary"])
+	for {
+		part, err := mr.NextPart()
+		if err == io.EOF {
+			return bodies
+		}
+		if err != nil {
+			t.Fatalf("读取正文失败: %v", err)
+		}
+		partType, _, _ := mime.ParseMediaType(part.Header.Get("Content-Type"))
+		body, _ := io.ReadAll(part)
+		bodies[partType] = strings.ReplaceAll(string(body), "\r\n", "\n")
+	}
+}
+
+func sameBodies(got, want map[string]string) bool {
+	if len(got) != len(want) {
+		return false
+	}
+	for k, v := range want {
+		if got[k] != v {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,66 @@
+package email
+
+import (
+	"errors"
+
+	"domain-max/pkg/email/models"
+
+	"gorm.io/gorm"
+)
+
+// ErrNoSMTPConfig 没有启用的SMTP配置
+var ErrNoSMTPConfig = errors.New("没有启用的SMTP配置")
+
+// Service 邮件服务，使用启用的默认SMTP配置发送邮件
+type Service struct {
+	db *gorm.DB
+}
+
+// NewService 创建邮件服务
+func NewService(db *gorm.DB) *Service {
+	return &Service{db: db}
+}
+
+// Send 使用默认SMTP配置发送邮件
+func (s *Service) Send(msg *Message) error {
+	config, err := DefaultConfig(s.db)
+	if err != nil {
+		return err
+	}
+	return Send(config, msg)
+}
+
+// SendTemplate 使用指定语言渲染模板并发送给to
+func (s *Service) SendTemplate(to, name, locale string, data interface{}) error {
+	msg, err := Render(name, locale, data)
+	if err != nil {
+		return err
+	}
+	msg.To = to
+	return s.Send(msg)
+}
+
+// DefaultConfig 返回发送邮件时首先使用的SMTP配置，即ActiveConfigs中的第一个
+func DefaultConfig(db *gorm.DB) (*models.SMTPConfig, error) {
+	configs, err := ActiveConfigs(db)
+	if err != nil {
+		return nil, err
+	}
+	return &configs[0], nil
+}
+
+// ActiveConfigs 按优先级返回启用的SMTP配置：默认配置在前，其余按排序字段和创建顺序
+//
+// 没有启用的配置时返回ErrNoSMTPConfig。
+func ActiveConfigs(db *gorm.DB) ([]models.SMTPConfig, error) {
+	var configs []models.SMTPConfig
+	if err := db.Where("is_active = ?", true).
+		Order("is_default DESC").Order("sort_order ASC").Order("id ASC").
+		Find(&configs).Error; err != nil {
+		return nil, err
+	}
+	if len(configs) == 0 {
+		return nil, ErrNoSMTPConfig
+	}
+	return configs, nil
+}
@@ -0,0 +1,180 @@
+package email
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	htmltemplate "html/template"
+	"io/fs"
+	"path"
+	"strings"
+	texttemplate "text/template"
+	"time"
+)
+
+// DefaultLocale 默认的邮件语言，其他语言缺少模板时使用该语言
+const DefaultLocale = "zh-CN"
+
+// 邮件模板名称
+const (
+	TemplateVerification  = "verification"   // 邮箱验证，数据为VerificationData
+	TemplatePasswordReset = "password_reset" // 密码重置，数据为PasswordResetData
+	TemplateRecordChange  = "record_change"  // DNS记录变更通知，数据为RecordChangeData
+	TemplateTest          = "test"           // SMTP配置测试，数据为TestData
+)
+
+// VerificationData 邮箱验证邮件的数据
+type VerificationData struct {
+	Name       string
+	Link       string
+	ValidHours int
+}
+
+// PasswordResetData 密码重置邮件的数据
+type PasswordResetData struct {
+	Name         string
+	Link         string
+	ValidMinutes int
+}
+
+// RecordChangeData DNS记录变更通知的数据，Action为create、update或delete
+type RecordChangeData struct {
+	Name      string
+	Action    string
+	Domain    string
+	Record    string // 记录的完整域名
+	Type      string
+	Value     string
+	ChangedAt time.Time
+}
+
+// TestData SMTP配置测试邮件的数据
+type TestData struct {
+	ConfigName string
+	Host       string
+	Port       int
+	SentAt     time.Time
+}
+
+// templates/<语言>/<模板>.txt 为纯文本正文，并以 {{define "subject"}} 定义邮件主题；
+// 同名的 .html 文件为HTML正文，可以省略。
+//
+//go:embed templates
+var templateFS embed.FS
+
+// localizedTemplate 一种语言的邮件模板
+type localizedTemplate struct {
+	text *texttemplate.Template
+	html *htmltemplate.Template
+}
+
+// templates 按语言和模板名称索引的邮件模板
+var templates = mustLoadTemplates()
+
+// Render 使用指定语言渲染邮件模板，返回的邮件未填写收件人
+//
+// 不支持的语言或该语言缺少模板时使用DefaultLocale。
+func Render(name, locale string, data interface{}) (*Message, error) {
+	tmpl, ok := templates[locale][name]
+	if !ok {
+		tmpl, ok = templates[DefaultLocale][name]
+	}
+	if !ok {
+		return nil, fmt.Errorf("邮件模板 %s 不存在", name)
+	}
+
+	var subject, text bytes.Buffer
+	if err := tmpl.text.ExecuteTemplate(&subject, "subject", data); err != nil {
+		return nil, fmt.Errorf("渲染邮件模板 %s 失败: %v", name, err)
+	}
+	if err := tmpl.text.Execute(&text, data); err != nil {
+		return nil, fmt.Errorf("渲染邮件模板 %s 失败: %v", name, err)
+	}
+	msg := &Message{
+		Subject: strings.TrimSpace(subject.String()),
+		Text:    strings.TrimLeft(text.String(), "\n"),
+	}
+
+	if tmpl.html != nil {
+		var html bytes.Buffer
+		if err := tmpl.html.Execute(&html, data); err != nil {
+			return nil, fmt.Errorf("渲染邮件模板 %s 失败: %v", name, err)
+		}
+		msg.HTML = html.String()
+	}
+	return msg, nil
+}
+
+// Locales 返回支持的邮件语言
+func Locales() []string {
+	locales := make([]string, 0, len(templates))
+	for locale := range templates {
+		locales = append(locales, locale)
+	}
+	return locales
+}
+
+// MatchLocale 按Accept-Language请求头选择支持的邮件语言，忽略权重按出现顺序匹配
+//
+// 先完整匹配语言标签（如en-US），再按主语言匹配（如zh-TW匹配zh-CN），都不匹配时返回DefaultLocale。
+func MatchLocale(acceptLanguage string) string {
+	for _, tag := range strings.Split(acceptLanguage, ",") {
+		tag = strings.TrimSpace(strings.SplitN(tag, ";", 2)[0])
+		if tag == "" || tag == "*" {
+			continue
+		}
+		for locale := range templates {
+			if strings.EqualFold(locale, tag) {
+				return locale
+			}
+		}
+		base := strings.SplitN(tag, "-", 2)[0]
+		for locale := range templates {
+			if strings.EqualFold(strings.SplitN(locale, "-", 2)[0], base) {
+				return locale
+			}
+		}
+	}
+	return DefaultLocale
+}
+
+// mustLoadTemplates 解析嵌入的全部邮件模板，模板有误时panic
+func mustLoadTemplates() map[string]map[string]*localizedTemplate {
+	dirs, err := fs.ReadDir(templateFS, "templates")
+	if err != nil {
+		panic(err)
+	}
+
+	loaded := make(map[string]map[string]*localizedTemplate)
+	for _, dir := range dirs {
+		if !dir.IsDir() {
+			continue
+		}
+		locale := dir.Name()
+		files, err := fs.Glob(templateFS, path.Join("templates", locale, "*.txt"))
+		if err != nil {
+			panic(err)
+		}
+
+		loaded[locale] = make(map[string]*localizedTemplate)
+		for _, file := range files {
+			name := strings.TrimSuffix(path.Base(file), ".txt")
+			tmpl := &localizedTemplate{
+				text: texttemplate.Must(texttemplate.ParseFS(templateFS, file)),
+			}
+			if tmpl.text.Lookup("subject") == nil {
+				panic(fmt.Sprintf("邮件模板 %s 缺少subject定义", file))
+			}
+
+			htmlFile := strings.TrimSuffix(file, ".txt") + ".html"
+			if _, err := fs.Stat(templateFS, htmlFile); err == nil {
+				tmpl.html = htmltemplate.Must(htmltemplate.ParseFS(templateFS, htmlFile))
+			}
+			loaded[locale][name] = tmpl
+		}
+	}
+	if len(loaded[DefaultLocale]) == 0 {
+		panic("缺少默认语言 " + DefaultLocale + " 的邮件模板")
+	}
+	return loaded
+}
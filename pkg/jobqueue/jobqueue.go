@@ -0,0 +1,101 @@
+// Package jobqueue 数据库任务队列的公共逻辑：读取到期任务、认领任务、回收中断的任务和失败后的退避重试
+//
+// 任务表需包含 status、attempts、next_run_at、locked_at 和 last_error 列，
+// 状态使用本包定义的取值，其余状态由各队列自行定义。
+package jobqueue
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// 认领和重试使用的任务状态
+const (
+	StatusPending = "pending" // 等待执行或等待重试
+	StatusRunning = "running" // 执行中
+	StatusFailed  = "failed"  // 达到最大执行次数
+)
+
+const (
+	// StaleTimeout 执行中的任务超过该时间未完成时视为中断，重新执行
+	StaleTimeout = 10 * time.Minute
+	// baseBackoff 第一次重试的等待时间，之后每次翻倍
+	baseBackoff = 30 * time.Second
+	// maxBackoff 重试等待时间的上限
+	maxBackoff = time.Hour
+)
+
+// FindDue 按ID顺序读取ID大于afterID的到期任务，dest为任务切片的指针
+func FindDue(db *gorm.DB, dest interface{}, afterID uint, limit int) error {
+	return db.Where("status = ? AND next_run_at <= ? AND id > ?", StatusPending, time.Now(), afterID).
+		Order("id").Limit(limit).Find(dest).Error
+}
+
+// Claim 认领任务并增加执行次数，返回认领时间；任务已被其他实例认领时返回false
+//
+// model为任务表对应的模型，如 &models.SyncJob{}。
+func Claim(db *gorm.DB, model interface{}, id uint) (time.Time, bool, error) {
+	now := time.Now()
+	result := db.Model(model).
+		Where("id = ? AND status = ?", id, StatusPending).
+		Updates(map[string]interface{}{
+			"status":    StatusRunning,
+			"attempts":  gorm.Expr("attempts + 1"),
+			"locked_at": now,
+		})
+	if result.Error != nil {
+		return now, false, result.Error
+	}
+	return now, result.RowsAffected > 0, nil
+}
+
+// Release 将已认领但未执行的任务放回队列，不计入执行次数
+func Release(db *gorm.DB, model interface{}, id uint) error {
+	return db.Model(model).Where("id = ?", id).Updates(map[string]interface{}{
+		"status":    StatusPending,
+		"attempts":  gorm.Expr("attempts - 1"),
+		"locked_at": nil,
+	}).Error
+}
+
+// RecoverStale 将中断的任务重新放回队列
+func RecoverStale(db *gorm.DB, model interface{}) error {
+	return db.Model(model).
+		Where("status = ? AND locked_at < ?", StatusRunning, time.Now().Add(-StaleTimeout)).
+		Updates(map[string]interface{}{
+			"status":      StatusPending,
+			"locked_at":   nil,
+			"next_run_at": time.Now(),
+		}).Error
+}
+
+// Failure 返回第attempts次执行失败后需要更新的任务字段
+//
+// 未达到最大执行次数时任务按指数退避等待重试，否则标记为失败，exhausted为true。
+func Failure(attempts, maxAttempts int, lastError string) (updates map[string]interface{}, exhausted bool) {
+	exhausted = attempts >= maxAttempts
+	updates = map[string]interface{}{
+		"last_error": lastError,
+		"locked_at":  nil,
+	}
+	if exhausted {
+		updates["status"] = StatusFailed
+	} else {
+		updates["status"] = StatusPending
+		updates["next_run_at"] = time.Now().Add(Backoff(attempts))
+	}
+	return updates, exhausted
+}
+
+// Backoff 返回第attempts次失败后的重试等待时间
+func Backoff(attempts int) time.Duration {
+	d := baseBackoff
+	for i := 1; i < attempts; i++ {
+		d *= 2
+		if d >= maxBackoff {
+			return maxBackoff
+		}
+	}
+	return d
+}
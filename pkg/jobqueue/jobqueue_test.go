@@ -0,0 +1,68 @@
+package jobqueue
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBackoff(t *testing.T) {
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{attempts: 1, want: 30 * time.Second},
+		{attempts: 2, want: time.Minute},
+		{attempts: 3, want: 2 * time.Minute},
+		{attempts: 7, want: 32 * time.Minute},
+		{attempts: 9, want: time.Hour},
+		{attempts: 100, want: time.Hour},
+	}
+
+	for _, tt := range tests {
+		if got := Backoff(tt.attempts); got != tt.want {
+			t.Errorf("Backoff(%d) = %v，应为 %v", tt.attempts, got, tt.want)
+		}
+	}
+}
+
+func TestFailure(t *testing.T) {
+	tests := []struct {
+		name      string
+		attempts  int
+		exhausted bool
+	}{
+		{name: "retry", attempts: 1},
+		{name: "last retry", attempts: 5},
+		{name: "exhausted", attempts: 6, exhausted: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			before := time.Now()
+			updates, exhausted := Failure(tt.attempts, 6, "boom")
+			if exhausted != tt.exhausted {
+				t.Fatalf("exhausted = %v，应为 %v", exhausted, tt.exhausted)
+			}
+			if updates["last_error"] != "boom" || updates["locked_at"] != nil {
+				t.Fatalf("更新的字段为 %v", updates)
+			}
+
+			if tt.exhausted {
+				if updates["status"] != StatusFailed {
+					t.Fatalf("状态为 %v，应为 %q", updates["status"], StatusFailed)
+				}
+				if _, ok := updates["next_run_at"]; ok {
+					t.Fatalf("失败的任务不应安排重试: %v", updates)
+				}
+				return
+			}
+			if updates["status"] != StatusPending {
+				t.Fatalf("状态为 %v，应为 %q", updates["status"], StatusPending)
+			}
+			next, ok := updates["next_run_at"].(time.Time)
+			if !ok || next.Before(before.Add(Backoff(tt.attempts))) {
+				t.Fatalf("下次执行时间为 %v", updates["next_run_at"])
+			}
+		})
+	}
+}
@@ -5,11 +5,28 @@ import (
 	"strings"
 
 	"github.com/gin-gonic/gin"
-	"github.com/golang-jwt/jwt/v5"
 )
 
-// AuthMiddleware JWT认证中间件
-func AuthMiddleware(jwtSecret string) gin.HandlerFunc {
+// identityKey 请求上下文中保存已认证身份的键
+const identityKey = "identity"
+
+// CurrentUser 返回AuthMiddleware写入请求上下文的已认证身份，未认证时返回false
+func CurrentUser(c *gin.Context) (*Identity, bool) {
+	value, exists := c.Get(identityKey)
+	if !exists {
+		return nil, false
+	}
+	identity, ok := value.(*Identity)
+	return identity, ok && identity != nil
+}
+
+// RevocationChecker 按jti判断登录令牌是否已被吊销
+type RevocationChecker interface {
+	IsRevoked(jti string) (bool, error)
+}
+
+// AuthMiddleware JWT认证中间件，revocations为空时不检查令牌是否已被吊销
+func AuthMiddleware(jwtSecret string, revocations RevocationChecker) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		// 从Authorization头获取token
 		authHeader := c.GetHeader("Authorization")
@@ -28,30 +45,28 @@ func AuthMiddleware(jwtSecret string) gin.HandlerFunc {
 		}
 		
 		// 解析JWT token
-		token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-			// 验证签名方法
-			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-				return nil, jwt.ErrSignatureInvalid
-			}
-			return []byte(jwtSecret), nil
-		})
-		
-		if err != nil || !token.Valid {
+		identity, err := ParseAccessToken(jwtSecret, tokenString)
+		if err != nil {
 			c.JSON(http.StatusUnauthorized, gin.H{"error": "无效的认证令牌"})
 			c.Abort()
 			return
 		}
 		
-		// 获取claims
-		if claims, ok := token.Claims.(jwt.MapClaims); ok {
-			c.Set("user_id", claims["user_id"])
-			c.Set("email", claims["email"])
-			c.Set("is_admin", claims["is_admin"])
-		} else {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "无效的令牌声明"})
-			c.Abort()
-			return
+		// 检查令牌是否已退出登录或被吊销
+		if revocations != nil {
+			revoked, err := revocations.IsRevoked(identity.TokenID)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "验证认证令牌失败"})
+				c.Abort()
+				return
+			}
+			if revoked {
+				c.JSON(http.StatusUnauthorized, gin.H{"error": "认证令牌已失效，请重新登录"})
+				c.Abort()
+				return
+			}
 		}
+		c.Set(identityKey, identity)
 		
 		c.Next()
 	}
@@ -60,8 +75,8 @@ func AuthMiddleware(jwtSecret string) gin.HandlerFunc {
 // AdminMiddleware 管理员权限中间件
 func AdminMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		isAdmin, exists := c.Get("is_admin")
-		if !exists || !isAdmin.(bool) {
+		identity, ok := CurrentUser(c)
+		if !ok || !identity.IsAdmin {
 			c.JSON(http.StatusForbidden, gin.H{"error": "需要管理员权限"})
 			c.Abort()
 			return
@@ -0,0 +1,112 @@
+package middleware
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	// TokenIssuer 登录令牌的签发者
+	TokenIssuer = "domain-max"
+	// TokenAudience 登录令牌的受众
+	TokenAudience = "domain-max-api"
+	// AccessTokenTTL 登录令牌的有效期，过期后使用刷新令牌换取新的登录令牌
+	AccessTokenTTL = 15 * time.Minute
+)
+
+// Claims 登录令牌的声明，Subject为用户ID，ID为令牌的唯一标识
+type Claims struct {
+	Email   string `json:"email"`
+	IsAdmin bool   `json:"is_admin"`
+	jwt.RegisteredClaims
+}
+
+// Identity 已认证用户的身份
+type Identity struct {
+	UserID    uint
+	Email     string
+	IsAdmin   bool
+	TokenID   string    // 令牌的jti
+	ExpiresAt time.Time // 令牌的过期时间
+}
+
+// SignAccessToken 为用户签发登录令牌，返回令牌及其对应的身份
+func SignAccessToken(secret string, userID uint, email string, isAdmin bool) (string, *Identity, error) {
+	jti, err := newTokenID()
+	if err != nil {
+		return "", nil, err
+	}
+
+	now := time.Now()
+	claims := Claims{
+		Email:   email,
+		IsAdmin: isAdmin,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    TokenIssuer,
+			Audience:  jwt.ClaimStrings{TokenAudience},
+			Subject:   strconv.FormatUint(uint64(userID), 10),
+			ID:        jti,
+			IssuedAt:  jwt.NewNumericDate(now),
+			NotBefore: jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(AccessTokenTTL)),
+		},
+	}
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(secret))
+	if err != nil {
+		return "", nil, err
+	}
+	return token, &Identity{
+		UserID:    userID,
+		Email:     email,
+		IsAdmin:   isAdmin,
+		TokenID:   jti,
+		ExpiresAt: claims.ExpiresAt.Time,
+	}, nil
+}
+
+// ParseAccessToken 验证登录令牌的签名、签发者、受众和有效期，返回令牌中的身份
+func ParseAccessToken(secret, tokenString string) (*Identity, error) {
+	var claims Claims
+	_, err := jwt.ParseWithClaims(tokenString, &claims, func(token *jwt.Token) (interface{}, error) {
+		return []byte(secret), nil
+	},
+		jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}),
+		jwt.WithIssuer(TokenIssuer),
+		jwt.WithAudience(TokenAudience),
+	)
+	if err != nil {
+		return nil, err
+	}
+	if claims.ExpiresAt == nil {
+		return nil, errors.New("令牌缺少过期时间")
+	}
+
+	userID, err := strconv.ParseUint(claims.Subject, 10, 64)
+	if err != nil || userID == 0 {
+		return nil, errors.New("令牌中的用户ID无效")
+	}
+	if claims.ID == "" {
+		return nil, errors.New("令牌缺少jti")
+	}
+	return &Identity{
+		UserID:    uint(userID),
+		Email:     claims.Email,
+		IsAdmin:   claims.IsAdmin,
+		TokenID:   claims.ID,
+		ExpiresAt: claims.ExpiresAt.Time,
+	}, nil
+}
+
+// newTokenID 生成随机的令牌标识
+func newTokenID() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
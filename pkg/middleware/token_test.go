@@ -0,0 +1,145 @@
+package middleware
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const testSecret = "test_jwt_secret_key_for_middleware_tests_only_0123456789abcdefgh"
+
+// validClaims 返回可以通过校验的声明
+func validClaims() Claims {
+	now := time.Now()
+	return Claims{
+		Email: "user@example.com",
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    TokenIssuer,
+			Audience:  jwt.ClaimStrings{TokenAudience},
+			Subject:   "1",
+			ID:        "jti",
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(AccessTokenTTL)),
+		},
+	}
+}
+
+// signHS256 使用密钥签名声明
+func signHS256(t *testing.T, secret string, claims Claims) string {
+	t.Helper()
+
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(secret))
+	if err != nil {
+		t.Fatalf("签名失败: %v", err)
+	}
+	return token
+}
+
+func TestSignAccessToken(t *testing.T) {
+	token, signed, err := SignAccessToken(testSecret, 42, "admin@example.com", true)
+	if err != nil {
+		t.Fatalf("签发令牌失败: %v", err)
+	}
+	identity, err := ParseAccessToken(testSecret, token)
+	if err != nil {
+		t.Fatalf("解析令牌失败: %v", err)
+	}
+	if identity.UserID != 42 || identity.Email != "admin@example.com" || !identity.IsAdmin ||
+		identity.TokenID == "" || identity.TokenID != signed.TokenID || !identity.ExpiresAt.Equal(signed.ExpiresAt) {
+		t.Fatalf("解析的身份为 %+v，签发的身份为 %+v", identity, signed)
+	}
+}
+
+func TestParseAccessTokenRejects(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("生成RSA密钥失败: %v", err)
+	}
+
+	tests := []struct {
+		name  string
+		token func(t *testing.T) string
+	}{
+		{"wrong issuer", func(t *testing.T) string {
+			claims := validClaims()
+			claims.Issuer = "other"
+			return signHS256(t, testSecret, claims)
+		}},
+		{"wrong audience", func(t *testing.T) string {
+			claims := validClaims()
+			claims.Audience = jwt.ClaimStrings{"other-api"}
+			return signHS256(t, testSecret, claims)
+		}},
+		{"missing audience", func(t *testing.T) string {
+			claims := validClaims()
+			claims.Audience = nil
+			return signHS256(t, testSecret, claims)
+		}},
+		{"alg none", func(t *testing.T) string {
+			token, err := jwt.NewWithClaims(jwt.SigningMethodNone, validClaims()).SignedString(jwt.UnsafeAllowNoneSignatureType)
+			if err != nil {
+				t.Fatalf("签名失败: %v", err)
+			}
+			return token
+		}},
+		{"alg RS256", func(t *testing.T) string {
+			token, err := jwt.NewWithClaims(jwt.SigningMethodRS256, validClaims()).SignedString(rsaKey)
+			if err != nil {
+				t.Fatalf("签名失败: %v", err)
+			}
+			return token
+		}},
+		{"alg HS512", func(t *testing.T) string {
+			token, err := jwt.NewWithClaims(jwt.SigningMethodHS512, validClaims()).SignedString([]byte(testSecret))
+			if err != nil {
+				t.Fatalf("签名失败: %v", err)
+			}
+			return token
+		}},
+		{"missing exp", func(t *testing.T) string {
+			claims := validClaims()
+			claims.ExpiresAt = nil
+			return signHS256(t, testSecret, claims)
+		}},
+		{"expired", func(t *testing.T) string {
+			claims := validClaims()
+			claims.ExpiresAt = jwt.NewNumericDate(time.Now().Add(-time.Minute))
+			return signHS256(t, testSecret, claims)
+		}},
+		{"missing jti", func(t *testing.T) string {
+			claims := validClaims()
+			claims.ID = ""
+			return signHS256(t, testSecret, claims)
+		}},
+		{"non-numeric sub", func(t *testing.T) string {
+			claims := validClaims()
+			claims.Subject = "admin"
+			return signHS256(t, testSecret, claims)
+		}},
+		{"zero sub", func(t *testing.T) string {
+			claims := validClaims()
+			claims.Subject = "0"
+			return signHS256(t, testSecret, claims)
+		}},
+		{"different secret", func(t *testing.T) string {
+			return signHS256(t, "another_secret_key_that_is_long_enough_for_hs256_0123456789abcd", validClaims())
+		}},
+		{"malformed", func(*testing.T) string { return "not.a.jwt" }},
+	}
+
+	// 校验用例本身有效，只修改了对应的声明
+	if _, err := ParseAccessToken(testSecret, signHS256(t, testSecret, validClaims())); err != nil {
+		t.Fatalf("有效的令牌解析失败: %v", err)
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if identity, err := ParseAccessToken(testSecret, tt.token(t)); err == nil {
+				t.Fatalf("令牌被接受，身份为 %+v", identity)
+			}
+		})
+	}
+}
@@ -0,0 +1,144 @@
+package utils
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// encryptedSecretPrefix 加密后敏感数据的前缀，用于区分明文
+const encryptedSecretPrefix = "enc:v1:"
+
+// dataKeySize 数据密钥长度（AES-256）
+const dataKeySize = 32
+
+// ParseEncryptionKey 解析64位十六进制的加密密钥
+func ParseEncryptionKey(hexKey string) ([]byte, error) {
+	if err := validateEncryptionKey(hexKey, false); err != nil {
+		return nil, err
+	}
+	return hex.DecodeString(hexKey)
+}
+
+// IsEncryptedSecret 判断值是否为EncryptSecret生成的密文
+func IsEncryptedSecret(value string) bool {
+	return strings.HasPrefix(value, encryptedSecretPrefix)
+}
+
+// EncryptSecret 使用信封加密保护敏感数据
+//
+// 每次加密生成随机的数据密钥，用数据密钥以AES-GCM加密明文，
+// 再用主密钥加密数据密钥。轮换主密钥时只需重新加密数据密钥。
+// 密文格式为"enc:v1:" + Base64(加密的数据密钥 + 明文密文)。
+func EncryptSecret(plaintext string, key []byte) (string, error) {
+	dataKey := make([]byte, dataKeySize)
+	if _, err := rand.Read(dataKey); err != nil {
+		return "", err
+	}
+
+	wrappedKey, err := gcmSeal(key, dataKey)
+	if err != nil {
+		return "", err
+	}
+	sealed, err := gcmSeal(dataKey, []byte(plaintext))
+	if err != nil {
+		return "", err
+	}
+
+	return encryptedSecretPrefix + base64.StdEncoding.EncodeToString(append(wrappedKey, sealed...)), nil
+}
+
+// DecryptSecret 解密EncryptSecret生成的密文
+func DecryptSecret(value string, key []byte) (string, error) {
+	wrappedKey, sealed, err := splitSecret(value)
+	if err != nil {
+		return "", err
+	}
+
+	dataKey, err := gcmOpen(key, wrappedKey)
+	if err != nil {
+		return "", errors.New("解密数据密钥失败，加密密钥可能不正确")
+	}
+	plaintext, err := gcmOpen(dataKey, sealed)
+	if err != nil {
+		return "", errors.New("解密数据失败，密文可能已损坏")
+	}
+	return string(plaintext), nil
+}
+
+// RewrapSecret 使用新的主密钥重新加密数据密钥，数据密文保持不变
+func RewrapSecret(value string, oldKey, newKey []byte) (string, error) {
+	wrappedKey, sealed, err := splitSecret(value)
+	if err != nil {
+		return "", err
+	}
+
+	dataKey, err := gcmOpen(oldKey, wrappedKey)
+	if err != nil {
+		return "", errors.New("解密数据密钥失败，原加密密钥可能不正确")
+	}
+	rewrapped, err := gcmSeal(newKey, dataKey)
+	if err != nil {
+		return "", err
+	}
+
+	return encryptedSecretPrefix + base64.StdEncoding.EncodeToString(append(rewrapped, sealed...)), nil
+}
+
+// splitSecret 拆分密文中加密的数据密钥和数据密文
+func splitSecret(value string) ([]byte, []byte, error) {
+	if !IsEncryptedSecret(value) {
+		return nil, nil, errors.New("不是有效的密文")
+	}
+	data, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(value, encryptedSecretPrefix))
+	if err != nil {
+		return nil, nil, fmt.Errorf("密文格式错误: %v", err)
+	}
+
+	// 加密的数据密钥长度固定为 nonce + 密钥 + 认证标签
+	wrappedLen := 12 + dataKeySize + 16
+	if len(data) <= wrappedLen {
+		return nil, nil, errors.New("密文长度错误")
+	}
+	return data[:wrappedLen], data[wrappedLen:], nil
+}
+
+// gcmSeal 使用AES-GCM加密，返回 nonce + 密文
+func gcmSeal(key, plaintext []byte) ([]byte, error) {
+	aead, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return aead.Seal(nonce, nonce, plaintext, nil), nil
+}
+
+// gcmOpen 解密gcmSeal生成的数据
+func gcmOpen(key, data []byte) ([]byte, error) {
+	aead, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < aead.NonceSize() {
+		return nil, errors.New("密文长度错误")
+	}
+	nonce, ciphertext := data[:aead.NonceSize()], data[aead.NonceSize():]
+	return aead.Open(nil, nonce, ciphertext, nil)
+}
+
+// newGCM 创建AES-GCM加密器
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
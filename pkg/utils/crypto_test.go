@@ -0,0 +1,113 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/base64"
+	"strings"
+	"testing"
+)
+
+// testKey 返回由单个字节重复组成的32字节主密钥
+func testKey(b byte) []byte {
+	return bytes.Repeat([]byte{b}, dataKeySize)
+}
+
+func TestEncryptSecret(t *testing.T) {
+	key := testKey(1)
+
+	for _, plaintext := range []string{"", "secret", "密钥 with spaces\n"} {
+		value, err := EncryptSecret(plaintext, key)
+		if err != nil {
+			t.Fatalf("加密失败: %v", err)
+		}
+		if !IsEncryptedSecret(value) || (plaintext != "" && strings.Contains(value, plaintext)) {
+			t.Fatalf("密文为 %s", value)
+		}
+		got, err := DecryptSecret(value, key)
+		if err != nil {
+			t.Fatalf("解密失败: %v", err)
+		}
+		if got != plaintext {
+			t.Fatalf("解密结果为 %q，应为 %q", got, plaintext)
+		}
+	}
+
+	// 每次加密使用随机的数据密钥和nonce
+	a, _ := EncryptSecret("secret", key)
+	b, _ := EncryptSecret("secret", key)
+	if a == b {
+		t.Fatal("两次加密的密文相同")
+	}
+}
+
+func TestDecryptSecretErrors(t *testing.T) {
+	key := testKey(1)
+	value, err := EncryptSecret("secret", key)
+	if err != nil {
+		t.Fatalf("加密失败: %v", err)
+	}
+
+	// tamper 修改密文中第i个字节，i为负数时从末尾计算
+	tamper := func(i int) string {
+		data, _ := base64.StdEncoding.DecodeString(strings.TrimPrefix(value, encryptedSecretPrefix))
+		if i < 0 {
+			i += len(data)
+		}
+		data[i] ^= 0xff
+		return encryptedSecretPrefix + base64.StdEncoding.EncodeToString(data)
+	}
+
+	tests := []struct {
+		name  string
+		value string
+		key   []byte
+		want  string
+	}{
+		{"wrong key", value, testKey(2), "加密密钥可能不正确"},
+		{"tampered data key", tamper(20), key, "加密密钥可能不正确"},
+		{"tampered data", tamper(-1), key, "密文可能已损坏"},
+		{"plaintext", "secret", key, "不是有效的密文"},
+		{"bad base64", encryptedSecretPrefix + "!!!", key, "密文格式错误"},
+		{"truncated", encryptedSecretPrefix + base64.StdEncoding.EncodeToString([]byte("short")), key, "密文长度错误"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := DecryptSecret(tt.value, tt.key)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("解密结果为 %q，错误为 %v，应包含 %s", got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestRewrapSecret(t *testing.T) {
+	oldKey, newKey := testKey(1), testKey(2)
+	value, err := EncryptSecret("secret", oldKey)
+	if err != nil {
+		t.Fatalf("加密失败: %v", err)
+	}
+
+	rewrapped, err := RewrapSecret(value, oldKey, newKey)
+	if err != nil {
+		t.Fatalf("重新加密失败: %v", err)
+	}
+	if got, err := DecryptSecret(rewrapped, newKey); err != nil || got != "secret" {
+		t.Fatalf("使用新密钥解密结果为 %q: %v", got, err)
+	}
+	if _, err := DecryptSecret(rewrapped, oldKey); err == nil {
+		t.Fatal("重新加密后原密钥仍可解密")
+	}
+
+	// 数据密文保持不变，只替换加密的数据密钥
+	wrappedLen := 12 + dataKeySize + 16
+	before, _ := base64.StdEncoding.DecodeString(strings.TrimPrefix(value, encryptedSecretPrefix))
+	after, _ := base64.StdEncoding.DecodeString(strings.TrimPrefix(rewrapped, encryptedSecretPrefix))
+	if !bytes.Equal(before[wrappedLen:], after[wrappedLen:]) {
+		t.Fatal("重新加密修改了数据密文")
+	}
+
+	if _, err := RewrapSecret(value, testKey(3), newKey); err == nil {
+		t.Fatal("使用错误的原密钥重新加密成功")
+	}
+}
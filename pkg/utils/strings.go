@@ -0,0 +1,10 @@
+package utils
+
+// TruncateRunes 按字符截断字符串，避免超出数据库字段长度
+func TruncateRunes(s string, max int) string {
+	runes := []rune(s)
+	if len(runes) <= max {
+		return s
+	}
+	return string(runes[:max])
+}